@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"davidHwang/ecomm/db"
+	"davidHwang/ecomm/ecomm-grpc/storer"
+	"davidHwang/ecomm/rbac"
+	"davidHwang/ecomm/util"
+	"davidHwang/ecomm/validate"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ianschenck/envflag"
+)
+
+// * ecomm-admin создает первого суперпользователя (is_admin и роль superadmin) напрямую в базе,
+// * потому что через API права администратора может выдать только администратор.
+// * Пароль берется из ECOMM_ADMIN_PASSWORD или читается из первой строки stdin:
+// *
+// *	echo "$PASSWORD" | ecomm-admin -name Admin -email admin@example.com
+type superuserReq struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func main() {
+	var (
+		name  = flag.String("name", "", "name of the superuser")
+		email = flag.String("email", "", "email of the superuser")
+		force = flag.Bool("force", false, "create the superuser even if another admin already exists")
+
+		passwordMinLength    = envflag.Int("PASSWORD_MIN_LENGTH", validate.DefaultMinPasswordLength, "minimum length of the password, must match ecomm-api")
+		passwordBreachedList = envflag.String("PASSWORD_BREACHED_LIST", "", "file with breached or common passwords, one per line, that cannot be used")
+	)
+
+	flag.Parse()
+	envflag.Parse()
+
+	//* пароль суперпользователя проверяется той же политикой, что и пароли пользователей в ecomm-api
+	passwordPolicy, err := validate.NewPasswordPolicy(*passwordMinLength, *passwordBreachedList)
+
+	if err != nil {
+		log.Fatalf("invalid password policy: %v", err)
+	}
+
+	//* те же настройки хэширования, что у ecomm-grpc, иначе пароль пересчитается при первом входе
+	passwordConfig, err := util.PasswordConfigFromEnv()
+
+	if err != nil {
+		log.Fatalf("invalid password hash settings: %v", err)
+	}
+
+	hasher, err := util.NewPasswordHasher(passwordConfig)
+
+	if err != nil {
+		log.Fatalf("invalid password hash settings: %v", err)
+	}
+
+	util.SetPasswordHasher(hasher)
+
+	password, err := readPassword()
+
+	if err != nil {
+		log.Fatalf("error reading password: %v", err)
+	}
+
+	req := superuserReq{Name: *name, Email: *email, Password: password}
+
+	if errs := validate.Struct(req, validate.User, passwordPolicy.Rules("password")); len(errs) > 0 {
+		log.Fatalf("invalid superuser: %v", errs)
+	}
+
+	database, err := db.NewDatabase()
+
+	if err != nil {
+		log.Fatalf("error opening connection to database: %v", err)
+	}
+
+	defer database.Close()
+
+	u, err := createSuperuser(context.Background(), storer.NewMySQLStorer(database.GetDB()), req, *force)
+
+	if err != nil {
+		log.Fatalf("error creating superuser: %v", err)
+	}
+
+	log.Printf("superuser %s created with id %d", u.Email, u.ID)
+}
+
+func readPassword() (string, error) {
+	if password := os.Getenv("ECOMM_ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	if err != nil && line == "" {
+		return "", fmt.Errorf("password is not set: use ECOMM_ADMIN_PASSWORD or stdin: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// * первый суперпользователь создается только если администраторов еще нет (или задан -force)
+func createSuperuser(ctx context.Context, st storer.Storer, req superuserReq, force bool) (*storer.User, error) {
+	if !force {
+		users, err := st.ListUsers(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range users {
+			if u.IsAdmin {
+				return nil, errors.New("an admin already exists, use -force to create another one")
+			}
+		}
+	}
+
+	hashed, err := util.HashPassword(req.Password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.CreateUser(ctx, &storer.User{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: hashed,
+		IsAdmin:  true,
+	}, rbac.RoleSuperadmin)
+}
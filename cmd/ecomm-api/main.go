@@ -1,27 +1,105 @@
 package main
 
 import (
+	"context"
 	"davidHwang/ecomm/ecomm-api/handler"
 	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/mailer"
+	"davidHwang/ecomm/token"
+	"davidHwang/ecomm/validate"
 	"log"
+	"time"
 
 	"github.com/ianschenck/envflag"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-const minSecretKeySize = 32
-
 func main() {
 
 	var (
-		secretKey = envflag.String("SECRET_KEY", "01234567890123456789012345678901", "secret key for JWT signing")
+		tokenFormat = envflag.String("TOKEN_FORMAT", token.FormatJWT, "format of access and refresh tokens: jwt, paseto-v4-local or paseto-v4-public")
+
+		secretKey = envflag.String("SECRET_KEY", "", "secret key of at least 32 characters for HS256 JWT signing, required unless JWT_KEYS_DIR or PASETO is used")
+
+		jwtKeysDir    = envflag.String("JWT_KEYS_DIR", "", "directory with PEM keys for RS256/EdDSA signing, replaces SECRET_KEY when set")
+		jwtSigningKID = envflag.String("JWT_SIGNING_KID", "", "id (file name without .pem) of the signing key, defaults to the latest private key")
+		jwtKeysReload = envflag.Duration("JWT_KEYS_RELOAD", time.Minute, "how often keys are reloaded from JWT_KEYS_DIR, 0 disables reloading")
+
+		pasetoLocalKey  = envflag.String("PASETO_LOCAL_KEY", "", "hex encoded 32-byte key for paseto-v4-local tokens, must match ecomm-grpc")
+		pasetoSecretKey = envflag.String("PASETO_SECRET_KEY", "", "hex encoded Ed25519 secret key for signing paseto-v4-public tokens")
 
 		svcAddr = envflag.String("GRPC_SVC_ADDR", "0.0.0.0:9091", "address where the ecomm-grpc service is listening on")
+
+		httpTimeout   = envflag.Duration("HTTP_TIMEOUT", handler.DefaultRouteTimeout, "default deadline of an HTTP request, 0 disables it")
+		routeTimeouts = envflag.String("HTTP_ROUTE_TIMEOUTS", "", "per-route deadlines, e.g. \"GET /products/search=3s,POST /orders=15s\"")
+
+		trustedProxiesList = envflag.String("TRUSTED_PROXIES", "", "comma separated addresses or CIDRs of reverse proxies whose X-Forwarded-For is used as the client IP")
+
+		sessionCacheTTL = envflag.Duration("SESSION_CACHE_TTL", handler.DefaultSessionCacheTTL, "how long the revocation state of a session is cached for access token checks")
+
+		actionTokenKey = envflag.String("ACTION_TOKEN_KEY", "", "secret for tokens in email links (verification), defaults to SECRET_KEY, required when tokens are not signed with SECRET_KEY")
+
+		mailerBackend = envflag.String("MAILER", "log", "how outbound mail is delivered: log, file or smtp")
+		mailFrom      = envflag.String("MAIL_FROM", "no-reply@ecomm.local", "sender address of outbound mail")
+		mailDir       = envflag.String("MAIL_DIR", "mail", "directory for .eml files when MAILER=file")
+		smtpAddr      = envflag.String("SMTP_ADDR", "localhost:1025", "SMTP server address when MAILER=smtp")
+		smtpUsername  = envflag.String("SMTP_USERNAME", "", "SMTP username, empty disables authentication")
+		smtpPassword  = envflag.String("SMTP_PASSWORD", "", "SMTP password")
+
+		emailVerification = envflag.String("EMAIL_VERIFICATION", "off", "what requires a verified email: off, login or order")
+		emailVerifyURL    = envflag.String("EMAIL_VERIFY_URL", "http://localhost:3000/verify-email", "page of the email verification link, the token is added as the token query parameter")
+		emailResendLimit  = envflag.Int("EMAIL_VERIFY_RESEND_LIMIT", handler.DefaultVerificationResendLimit, "verification emails that can be resent per email within EMAIL_VERIFY_RESEND_WINDOW, 0 disables the limit")
+		emailResendWindow = envflag.Duration("EMAIL_VERIFY_RESEND_WINDOW", handler.DefaultVerificationResendWindow, "window of the per-email verification resend limit")
+
+		passwordResetURL    = envflag.String("PASSWORD_RESET_URL", "http://localhost:3000/reset-password", "page of the password reset link, the token is added as the token query parameter")
+		passwordResetTTL    = envflag.Duration("PASSWORD_RESET_TTL", handler.DefaultPasswordResetTTL, "how long a password reset link is valid")
+		passwordResetLimit  = envflag.Int("PASSWORD_RESET_LIMIT", handler.DefaultPasswordResetLimit, "password reset requests allowed per email within PASSWORD_RESET_WINDOW, 0 disables the limit")
+		passwordResetWindow = envflag.Duration("PASSWORD_RESET_WINDOW", handler.DefaultPasswordResetWindow, "window of the per-email password reset limit")
+
+		loginFreeAttempts    = envflag.Int("LOGIN_FREE_ATTEMPTS", handler.DefaultLoginFreeAttempts, "failed logins per email, IP or email and IP pair before delays start")
+		loginBaseDelay       = envflag.Duration("LOGIN_BASE_DELAY", handler.DefaultLoginBaseDelay, "first delay after the free attempts, doubled after every next failure")
+		loginMaxDelay        = envflag.Duration("LOGIN_MAX_DELAY", handler.DefaultLoginMaxDelay, "upper bound of the delay between failed logins")
+		loginLockoutAfter    = envflag.Int("LOGIN_LOCKOUT_AFTER", handler.DefaultLoginLockoutAfter, "failed logins per email and IP pair that lock the email out for that IP, 0 disables the lockout")
+		loginLockoutDuration = envflag.Duration("LOGIN_LOCKOUT_DURATION", handler.DefaultLoginLockoutDuration, "how long a locked out email cannot log in from the IP")
+		loginAttemptsShared  = envflag.Bool("LOGIN_ATTEMPTS_SHARED", false, "keep failed login counters in ecomm-grpc so they are shared by all API replicas")
+
+		passwordMinLength    = envflag.Int("PASSWORD_MIN_LENGTH", validate.DefaultMinPasswordLength, "minimum length of new passwords")
+		passwordBreachedList = envflag.String("PASSWORD_BREACHED_LIST", "", "file with breached or common passwords, one per line, that cannot be used as new passwords")
 	)
 
-	if len(*secretKey) < minSecretKeySize {
-		log.Fatalf("SECRET_KEY must be at least %d characters long", minSecretKeySize)
+	envflag.Parse()
+
+	tokenMaker, err := token.NewMaker(context.Background(), token.Config{
+		Format:          *tokenFormat,
+		SecretKey:       *secretKey,
+		KeysDir:         *jwtKeysDir,
+		SigningKID:      *jwtSigningKID,
+		KeysReload:      *jwtKeysReload,
+		PasetoLocalKey:  *pasetoLocalKey,
+		PasetoSecretKey: *pasetoSecretKey,
+	})
+
+	if err != nil {
+		log.Fatalf("error creating token maker: %v", err)
+	}
+
+	//* ecomm-api выдает токены, поэтому нужен ключ подписи: JWT_KEYS_DIR только с открытыми ключами
+	//* или PASETO v4.public без секретного ключа подходят только ecomm-grpc
+	if _, _, err := tokenMaker.CreateServiceToken("ecomm-api", time.Minute); err != nil {
+		log.Fatalf("token maker can not sign tokens: %v", err)
+	}
+
+	passwordPolicy, err := validate.NewPasswordPolicy(*passwordMinLength, *passwordBreachedList)
+
+	if err != nil {
+		log.Fatalf("invalid password policy: %v", err)
+	}
+
+	trustedProxies, err := handler.ParseTrustedProxies(*trustedProxiesList)
+
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
 	}
 
 	// db, err := db.NewDatabase()
@@ -38,14 +116,61 @@ func main() {
 	// st := storer.NewMySQLStorer(db.GetDB())
 	// srv := server.NewServer(st)
 
+	//* SECRET_KEY проверяется (token.NewMaker) и подписывает токены только в режиме JWT без JWT_KEYS_DIR,
+	//* в остальных режимах он может быть не задан
+	if *actionTokenKey == "" {
+		if (*tokenFormat != "" && *tokenFormat != token.FormatJWT) || *jwtKeysDir != "" {
+			log.Fatalf("ACTION_TOKEN_KEY is required when tokens are not signed with SECRET_KEY")
+		}
+
+		*actionTokenKey = *secretKey
+	}
+
+	actionTokens, err := token.NewActionTokenMaker(*actionTokenKey)
+
+	if err != nil {
+		log.Fatalf("invalid ACTION_TOKEN_KEY: %v", err)
+	}
+
+	var mail mailer.Mailer
+
+	switch *mailerBackend {
+	case "log":
+		mail = mailer.NewLogMailer(nil)
+	case "file":
+		mail, err = mailer.NewFileMailer(*mailDir, *mailFrom)
+
+		if err != nil {
+			log.Fatalf("error creating file mailer: %v", err)
+		}
+	case "smtp":
+		mail = mailer.NewSMTPMailer(*smtpAddr, *mailFrom, *smtpUsername, *smtpPassword)
+	default:
+		log.Fatalf("unknown MAILER %q, expected log, file or smtp", *mailerBackend)
+	}
+
+	verificationMode, err := handler.ParseEmailVerificationMode(*emailVerification)
+
+	if err != nil {
+		log.Fatalf("invalid EMAIL_VERIFICATION: %v", err)
+	}
+
 	//! Подрубаем GRPC клиент
 
 	// параметр подключения
 	//* WithTransportCredentials - учетные данные траспорта для подключения
 	//* insecure.NewCredentials() - новые учетные данные
 
+	timeouts, err := handler.ParseRouteTimeouts(*httpTimeout, *routeTimeouts)
+
+	if err != nil {
+		log.Fatalf("invalid HTTP_ROUTE_TIMEOUTS: %v", err)
+	}
+
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		//* request ID и пользователь передаются в ecomm-grpc через metadata
+		grpc.WithChainUnaryInterceptor(handler.MetadataInterceptor()),
 	}
 
 	conn, err := grpc.NewClient(*svcAddr, opts...)
@@ -61,7 +186,36 @@ func main() {
 	//! Подрубаем GRPC клиент end
 
 	//* подключение для grpc
-	hdlGRPC := handler.NewHandler(client, *secretKey)
+	hdlGRPC := handler.NewHandler(handler.Config{
+		Client:          client,
+		TokenMaker:      tokenMaker,
+		ActionTokens:    actionTokens,
+		Mailer:          mail,
+		Timeouts:        timeouts,
+		SessionCacheTTL: *sessionCacheTTL,
+		Verification: handler.EmailVerification{
+			Mode:         verificationMode,
+			URL:          *emailVerifyURL,
+			ResendLimit:  *emailResendLimit,
+			ResendWindow: *emailResendWindow,
+		},
+		PasswordReset: handler.PasswordReset{
+			URL:    *passwordResetURL,
+			TTL:    *passwordResetTTL,
+			Limit:  *passwordResetLimit,
+			Window: *passwordResetWindow,
+		},
+		LoginThrottle: handler.LoginThrottle{
+			FreeAttempts:    *loginFreeAttempts,
+			BaseDelay:       *loginBaseDelay,
+			MaxDelay:        *loginMaxDelay,
+			LockoutAfter:    *loginLockoutAfter,
+			LockoutDuration: *loginLockoutDuration,
+			Shared:          *loginAttemptsShared,
+		},
+		PasswordPolicy: passwordPolicy,
+		TrustedProxies: trustedProxies,
+	})
 
 	//* подключение для grpc end
 
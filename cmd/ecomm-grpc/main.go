@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"davidHwang/ecomm/db"
 	"davidHwang/ecomm/ecomm-grpc/pb"
 	"davidHwang/ecomm/ecomm-grpc/server"
 	"davidHwang/ecomm/ecomm-grpc/storer"
+	"davidHwang/ecomm/token"
+	"davidHwang/ecomm/totp"
+	"davidHwang/ecomm/util"
 	"log"
 	"net"
+	"time"
 
 	"github.com/ianschenck/envflag"
 	"google.golang.org/grpc"
@@ -15,29 +20,114 @@ import (
 func main() {
 	var (
 		svcAddr = envflag.String("SVC_ADDR", "0.0.0.0:9091", "address where the ecomm-grpc service is listening on")
+
+		tokenFormat = envflag.String("TOKEN_FORMAT", token.FormatJWT, "format of access tokens issued by ecomm-api: jwt, paseto-v4-local or paseto-v4-public")
+
+		secretKey = envflag.String("SECRET_KEY", "", "secret key for verifying HS256 access tokens, must match ecomm-api, required unless JWT_KEYS_DIR or PASETO is used")
+
+		jwtKeysDir    = envflag.String("JWT_KEYS_DIR", "", "directory with PEM public keys of ecomm-api for RS256/EdDSA tokens, replaces SECRET_KEY when set")
+		jwtKeysReload = envflag.Duration("JWT_KEYS_RELOAD", time.Minute, "how often keys are reloaded from JWT_KEYS_DIR, 0 disables reloading")
+
+		pasetoLocalKey  = envflag.String("PASETO_LOCAL_KEY", "", "hex encoded 32-byte key for paseto-v4-local tokens, must match ecomm-api")
+		pasetoPublicKey = envflag.String("PASETO_PUBLIC_KEY", "", "hex encoded Ed25519 public key of ecomm-api for paseto-v4-public tokens")
+
+		sessionCacheTTL = envflag.Duration("SESSION_CACHE_TTL", server.DefaultSessionCacheTTL, "how long the revocation state of a session is cached for access token checks")
+
+		totpEncryptionKey = envflag.String("TOTP_ENCRYPTION_KEY", "", "hex encoded 32-byte key that encrypts TOTP secrets at rest, empty stores new secrets unencrypted")
+
+		storerBackend = envflag.String("STORER", "mysql", "storage backend for the ecomm-grpc service: mysql or memory")
+
+		taxRate          = envflag.Float64("TAX_RATE", 0.15, "tax rate applied to the items price of an order")
+		shippingPrice    = envflag.Float64("SHIPPING_PRICE", 10, "flat shipping price of an order")
+		freeShippingOver = envflag.Float64("FREE_SHIPPING_OVER", 100, "items price from which shipping is free, 0 disables free shipping")
 	)
 
-	//*создадим
+	envflag.Parse()
+
+	tokenMaker, err := token.NewMaker(context.Background(), token.Config{
+		Format:          *tokenFormat,
+		SecretKey:       *secretKey,
+		KeysDir:         *jwtKeysDir,
+		KeysReload:      *jwtKeysReload,
+		PasetoLocalKey:  *pasetoLocalKey,
+		PasetoPublicKey: *pasetoPublicKey,
+	})
+
+	if err != nil {
+		log.Fatalf("error creating token maker: %v", err)
+	}
+
+	//* пароли хэширует только ecomm-grpc, VerifyCredentials пересчитывает хэши с другими параметрами
+	passwordConfig, err := util.PasswordConfigFromEnv()
+
+	if err != nil {
+		log.Fatalf("invalid password hash settings: %v", err)
+	}
 
-	//* 1 экземпляр базы данных
-	db, err := db.NewDatabase()
+	hasher, err := util.NewPasswordHasher(passwordConfig)
 
 	if err != nil {
-		log.Fatalf("error opening connection to database: %v", err)
+		log.Fatalf("invalid password hash settings: %v", err)
 	}
 
-	defer db.Close()
+	util.SetPasswordHasher(hasher)
 
-	log.Println("successfully connected to database")
+	var totpCipher *totp.SecretCipher
 
-	// do something with the database
-	st := storer.NewMySQLStorer(db.GetDB())
+	if *totpEncryptionKey != "" {
+		totpCipher, err = totp.NewSecretCipher(*totpEncryptionKey)
+
+		if err != nil {
+			log.Fatalf("invalid TOTP_ENCRYPTION_KEY: %v", err)
+		}
+	} else {
+		log.Println("TOTP_ENCRYPTION_KEY is not set, TOTP secrets are stored unencrypted")
+	}
+
+	//*создадим
+
+	//* 1 экземпляр хранилища
+	var st storer.Storer
+
+	switch *storerBackend {
+	case "mysql":
+		db, err := db.NewDatabase()
+
+		if err != nil {
+			log.Fatalf("error opening connection to database: %v", err)
+		}
+
+		defer db.Close()
+
+		log.Println("successfully connected to database")
+
+		st = storer.NewMySQLStorer(db.GetDB())
+	case "memory":
+		log.Println("using in-memory storer, data will be lost on restart")
+
+		st = storer.NewMemoryStorer()
+	default:
+		log.Fatalf("unknown STORER %q, expected mysql or memory", *storerBackend)
+	}
 
 	//* 2 экземпляр сервера
-	srv := server.NewServer(st)
+	pricer := server.NewPricer(
+		server.FlatRateTax{Rate: *taxRate},
+		server.FlatRateShipping{Price: *shippingPrice, FreeOver: *freeShippingOver},
+	)
+
+	srv, err := server.NewServer(st, pricer, totpCipher)
+
+	if err != nil {
+		log.Fatalf("error creating server: %v", err)
+	}
 
 	//* 3 зарегистрируем сервер в GRPC сервере
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		server.RequestInfoInterceptor(),
+		server.AuthInterceptor(tokenMaker, server.NewSessionChecker(st, *sessionCacheTTL)),
+		server.ValidationInterceptor(),
+	))
 	pb.RegisterEcommServer(grpcServer, srv)
 
 	listener, err := net.Listen("tcp", *svcAddr)
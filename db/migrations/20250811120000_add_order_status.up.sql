@@ -0,0 +1,2 @@
+ALTER TABLE `orders`
+  ADD COLUMN `status` varchar(32) NOT NULL DEFAULT 'pending';
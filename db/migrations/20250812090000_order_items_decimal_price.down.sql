@@ -0,0 +1,2 @@
+ALTER TABLE `order_items`
+  MODIFY COLUMN `price` int NOT NULL;
@@ -0,0 +1,4 @@
+CREATE INDEX `products_category_idx` ON `products` (`category`);
+CREATE INDEX `products_price_id_idx` ON `products` (`price`, `id`);
+CREATE INDEX `products_rating_id_idx` ON `products` (`rating`, `id`);
+CREATE INDEX `products_created_at_id_idx` ON `products` (`created_at`, `id`);
@@ -0,0 +1 @@
+DROP INDEX `products_search_idx` ON `products`;
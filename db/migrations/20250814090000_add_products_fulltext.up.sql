@@ -0,0 +1 @@
+CREATE FULLTEXT INDEX `products_search_idx` ON `products` (`name`, `category`, `description`);
@@ -0,0 +1,5 @@
+DROP INDEX `sessions_family_id_idx` ON `sessions`;
+ALTER TABLE `sessions`
+  DROP COLUMN `rotated_at`,
+  DROP COLUMN `parent_id`,
+  DROP COLUMN `family_id`;
@@ -0,0 +1,6 @@
+ALTER TABLE `sessions`
+  ADD COLUMN `family_id` varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN `parent_id` varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN `rotated_at` datetime;
+UPDATE `sessions` SET `family_id` = `id` WHERE `family_id` = '';
+CREATE INDEX `sessions_family_id_idx` ON `sessions` (`family_id`);
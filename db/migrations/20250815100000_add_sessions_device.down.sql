@@ -0,0 +1,4 @@
+DROP INDEX `sessions_user_email_idx` ON `sessions`;
+ALTER TABLE `sessions`
+  DROP COLUMN `ip_address`,
+  DROP COLUMN `user_agent`;
@@ -0,0 +1,4 @@
+ALTER TABLE `sessions`
+  ADD COLUMN `user_agent` varchar(512) NOT NULL DEFAULT '',
+  ADD COLUMN `ip_address` varchar(45) NOT NULL DEFAULT '';
+CREATE INDEX `sessions_user_email_idx` ON `sessions` (`user_email`);
@@ -0,0 +1,26 @@
+CREATE TABLE `roles` (
+  `name` varchar(64) PRIMARY KEY NOT NULL,
+  `description` varchar(255) NOT NULL DEFAULT '',
+  `permissions` json NOT NULL,
+  `created_at` datetime DEFAULT (now())
+);
+
+CREATE TABLE `user_roles` (
+  `user_id` int NOT NULL,
+  `role_name` varchar(64) NOT NULL,
+  `created_at` datetime DEFAULT (now()),
+  PRIMARY KEY (`user_id`, `role_name`)
+);
+
+ALTER TABLE `user_roles` ADD FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+ALTER TABLE `user_roles` ADD FOREIGN KEY (`role_name`) REFERENCES `roles` (`name`);
+CREATE INDEX `user_roles_role_name_idx` ON `user_roles` (`role_name`);
+
+INSERT INTO `roles` (`name`, `description`, `permissions`) VALUES
+  ('customer', 'buyer, works only with own orders and profile', JSON_ARRAY()),
+  ('catalog-manager', 'manages the product catalog', JSON_ARRAY('products:write')),
+  ('order-support', 'handles customer orders', JSON_ARRAY('orders:read', 'orders:write', 'users:read')),
+  ('superadmin', 'full access', JSON_ARRAY('*'));
+
+INSERT INTO `user_roles` (`user_id`, `role_name`)
+SELECT `id`, 'superadmin' FROM `users` WHERE `is_admin` = true;
@@ -0,0 +1,10 @@
+CREATE TABLE `password_resets` (
+  `token_hash` char(64) PRIMARY KEY NOT NULL,
+  `user_id` int NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime,
+  `created_at` datetime DEFAULT (now())
+);
+
+ALTER TABLE `password_resets` ADD FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+CREATE INDEX `password_resets_user_id_idx` ON `password_resets` (`user_id`);
@@ -0,0 +1,18 @@
+CREATE TABLE `user_totp` (
+  `user_id` int PRIMARY KEY NOT NULL,
+  `secret` varchar(64) NOT NULL,
+  `enabled_at` datetime,
+  `last_used_step` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime DEFAULT (now())
+);
+
+CREATE TABLE `user_recovery_codes` (
+  `user_id` int NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime,
+  `created_at` datetime DEFAULT (now()),
+  PRIMARY KEY (`user_id`, `code_hash`)
+);
+
+ALTER TABLE `user_totp` ADD FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+ALTER TABLE `user_recovery_codes` ADD FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+CREATE TABLE `login_attempts` (
+  `attempt_key` varchar(320) PRIMARY KEY NOT NULL,
+  `failures` int NOT NULL DEFAULT 0,
+  `last_failure_at` datetime(3) NOT NULL
+);
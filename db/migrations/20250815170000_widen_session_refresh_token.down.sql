@@ -0,0 +1 @@
+ALTER TABLE `sessions` MODIFY `refresh_token` varchar(512) NOT NULL;
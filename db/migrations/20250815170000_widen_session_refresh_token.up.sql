@@ -0,0 +1 @@
+ALTER TABLE `sessions` MODIFY `refresh_token` varchar(2048) NOT NULL;
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// * AuditEvent - событие безопасности (блокировка входа и т.п.) для журнала аудита
+type AuditEvent struct {
+	Time      time.Time      `json:"time"`
+	Type      string         `json:"type"`
+	RequestID string         `json:"request_id,omitempty"`
+	IP        string         `json:"ip,omitempty"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// * событие запроса r с идентификатором запроса и IP клиента
+func newAuditEvent(r *http.Request, eventType string, fields map[string]any) AuditEvent {
+	return AuditEvent{
+		Time:      time.Now(),
+		Type:      eventType,
+		RequestID: middleware.GetReqID(r.Context()),
+		IP:        clientIP(r),
+		Fields:    fields,
+	}
+}
+
+// * по умолчанию события пишутся в лог одной JSON строкой с префиксом audit
+func logAuditEvent(e AuditEvent) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("error encoding audit event %s: %v", e.Type, err)
+		return
+	}
+
+	log.Printf("audit %s", data)
+}
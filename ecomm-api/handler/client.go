@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/rpcmeta"
+	"davidHwang/ecomm/token"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const authorizationMetadataKey = "authorization"
+
+// * время жизни сервисного токена ecomm-api для одного вызова ecomm-grpc
+const serviceTokenDuration = time.Minute
+
+const serviceName = "ecomm-api"
+
+// * MetadataInterceptor передает в ecomm-grpc идентификатор HTTP запроса,
+// * пользователя из токена доступа и сам токен (authorization: Bearer) через gRPC metadata.
+// * Если authorization уже задан (сервисный токен), он не перезаписывается
+func MetadataInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ri := rpcmeta.RequestInfo{RequestID: middleware.GetReqID(ctx)}
+
+		if claims, ok := ctx.Value(authKey{}).(*token.UserClaims); ok {
+			ri.UserID = claims.ID
+			ri.UserEmail = claims.Email
+			ri.IsAdmin = claims.IsAdmin
+		}
+
+		ctx = rpcmeta.AppendToOutgoingContext(ctx, ri)
+
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if tokenStr, ok := ctx.Value(authTokenKey{}).(string); ok && len(md.Get(authorizationMetadataKey)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, authorizationMetadataKey, "Bearer "+tokenStr)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// * serviceContext - контекст вызова ecomm-grpc от имени самого ecomm-api
+// * для операций до входа пользователя (поиск пользователя, создание и проверка сессии).
+// * Сервисный токен отличается от токена администратора аудиторией token.ServiceAudience
+func (h *handler) serviceContext(ctx context.Context) (context.Context, error) {
+	tokenStr, _, err := h.TokenMaker.CreateServiceToken(serviceName, serviceTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, authorizationMetadataKey, "Bearer "+tokenStr), nil
+}
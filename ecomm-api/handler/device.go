@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const unknownDevice = "Unknown device"
+
+// * признаки браузеров и систем в User-Agent, проверяются по порядку:
+// * Edge и Opera содержат "Chrome/", Chrome содержит "Safari/"
+var (
+	userAgentBrowsers = []struct{ marker, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+
+	userAgentSystems = []struct{ marker, name string }{
+		{"Windows", "Windows"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// * короткое название устройства для списка сессий, например "Chrome on Windows"
+func deviceName(userAgent string) string {
+	browser := matchUserAgent(userAgent, userAgentBrowsers)
+	system := matchUserAgent(userAgent, userAgentSystems)
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	return unknownDevice
+}
+
+func matchUserAgent(userAgent string, markers []struct{ marker, name string }) string {
+	for _, m := range markers {
+		if strings.Contains(userAgent, m.marker) {
+			return m.name
+		}
+	}
+
+	return ""
+}
+
+// * IP адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// * ParseTrustedProxies читает список адресов и подсетей через запятую, например "10.0.0.0/8,192.168.1.10"
+func ParseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+
+		proxies = append(proxies, prefix.Masked())
+	}
+
+	return proxies, nil
+}
+
+func isTrustedProxy(addr netip.Addr, proxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+
+	for _, p := range proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// * forwardedClientIP - адрес клиента из X-Forwarded-For, если соединение пришло от доверенного прокси.
+// * Адреса разбираются справа налево до первого недоверенного: все левее него клиент мог подставить сам.
+// * Пустая строка - заголовку не доверяем, используется IP соединения
+func forwardedClientIP(r *http.Request, proxies []netip.Prefix) string {
+	peer, err := netip.ParseAddr(clientIP(r))
+	if err != nil || !isTrustedProxy(peer, proxies) {
+		return ""
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+
+	var client string
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+
+		client = addr.Unmap().String()
+		if !isTrustedProxy(addr, proxies) {
+			break
+		}
+	}
+
+	return client
+}
+
+// * trustedRealIP заменяет RemoteAddr адресом клиента за доверенными прокси, чтобы clientIP
+// * в счетчиках входа, сессиях и аудите не зависел от заголовков, которые может подделать клиент
+func trustedRealIP(proxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := forwardedClientIP(r, proxies); ip != "" {
+				r.RemoteAddr = ip
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
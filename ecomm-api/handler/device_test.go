@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceName(t *testing.T) {
+	tcs := []struct {
+		userAgent string
+		device    string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", "Chrome on Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/8.5.0", "curl"},
+		{"", unknownDevice},
+	}
+
+	for _, tc := range tcs {
+		require.Equal(t, tc.device, deviceName(tc.userAgent), tc.userAgent)
+	}
+}
+
+func TestForwardedClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		ip         string
+	}{
+		{"untrusted peer", "203.0.113.7:1234", []string{"198.51.100.1"}, ""},
+		{"trusted proxy", "10.1.2.3:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hops", "192.168.1.10:1234", []string{"1.2.3.4, 198.51.100.1, 10.0.0.5"}, "198.51.100.1"},
+		{"several headers", "10.1.2.3:1234", []string{"1.2.3.4", "198.51.100.1"}, "198.51.100.1"},
+		{"invalid hop", "10.1.2.3:1234", []string{"garbage, 10.0.0.5"}, "10.0.0.5"},
+		{"no header", "10.1.2.3:1234", nil, ""},
+	}
+
+	for _, tc := range tcs {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tc.remoteAddr
+		for _, v := range tc.forwarded {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+
+		require.Equal(t, tc.ip, forwardedClientIP(r, proxies), tc.name)
+	}
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	require.Error(t, err)
+
+	//* без доверенных прокси заголовок игнорируется
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	require.Empty(t, forwardedClientIP(r, nil))
+}
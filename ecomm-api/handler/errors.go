@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"davidHwang/ecomm/validate"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const problemContentType = "application/problem+json"
+
+// * нестандартный статус nginx: клиент закрыл соединение до ответа
+const statusClientClosedRequest = 499
+
+// * машинно-читаемые коды ошибок REST API
+const (
+	ErrCodeInvalidBody        = "invalid_body"
+	ErrCodeInvalidID          = "invalid_id"
+	ErrCodeInvalidQuery       = "invalid_query"
+	ErrCodeInvalidCredentials = "invalid_credentials"
+	ErrCodeInvalidToken       = "invalid_token"
+	ErrCodeSessionRevoked     = "session_revoked"
+	ErrCodeEmailNotVerified   = "email_not_verified"
+	ErrCodeTooManyRequests    = "too_many_requests"
+	ErrCodeInvalidMFACode     = "invalid_mfa_code"
+	ErrCodeForbidden          = "forbidden"
+	ErrCodeInternal           = "internal"
+	ErrCodeValidation         = "validation_failed"
+)
+
+// * причина errdetails.ErrorInfo от ecomm-grpc: неверный email или пароль, а не токен сервиса или сессия
+const reasonInvalidCredentials = "INVALID_CREDENTIALS"
+
+// * Problem - тело ответа с ошибкой в формате RFC 7807 (application/problem+json)
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// * ошибка конкретного поля запроса
+type FieldError = validate.FieldError
+
+func writeProblem(w http.ResponseWriter, r *http.Request, httpStatus int, code, detail string, fieldErrors ...FieldError) {
+	title := http.StatusText(httpStatus)
+	if httpStatus == statusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+
+	p := Problem{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    httpStatus,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    fieldErrors,
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(p)
+}
+
+// * проверка тела запроса по правилам validate, при ошибках отвечает 400 со списком полей
+func validateRequest(w http.ResponseWriter, r *http.Request, req any, rules ...validate.Rules) bool {
+	errs := validate.Struct(req, rules...)
+
+	if len(errs) == 0 {
+		return true
+	}
+
+	writeProblem(w, r, http.StatusBadRequest, ErrCodeValidation, "request validation failed", errs...)
+
+	return false
+}
+
+type grpcProblem struct {
+	status int
+	code   string
+}
+
+// * соответствие кодов gRPC кодам HTTP и кодам ошибок API
+var grpcProblems = map[codes.Code]grpcProblem{
+	codes.InvalidArgument:    {http.StatusBadRequest, "invalid_argument"},
+	codes.OutOfRange:         {http.StatusBadRequest, "out_of_range"},
+	codes.NotFound:           {http.StatusNotFound, "not_found"},
+	codes.AlreadyExists:      {http.StatusConflict, "already_exists"},
+	codes.Aborted:            {http.StatusConflict, "conflict"},
+	codes.FailedPrecondition: {http.StatusConflict, "failed_precondition"},
+	codes.Unauthenticated:    {http.StatusUnauthorized, "unauthenticated"},
+	codes.PermissionDenied:   {http.StatusForbidden, ErrCodeForbidden},
+	codes.ResourceExhausted:  {http.StatusTooManyRequests, "too_many_requests"},
+	codes.DeadlineExceeded:   {http.StatusGatewayTimeout, "timeout"},
+	codes.Canceled:           {statusClientClosedRequest, "canceled"},
+	codes.Unavailable:        {http.StatusServiceUnavailable, "unavailable"},
+	codes.Unimplemented:      {http.StatusNotImplemented, "not_implemented"},
+}
+
+// * причина из errdetails.ErrorInfo ошибки gRPC, "" - ErrorInfo нет
+func grpcErrorReason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
+
+// * ответ на ошибку от gRPC сервера: для известных кодов клиент получает сообщение статуса,
+// * код из errdetails.ErrorInfo и ошибки полей из errdetails.BadRequest.
+// * Для остальных - 500 и msg, а сама ошибка пишется в лог
+func writeGRPCError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	st := status.Convert(err)
+
+	p, ok := grpcProblems[st.Code()]
+	if !ok {
+		log.Printf("%s [%s]: %v", msg, middleware.GetReqID(r.Context()), err)
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, msg)
+		return
+	}
+
+	code := p.code
+	var fieldErrors []FieldError
+
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			code = strings.ToLower(d.GetReason())
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				fieldErrors = append(fieldErrors, FieldError{Field: v.GetField(), Message: v.GetDescription()})
+			}
+		}
+	}
+
+	writeProblem(w, r, p.status, code, st.Message(), fieldErrors...)
+}
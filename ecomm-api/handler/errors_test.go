@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWriteGRPCError(t *testing.T) {
+	duplicate, err := status.New(codes.AlreadyExists, "email is already registered").WithDetails(
+		&errdetails.ErrorInfo{Reason: "DUPLICATE_EMAIL"},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "email", Description: "email is already registered"}}},
+	)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+		fields []FieldError
+	}{
+		{
+			name:   "not found",
+			err:    status.Error(codes.NotFound, "product not found"),
+			status: http.StatusNotFound,
+			code:   "not_found",
+			detail: "product not found",
+		},
+		{
+			name:   "details",
+			err:    duplicate.Err(),
+			status: http.StatusConflict,
+			code:   "duplicate_email",
+			detail: "email is already registered",
+			fields: []FieldError{{Field: "email", Message: "email is already registered"}},
+		},
+		{
+			name:   "internal error is hidden",
+			err:    status.Error(codes.Internal, "dial tcp: connection refused"),
+			status: http.StatusInternalServerError,
+			code:   ErrCodeInternal,
+			detail: "error getting product",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+
+			writeGRPCError(w, r, tc.err, "error getting product")
+
+			require.Equal(t, tc.status, w.Code)
+			require.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+			var p Problem
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+			require.Equal(t, tc.status, p.Status)
+			require.Equal(t, tc.code, p.Code)
+			require.Equal(t, tc.detail, p.Detail)
+			require.Equal(t, "/products/1", p.Instance)
+			require.Equal(t, tc.fields, p.Errors)
+		})
+	}
+}
+
+func TestGRPCErrorReason(t *testing.T) {
+	//* отклоненный токен сервиса или отозванная сессия - тоже Unauthenticated, но не неверный пароль
+	require.Empty(t, grpcErrorReason(status.Error(codes.Unauthenticated, "invalid token")))
+	require.Equal(t, reasonInvalidCredentials, grpcErrorReason(invalidCredentialsError()))
+
+	revoked, err := status.New(codes.Unauthenticated, "session revoked").WithDetails(&errdetails.ErrorInfo{Reason: "SESSION_REVOKED"})
+	require.NoError(t, err)
+	require.Equal(t, "SESSION_REVOKED", grpcErrorReason(revoked.Err()))
+}
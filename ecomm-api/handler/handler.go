@@ -4,17 +4,21 @@ import (
 	"context"
 	// "davidHwang/ecomm/ecomm-api/server"
 	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/mailer"
 
 	// "davidHwang/ecomm/ecomm-api/storer"
 	"davidHwang/ecomm/token"
-	"davidHwang/ecomm/util"
+	"davidHwang/ecomm/validate"
 	"encoding/json"
-	"log"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -35,17 +39,78 @@ import (
 
 // ! GRPC CLIENT
 type handler struct {
-	ctx        context.Context
-	client     pb.EcommClient
-	TokenMaker *token.JWTMaker
+	client       pb.EcommClient
+	TokenMaker   token.Maker
+	actionTokens *token.ActionTokenMaker
+	mailer       mailer.Mailer
+	timeouts     RouteTimeouts
+	sessions     *token.SessionCache
+	verification EmailVerification
+
+	passwordReset PasswordReset
+	resetLimiter  *rateLimiter
+	resendLimiter *rateLimiter
+
+	loginThrottle LoginThrottle
+	loginAttempts LoginAttemptStore
+	audit         func(AuditEvent)
+
+	passwordPolicy *validate.PasswordPolicy
+	trustedProxies []netip.Prefix
 }
 
-func NewHandler(client pb.EcommClient, secretKey string) *handler {
-	return &handler{
-		ctx:        context.Background(),
-		client:     client,
-		TokenMaker: token.NewJWTMaker(secretKey),
+// * Config - зависимости и настройки handler для NewHandler
+type Config struct {
+	Client     pb.EcommClient
+	TokenMaker token.Maker
+	// * ActionTokens - токены для ссылок из писем, которые отправляет Mailer
+	ActionTokens *token.ActionTokenMaker
+	Mailer       mailer.Mailer
+	Timeouts     RouteTimeouts
+	// * SessionCacheTTL - сколько хранится состояние сессии для проверки токенов доступа
+	SessionCacheTTL time.Duration
+	Verification    EmailVerification
+	PasswordReset   PasswordReset
+	LoginThrottle   LoginThrottle
+	// * PasswordPolicy - требования к новым паролям (nil - только минимальная длина)
+	PasswordPolicy *validate.PasswordPolicy
+	// * TrustedProxies - адреса прокси, от которых принимается X-Forwarded-For (nil - IP соединения)
+	TrustedProxies []netip.Prefix
+}
+
+func NewHandler(cfg Config) *handler {
+	h := &handler{
+		client:       cfg.Client,
+		TokenMaker:   cfg.TokenMaker,
+		actionTokens: cfg.ActionTokens,
+		mailer:       cfg.Mailer,
+		timeouts:     cfg.Timeouts,
+		verification: cfg.Verification,
+
+		passwordReset: cfg.PasswordReset,
+		resetLimiter:  newRateLimiter(cfg.PasswordReset.Limit, cfg.PasswordReset.Window),
+		resendLimiter: newRateLimiter(cfg.Verification.ResendLimit, cfg.Verification.ResendWindow),
+
+		loginThrottle: cfg.LoginThrottle,
+		audit:         logAuditEvent,
+
+		passwordPolicy: cfg.PasswordPolicy,
+		trustedProxies: cfg.TrustedProxies,
+	}
+
+	if h.passwordPolicy == nil {
+		h.passwordPolicy = &validate.PasswordPolicy{MinLength: validate.DefaultMinPasswordLength}
 	}
+
+	h.sessions = token.NewSessionCache(cfg.SessionCacheTTL, h.lookupSession)
+
+	if cfg.LoginThrottle.Shared {
+		h.loginAttempts = grpcLoginAttempts{h: h}
+	} else {
+		h.loginAttempts = newMemoryLoginAttempts(h.loginThrottle)
+	}
+
+	return h
 }
 
 // ! GRPC CLIENT
@@ -56,14 +121,18 @@ func (h *handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var p ProductReq
 
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, p, validate.ProductCreate) {
 		return
 	}
 
-	product, err := h.client.CreateProduct(h.ctx, toPBProductReq(p))
+	product, err := h.client.CreateProduct(r.Context(), toPBProductReq(p))
 
 	if err != nil {
-		http.Error(w, "error creating product", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error creating product")
 		return
 	}
 
@@ -80,15 +149,15 @@ func (h *handler) getProduct(w http.ResponseWriter, r *http.Request) {
 	i, err := strconv.ParseInt(id, 10, 64)
 
 	if err != nil {
-		http.Error(w, "error parsing ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
 		return
 	}
 
-	product, err := h.client.GetProduct(h.ctx, &pb.ProductReq{Id: i})
+	product, err := h.client.GetProduct(r.Context(), &pb.ProductReq{Id: i})
 
 	if err != nil {
 
-		http.Error(w, "error getting product", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error getting product")
 		return
 	}
 
@@ -100,19 +169,52 @@ func (h *handler) getProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) ListProducts(w http.ResponseWriter, r *http.Request) {
-	lpr, err := h.client.ListProducts(h.ctx, &pb.ProductReq{})
+	req, err := toPBListProductsReq(r.URL.Query())
 
 	if err != nil {
-		http.Error(w, "error listing products", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
 		return
 	}
 
-	var res []ProductRes
+	lpr, err := h.client.ListProducts(r.Context(), req)
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error listing products")
+		return
+	}
+
+	res := ListProductRes{
+		Products:   []ProductRes{},
+		NextCursor: lpr.GetNextCursor(),
+	}
 
 	for _, p := range lpr.GetProducts() {
-		res = append(res, toProductRes(p))
+		res.Products = append(res.Products, toProductRes(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// products/search?q=
+func (h *handler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	req, err := toPBSearchProductsReq(r.URL.Query())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+
+	spr, err := h.client.SearchProducts(r.Context(), req)
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error searching products")
+		return
 	}
 
+	res := toProductSearchRes(spr)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
@@ -123,19 +225,23 @@ func (h *handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	i, err := strconv.ParseInt(id, 10, 64)
 
 	if err != nil {
-		http.Error(w, "error parsing ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
 		return
 	}
 
 	var p ProductReq
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, p, validate.ProductUpdate) {
 		return
 	}
 
 	p.ID = i
 
-	// product, err := h.client.GetProduct(h.ctx, &pb.ProductReq{Id: i})
+	// product, err := h.client.GetProduct(r.Context(), &pb.ProductReq{Id: i})
 
 	// if err != nil {
 	// 	http.Error(w, "error getting product", http.StatusInternalServerError)
@@ -144,10 +250,10 @@ func (h *handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 	// //* patch our product request
 	// patchProductReq(product, p)
-	updatedProduct, err := h.client.UpdateProduct(h.ctx, toPBProductReq(p))
+	updatedProduct, err := h.client.UpdateProduct(r.Context(), toPBProductReq(p))
 
 	if err != nil {
-		http.Error(w, "error updating product", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error updating product")
 		return
 	}
 
@@ -163,12 +269,12 @@ func (h *handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	i, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, "error parsing ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
 		return
 	}
-	_, err = h.client.DeleteProduct(h.ctx, &pb.ProductReq{Id: i})
+	_, err = h.client.DeleteProduct(r.Context(), &pb.ProductReq{Id: i})
 	if err != nil {
-		http.Error(w, "error deleting product", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error deleting product")
 		return
 	}
 
@@ -253,21 +359,29 @@ func (h *handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var o OrderReq
 
 	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "bad request")
+		return
+	}
+
+	if !validateRequest(w, r, o, validate.Order) {
 		return
 	}
 
 	claims := r.Context().Value(authKey{}).(*token.UserClaims)
 
+	if !h.checkEmailVerifiedForOrder(w, r, claims.Email) {
+		return
+	}
+
 	// so := toStoreOrder(o)
 	// so.UserID = claims.ID
 	po := toPBOrderReq(o)
 	po.UserId = claims.ID
 
-	created, err := h.client.CreateOrder(h.ctx, po)
+	created, err := h.client.CreateOrder(r.Context(), po)
 
 	if err != nil {
-		http.Error(w, "HANDLER - CreateOrder: error creating order", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error creating order")
 		return
 	}
 
@@ -289,10 +403,10 @@ func (h *handler) getOrder(w http.ResponseWriter, r *http.Request) {
 	// 	panic(err)
 	// }
 
-	order, err := h.client.GetOrder(h.ctx, &pb.OrderReq{UserId: claims.ID})
+	order, err := h.client.GetOrder(r.Context(), &pb.OrderReq{UserId: claims.ID})
 
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error getting order")
 		return
 	}
 
@@ -305,9 +419,9 @@ func (h *handler) getOrder(w http.ResponseWriter, r *http.Request) {
 
 // list orders
 func (h *handler) ListOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.client.ListOrders(h.ctx, &pb.OrderReq{})
+	orders, err := h.client.ListOrders(r.Context(), &pb.OrderReq{})
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error listing orders")
 		return
 	}
 	var res []OrderRes
@@ -319,6 +433,43 @@ func (h *handler) ListOrders(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
+// * смена статуса заказа (только администратор)
+func (h *handler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	i, err := strconv.ParseInt(id, 10, 64)
+
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
+		return
+	}
+
+	var req UpdateOrderStatusReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	st, err := toPBOrderStatus(req.Status)
+
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+
+	order, err := h.client.UpdateOrderStatus(r.Context(), &pb.UpdateOrderStatusReq{Id: i, Status: st})
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error updating order status")
+		return
+	}
+
+	res := toOrderRes(order)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
 // delete
 
 func (h *handler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
@@ -326,13 +477,14 @@ func (h *handler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	i, err := strconv.ParseInt(id, 10, 64)
 
 	if err != nil {
-		panic(err)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
+		return
 	}
 
-	_, err = h.client.DeleteOrder(h.ctx, &pb.OrderReq{Id: i})
+	_, err = h.client.DeleteOrder(r.Context(), &pb.OrderReq{Id: i})
 
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error deleting order")
 		return
 	}
 
@@ -399,26 +551,28 @@ func (h *handler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 func (h *handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var u UserReq
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "bad request")
 		return
 	}
 
-	//* hash password
-	hashedPass, err := util.HashPassword(u.Password)
-	if err != nil {
-		http.Error(w, "error hashing password", http.StatusInternalServerError)
+	//* при регистрации всегда создается обычный покупатель,
+	//* права выдает администратор через POST /admin/users
+	u.IsAdmin = false
+
+	if !validateRequest(w, r, u, validate.User, h.passwordPolicy.Rules("password")) {
 		return
 	}
-	u.Password = hashedPass
-	//* hash password end
 
-	created, err := h.client.CreateUser(h.ctx, toPBUserReq(u))
+	//* пароль хэширует ecomm-grpc
+	created, err := h.client.CreateUser(r.Context(), toPBUserReq(u))
 
 	if err != nil {
-		http.Error(w, "error creating user", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error creating user")
 		return
 	}
 
+	go h.sendVerificationEmail(context.WithoutCancel(r.Context()), created)
+
 	res := toUserRes(created)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -426,11 +580,36 @@ func (h *handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
+// * создание пользователя с ролями или правами администратора.
+// * ecomm-grpc проверяет, что администратор может выдать эти роли
+func (h *handler) createAdminUser(w http.ResponseWriter, r *http.Request) {
+	var u AdminUserReq
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, u, validate.User, h.passwordPolicy.Rules("password")) {
+		return
+	}
+
+	created, err := h.client.CreateUser(r.Context(), toPBAdminUserReq(u))
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error creating user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toUserRes(created))
+}
+
 func (h *handler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.client.ListUsers(h.ctx, &pb.UserReq{})
+	users, err := h.client.ListUsers(r.Context(), &pb.UserReq{})
 
 	if err != nil {
-		http.Error(w, "error listing users", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error listing users")
 		return
 	}
 
@@ -448,14 +627,25 @@ func (h *handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 func (h *handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	var u UserReq
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
 		return
 	}
 
 	claims := r.Context().Value(authKey{}).(*token.UserClaims)
-	u.Email =  claims.Email
+	u.Email = claims.Email
 
-	// user, err := h.client.GetUser(h.ctx, &pb.UserReq{Id: claims.ID})
+	if !validateRequest(w, r, u, validate.UserUpdate) {
+		return
+	}
+
+	//* смена пароля требует текущий пароль, см. changePassword
+	if u.Password != "" {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeValidation, "request validation failed",
+			FieldError{Field: "password", Message: "can only be changed with POST /users/me/password"})
+		return
+	}
+
+	// user, err := h.client.GetUser(r.Context(), &pb.UserReq{Id: claims.ID})
 
 	// if err != nil {
 	// 	http.Error(w, "error getting user", http.StatusInternalServerError)
@@ -469,10 +659,10 @@ func (h *handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	// 	user.Email = claims.Email
 	// }
 
-	updatedUser, err := h.client.UpdateUser(h.ctx, toPBUserReq(u))
+	updatedUser, err := h.client.UpdateUser(r.Context(), toPBUserReq(u))
 
 	if err != nil {
-		http.Error(w, "error updating user", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error updating user")
 		return
 	}
 
@@ -486,12 +676,12 @@ func (h *handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	i, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, "error parsing ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
 		return
 	}
-	_, err = h.client.DeleteUser(h.ctx, &pb.UserReq{Id: i})
+	_, err = h.client.DeleteUser(r.Context(), &pb.UserReq{Id: i})
 	if err != nil {
-		http.Error(w, "error deleting user", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error deleting user")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -545,6 +735,70 @@ func (h *handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 // 	}
 // }
 
+//* ROLES
+
+func (h *handler) listRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.client.ListRoles(r.Context(), &pb.RoleReq{})
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error listing roles")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toListRoleRes(roles))
+}
+
+// admin/users/{id}/roles
+func (h *handler) getUserRoles(w http.ResponseWriter, r *http.Request) {
+	i, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
+		return
+	}
+
+	roles, err := h.client.GetUserRoles(r.Context(), &pb.RoleReq{UserId: i})
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error getting user roles")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toListRoleRes(roles))
+}
+
+// * назначение роли пользователю, новые права попадут в токен доступа при следующем обновлении
+// admin/users/{id}/roles/{role}
+func (h *handler) assignRole(w http.ResponseWriter, r *http.Request) {
+	h.changeUserRole(w, r, h.client.AssignRole, "error assigning role")
+}
+
+func (h *handler) unassignRole(w http.ResponseWriter, r *http.Request) {
+	h.changeUserRole(w, r, h.client.UnassignRole, "error unassigning role")
+}
+
+func (h *handler) changeUserRole(w http.ResponseWriter, r *http.Request, change func(context.Context, *pb.RoleReq, ...grpc.CallOption) (*pb.ListRoleRes, error), msg string) {
+	i, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidID, "error parsing ID")
+		return
+	}
+
+	roles, err := change(r.Context(), &pb.RoleReq{UserId: i, Name: chi.URLParam(r, "role")})
+
+	if err != nil {
+		writeGRPCError(w, r, err, msg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toListRoleRes(roles))
+}
+
 //! AUTH USERS
 
 func (h *handler) loginUser(w http.ResponseWriter, r *http.Request) {
@@ -552,54 +806,96 @@ func (h *handler) loginUser(w http.ResponseWriter, r *http.Request) {
 	var u LoginUserReq
 
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
 		return
 	}
 
-	gu, err := h.client.GetUser(h.ctx, &pb.UserReq{Email: u.Email})
+	if !validateRequest(w, r, u, validate.Login) {
+		return
+	}
+
+	//* счетчики неудач по email и IP: пауза растет с каждой неудачей, затем временная блокировка
+	attemptKeys := loginAttemptKeys(r, u.Email)
+
+	attempt, wait := h.reserveLoginAttempt(r, attemptKeys)
+	if wait > 0 {
+		writeLoginThrottled(w, r, wait)
+		return
+	}
+	defer attempt.release()
+
+	ctx, err := h.serviceContext(r.Context())
 
 	if err != nil {
-		http.Error(w, "error getting user", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
 		return
 	}
 
-	err = util.CheckPassword(u.Password, gu.GetPassword())
+	//* пароль проверяет ecomm-grpc, хэш в ecomm-api не попадает
+	gu, err := h.client.VerifyCredentials(ctx, &pb.UserReq{Email: u.Email, Password: u.Password})
 
 	if err != nil {
-		http.Error(w, "wrong password", http.StatusUnauthorized)
+		if grpcErrorReason(err) == reasonInvalidCredentials {
+			attempt.failed()
+			writeInvalidCredentials(w, r)
+			return
+		}
+
+		writeGRPCError(w, r, err, "error verifying credentials")
+		return
+	}
+
+	if h.verification.Mode == VerificationLogin && gu.GetEmailVerifiedAt() == nil {
+		writeProblem(w, r, http.StatusForbidden, ErrCodeEmailNotVerified, "email must be verified before login")
+		return
+	}
+
+	//* с двухфакторной аутентификацией токены выдаются только после POST /users/login/mfa
+	if gu.GetMfaEnabled() {
+		h.writeMFAChallenge(w, r, gu)
 		return
 	}
 
+	attempt.succeeded()
+	h.completeLogin(ctx, w, r, gu)
+}
+
+// * выдача токенов и создание сессии после проверки всех факторов входа,
+// * ctx - контекст с сервисным токеном
+func (h *handler) completeLogin(ctx context.Context, w http.ResponseWriter, r *http.Request, gu *pb.UserRes) {
 	// * если пароль верный мы можем создать токен и вернуть в качестве ответа
 	//* json web token (jwt)
 
-	accessToken, accessClaims, err := h.TokenMaker.CreateToken(gu.GetId(), gu.GetEmail(), gu.GetIsAdmin(), time.Minute*15)
+	//* метод для создания токена обновления доступа, его id - это id сессии
+	refreshToken, refreshClaims, err := h.TokenMaker.CreateToken(gu.GetId(), gu.GetEmail(), gu.GetIsAdmin(), nil, "", time.Hour*24)
 
 	if err != nil {
-		http.Error(w, "error creating token", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
 		return
 	}
 
-	//* метод для создания токена обновления доступа
-	refreshToken, refreshClaims, err := h.TokenMaker.CreateToken(gu.GetId(), gu.GetEmail(), gu.GetIsAdmin(), time.Hour*24)
+	//* токен доступа ссылается на сессию (sid), чтобы выход из системы его отменял,
+	//* и содержит права ролей пользователя
+	accessToken, accessClaims, err := h.TokenMaker.CreateToken(gu.GetId(), gu.GetEmail(), gu.GetIsAdmin(), gu.GetPermissions(), refreshClaims.RegisteredClaims.ID, time.Minute*15)
 
 	if err != nil {
-		http.Error(w, "error creating token", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
 		return
 	}
 
 	//* создать сессию для хранения токена обновления в базе данных
-	session, err := h.client.CreateSession(h.ctx, &pb.SessionReq{
+	session, err := h.client.CreateSession(ctx, &pb.SessionReq{
 		Id:           refreshClaims.RegisteredClaims.ID,
 		UserEmail:    gu.GetEmail(),
 		RefreshToken: refreshToken,
 		IsRevoked:    false,
 		ExpiresAt:    timestamppb.New(refreshClaims.RegisteredClaims.ExpiresAt.Time),
+		UserAgent:    r.UserAgent(),
+		IpAddress:    clientIP(r),
 	})
 
 	if err != nil {
-		log.Printf("Error creating session: %v", err)
-		http.Error(w, "error creating session", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error creating session")
 		return
 	}
 
@@ -629,11 +925,13 @@ func (h *handler) logoutUser(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	_, err := h.client.DeleteSession(h.ctx, &pb.SessionReq{Id: claims.RegisteredClaims.ID})
+	_, err := h.client.DeleteSession(r.Context(), &pb.SessionReq{Id: claims.SessionID})
 	if err != nil {
-		http.Error(w, "error deleting session", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error deleting session")
 		return
 	}
+
+	h.sessions.Revoke(claims.SessionID)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -643,48 +941,89 @@ func (h *handler) renewAccessToken(w http.ResponseWriter, r *http.Request) {
 	var req RefreshTokenReq
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
 		return
 	}
 
 	//* для проверки токена обновления
 	refreshClaims, err := h.TokenMaker.VerifyToken(req.RefreshToken)
 	if err != nil {
-		http.Error(w, "error verifying token", http.StatusUnauthorized)
+		writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "error verifying token")
 		return
 	}
 
-	//* получим сессии из базы данных
-	session, err := h.client.GetSession(h.ctx, &pb.SessionReq{Id: refreshClaims.RegisteredClaims.ID})
+	//* маршрут без auth: токен доступа и токен сервиса вместо токена обновления не принимаются
+	if refreshClaims.SessionID != "" || refreshClaims.IsService() {
+		writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "not a refresh token")
+		return
+	}
+
+	ctx, err := h.serviceContext(r.Context())
 
 	if err != nil {
-		http.Error(w, "error getting session", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
 		return
 	}
 
-	//* проверим не отозвана ли эта сессия
-	if session.IsRevoked {
-		http.Error(w, "session revoked", http.StatusUnauthorized)
+	//* роли могли измениться после входа, поэтому права берем заново
+	gu, err := h.client.GetUser(ctx, &pb.UserReq{Email: refreshClaims.Email})
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "user not found")
+			return
+		}
+
+		writeGRPCError(w, r, err, "error getting user")
 		return
 	}
 
-	//* проверка совпадает ли адрес электронной почты с адресом в refreshClaims.Email
-	if session.GetUserEmail() != refreshClaims.Email {
-		http.Error(w, "session revoked", http.StatusUnauthorized)
+	//* получим данные для нового токена доступа и нового токена обновления
+	refreshToken, newRefreshClaims, err := h.TokenMaker.CreateToken(gu.GetId(), gu.GetEmail(), gu.GetIsAdmin(), nil, "", time.Hour*24)
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
 		return
 	}
 
-	//* получим данные для токена доступа и ошибку
-	accessToken, accessClaims, err := h.TokenMaker.CreateToken(refreshClaims.ID, refreshClaims.Email, refreshClaims.IsAdmin, time.Minute*15)
+	accessToken, accessClaims, err := h.TokenMaker.CreateToken(gu.GetId(), gu.GetEmail(), gu.GetIsAdmin(), gu.GetPermissions(), newRefreshClaims.RegisteredClaims.ID, time.Minute*15)
 
 	if err != nil {
-		http.Error(w, "error creating token", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
 		return
 	}
-	//* создадим ответ с токеном доступа
+
+	//* заменим сессию новой в том же семействе. Сервер проверяет, что сессия не отозвана,
+	//* принадлежит этому пользователю и еще не была заменена (иначе отзывает все семейство)
+	session, err := h.client.RotateSession(ctx, &pb.RotateSessionReq{
+		Id: refreshClaims.RegisteredClaims.ID,
+		Session: &pb.SessionReq{
+			Id:           newRefreshClaims.RegisteredClaims.ID,
+			UserEmail:    refreshClaims.Email,
+			RefreshToken: refreshToken,
+			ExpiresAt:    timestamppb.New(newRefreshClaims.RegisteredClaims.ExpiresAt.Time),
+			UserAgent:    r.UserAgent(),
+			IpAddress:    clientIP(r),
+		},
+	})
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			writeProblem(w, r, http.StatusUnauthorized, ErrCodeSessionRevoked, "session not found")
+			return
+		}
+
+		writeGRPCError(w, r, err, "error rotating session")
+		return
+	}
+
+	//* создадим ответ с токеном доступа и новым токеном обновления
 	res := RenewAccessTokenRes{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessClaims.RegisteredClaims.ExpiresAt.Time,
+		SessionID:             session.GetId(),
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  accessClaims.RegisteredClaims.ExpiresAt.Time,
+		RefreshTokenExpiresAt: newRefreshClaims.RegisteredClaims.ExpiresAt.Time,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -703,10 +1042,86 @@ func (h *handler) revokeSession(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	_, err := h.client.RevokeSession(h.ctx, &pb.SessionReq{Id: claims.RegisteredClaims.ID})
+	_, err := h.client.RevokeSession(r.Context(), &pb.SessionReq{Id: claims.SessionID})
 	if err != nil {
-		http.Error(w, "error revoking session", http.StatusInternalServerError)
+		writeGRPCError(w, r, err, "error revoking session")
 		return
 	}
+
+	h.sessions.Revoke(claims.SessionID)
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// * список активных сессий текущего пользователя
+func (h *handler) listSessions(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+
+	sessions, err := h.client.ListSessions(r.Context(), &pb.SessionReq{UserEmail: claims.Email})
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error listing sessions")
+		return
+	}
+
+	res := ListSessionRes{Sessions: []SessionRes{}}
+	for _, s := range sessions.GetSessions() {
+		res.Sessions = append(res.Sessions, toSessionRes(s))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// * выход на одном устройстве: отзыв сессии по id
+func (h *handler) revokeUserSession(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	_, err := h.client.RevokeSession(r.Context(), &pb.SessionReq{Id: id})
+	if err != nil {
+		writeGRPCError(w, r, err, "error revoking session")
+		return
+	}
+
+	h.sessions.Revoke(id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// * выход на всех устройствах
+func (h *handler) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+
+	//* активные сессии нужны, чтобы сразу отклонять их токены доступа в этом экземпляре
+	sessions, err := h.client.ListSessions(r.Context(), &pb.SessionReq{UserEmail: claims.Email})
+	if err != nil {
+		writeGRPCError(w, r, err, "error listing sessions")
+		return
+	}
+
+	_, err = h.client.RevokeUserSessions(r.Context(), &pb.SessionReq{UserEmail: claims.Email})
+	if err != nil {
+		writeGRPCError(w, r, err, "error revoking sessions")
+		return
+	}
+
+	for _, s := range sessions.GetSessions() {
+		h.sessions.Revoke(s.GetId())
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// * открытые ключи для проверки токенов другими сервисами (RFC 7517),
+// * для форматов без JWKS (PASETO) - пустой набор
+func (h *handler) jwks(w http.ResponseWriter, r *http.Request) {
+	set := token.JWKS{Keys: []token.JWK{}}
+	if publisher, ok := h.TokenMaker.(token.KeyPublisher); ok {
+		set = publisher.JWKS()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(set)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type fakeRenewClient struct {
+	fakeMFAClient
+
+	rotated *pb.RotateSessionReq
+}
+
+func (f *fakeRenewClient) RotateSession(_ context.Context, req *pb.RotateSessionReq, _ ...grpc.CallOption) (*pb.SessionRes, error) {
+	f.rotated = req
+	return &pb.SessionRes{Id: req.GetSession().GetId()}, nil
+}
+
+func TestRenewAccessToken(t *testing.T) {
+	client := &fakeRenewClient{fakeMFAClient: fakeMFAClient{user: &pb.UserRes{Id: 7, Name: "user", Email: "user@example.com"}}}
+	maker := token.NewJWTMaker("01234567890123456789012345678901")
+
+	h := NewHandler(Config{Client: client, TokenMaker: maker, Mailer: &fakeMailer{}, SessionCacheTTL: DefaultSessionCacheTTL})
+	router := RegisterRoutes(h)
+
+	refreshToken, refreshClaims, err := maker.CreateToken(7, "user@example.com", false, nil, "", time.Hour)
+	require.NoError(t, err)
+
+	//* токен доступа этой сессии уже истек
+	accessToken, _, err := maker.CreateToken(7, "user@example.com", false, nil, refreshClaims.RegisteredClaims.ID, -time.Minute)
+	require.NoError(t, err)
+
+	renew := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tokens/renew", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := renew(`{"refresh_token":"` + refreshToken + `"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, refreshClaims.RegisteredClaims.ID, client.rotated.GetId())
+	require.Contains(t, w.Body.String(), `"access_token":"`)
+
+	//* токен доступа вместо токена обновления не принимается
+	client.rotated = nil
+	freshAccess, _, err := maker.CreateToken(7, "user@example.com", false, nil, refreshClaims.RegisteredClaims.ID, time.Minute)
+	require.NoError(t, err)
+
+	w = renew(`{"refresh_token":"` + freshAccess + `"}`)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeInvalidToken+`"`)
+	require.Nil(t, client.rotated)
+}
@@ -0,0 +1,439 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	DefaultLoginFreeAttempts    = 3
+	DefaultLoginBaseDelay       = time.Second
+	DefaultLoginMaxDelay        = time.Minute
+	DefaultLoginLockoutAfter    = 10
+	DefaultLoginLockoutDuration = 15 * time.Minute
+)
+
+// * при переполнении удаляются устаревшие счетчики, затем самые старые из тех, что уже не задерживают вход
+const maxLoginAttemptEntries = 10000
+
+// * LoginThrottle - защита входа от перебора. Неудачи считаются отдельно по email, по IP клиента и по паре email и IP:
+// * первые FreeAttempts без задержки, затем пауза удваивается от BaseDelay до MaxDelay.
+// * После LockoutAfter неудач блокируется на LockoutDuration только пара email и IP (LockoutAfter = 0 - без блокировки):
+// * иначе любой, кто знает email, мог бы заблокировать чужой аккаунт, а один клиент - всех за общим NAT.
+// * Shared - счетчики хранятся в ecomm-grpc и общие для всех реплик ecomm-api, иначе - в памяти процесса
+type LoginThrottle struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	Shared          bool
+}
+
+// * счетчик без неудач дольше самой долгой паузы начинается заново
+func (t LoginThrottle) window() time.Duration {
+	return max(t.LockoutDuration, t.MaxDelay)
+}
+
+func (t LoginThrottle) locked(failures int64) bool {
+	return t.LockoutAfter > 0 && failures >= int64(t.LockoutAfter)
+}
+
+// * пауза после failures неудач подряд, lockout - ключ может быть заблокирован
+func (t LoginThrottle) delay(failures int64, lockout bool) time.Duration {
+	switch {
+	case lockout && t.locked(failures):
+		return t.LockoutDuration
+	case failures <= int64(t.FreeAttempts):
+		return 0
+	}
+
+	shift := failures - int64(t.FreeAttempts) - 1
+	if shift >= 30 {
+		return t.MaxDelay
+	}
+
+	return min(t.BaseDelay<<shift, t.MaxDelay)
+}
+
+// * сколько еще ждать до следующей попытки, 0 - вход разрешен
+func (t LoginThrottle) blockedFor(a LoginAttempt, now time.Time, lockout bool) time.Duration {
+	return max(a.LastFailure.Add(t.delay(a.Failures, lockout)).Sub(now), 0)
+}
+
+// * LoginAttempt - неудачные попытки входа по ключу
+type LoginAttempt struct {
+	Failures    int64
+	LastFailure time.Time
+}
+
+// * LoginAttemptStore хранит счетчики неудачных попыток входа
+type LoginAttemptStore interface {
+	Get(ctx context.Context, key string) (LoginAttempt, error)
+	// * AddFailure атомарно учитывает неудачу, счетчик без неудач дольше window начинается заново
+	AddFailure(ctx context.Context, key string, window time.Duration) (LoginAttempt, error)
+	// * RemoveFailure отменяет неудачу failureAt, учтенную AddFailure. Если после нее неудач не было,
+	// * время последней неудачи возвращается к previousAt (нулевое - не менять)
+	RemoveFailure(ctx context.Context, key string, failureAt, previousAt time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// * memoryLoginAttempts - счетчики одного экземпляра ecomm-api
+type memoryLoginAttempts struct {
+	mu       sync.Mutex
+	entries  map[string]LoginAttempt
+	throttle LoginThrottle
+
+	now func() time.Time
+}
+
+func newMemoryLoginAttempts(throttle LoginThrottle) *memoryLoginAttempts {
+	return &memoryLoginAttempts{
+		entries:  make(map[string]LoginAttempt),
+		throttle: throttle,
+		now:      time.Now,
+	}
+}
+
+func (m *memoryLoginAttempts) Get(_ context.Context, key string) (LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.entries[key], nil
+}
+
+func (m *memoryLoginAttempts) AddFailure(_ context.Context, key string, window time.Duration) (LoginAttempt, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.entries[key]
+	if !ok || now.Sub(a.LastFailure) > window {
+		a = LoginAttempt{}
+
+		if !ok && len(m.entries) >= maxLoginAttemptEntries {
+			m.evict(now, window)
+		}
+	}
+
+	a.Failures++
+	a.LastFailure = now
+	m.entries[key] = a
+
+	return a, nil
+}
+
+// * удаляет устаревшие счетчики, а если их не хватило - самые старые из тех, что уже не задерживают вход.
+// * Заблокированные ключи и ключи с паузой не удаляются никогда, поэтому во время перебора с множества
+// * адресов записей может стать больше maxLoginAttemptEntries - до конца их пауз
+func (m *memoryLoginAttempts) evict(now time.Time, window time.Duration) {
+	var idle []string
+	for k, e := range m.entries {
+		switch {
+		case now.Sub(e.LastFailure) > window:
+			delete(m.entries, k)
+		//* тип ключа хранилищу неизвестен, поэтому блокировка проверяется для всех ключей
+		case m.throttle.blockedFor(e, now, true) == 0:
+			idle = append(idle, k)
+		}
+	}
+
+	if len(m.entries) < maxLoginAttemptEntries {
+		return
+	}
+
+	slices.SortFunc(idle, func(a, b string) int {
+		return m.entries[a].LastFailure.Compare(m.entries[b].LastFailure)
+	})
+
+	//* запас в десятую часть, чтобы не перебирать все записи при каждом новом ключе
+	for _, k := range idle {
+		if len(m.entries) < maxLoginAttemptEntries-maxLoginAttemptEntries/10 {
+			break
+		}
+
+		delete(m.entries, k)
+	}
+}
+
+func (m *memoryLoginAttempts) RemoveFailure(_ context.Context, key string, failureAt, previousAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+
+	a.Failures--
+	if a.Failures <= 0 {
+		delete(m.entries, key)
+		return nil
+	}
+
+	if a.LastFailure.Equal(failureAt) && !previousAt.IsZero() {
+		a.LastFailure = previousAt
+	}
+
+	m.entries[key] = a
+
+	return nil
+}
+
+func (m *memoryLoginAttempts) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+
+	return nil
+}
+
+// * grpcLoginAttempts - счетчики в хранилище ecomm-grpc, общие для всех реплик
+type grpcLoginAttempts struct {
+	h *handler
+}
+
+func (g grpcLoginAttempts) Get(ctx context.Context, key string) (LoginAttempt, error) {
+	ctx, err := g.h.serviceContext(ctx)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	res, err := g.h.client.GetLoginAttempt(ctx, &pb.LoginAttemptReq{Key: key})
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	return toLoginAttempt(res), nil
+}
+
+func (g grpcLoginAttempts) AddFailure(ctx context.Context, key string, window time.Duration) (LoginAttempt, error) {
+	ctx, err := g.h.serviceContext(ctx)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	res, err := g.h.client.AddLoginFailure(ctx, &pb.LoginAttemptReq{Key: key, Window: durationpb.New(window)})
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	return toLoginAttempt(res), nil
+}
+
+func (g grpcLoginAttempts) RemoveFailure(ctx context.Context, key string, failureAt, previousAt time.Time) error {
+	ctx, err := g.h.serviceContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := &pb.LoginAttemptReq{Key: key, FailureAt: timestamppb.New(failureAt)}
+	if !previousAt.IsZero() {
+		req.PreviousFailureAt = timestamppb.New(previousAt)
+	}
+
+	_, err = g.h.client.RemoveLoginFailure(ctx, req)
+	return err
+}
+
+func (g grpcLoginAttempts) Reset(ctx context.Context, key string) error {
+	ctx, err := g.h.serviceContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.h.client.ResetLoginAttempts(ctx, &pb.LoginAttemptReq{Key: key})
+	return err
+}
+
+func toLoginAttempt(res *pb.LoginAttemptRes) LoginAttempt {
+	a := LoginAttempt{Failures: res.GetFailures()}
+
+	if res.GetLastFailureAt() != nil {
+		a.LastFailure = res.GetLastFailureAt().AsTime()
+	}
+
+	return a
+}
+
+// * loginAttemptKey - счетчик неудачных попыток входа, lockout - ключ блокируется после LockoutAfter неудач,
+// * account - счетчик аккаунта, сбрасывается успешным входом
+type loginAttemptKey struct {
+	key     string
+	lockout bool
+	account bool
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipAttemptKey(r *http.Request) string {
+	return "ip:" + clientIP(r)
+}
+
+func accountIPAttemptKey(r *http.Request, email string) string {
+	return accountAttemptKey(email) + "|" + ipAttemptKey(r)
+}
+
+// * ключи счетчиков попытки входа: email и IP клиента только замедляют перебор, блокируется пара email и IP
+func loginAttemptKeys(r *http.Request, email string) []loginAttemptKey {
+	return []loginAttemptKey{
+		{key: accountAttemptKey(email), account: true},
+		{key: ipAttemptKey(r)},
+		{key: accountIPAttemptKey(r, email), lockout: true, account: true},
+	}
+}
+
+// * loginReservation - попытка входа, заранее учтенная неудачей по всем ключам. Так параллельные запросы
+// * не проверяют пароль по одному и тому же старому счетчику: каждый получает свой номер попытки.
+// * Неудача остается учтенной после failed, succeeded и release ее отменяют
+type loginReservation struct {
+	h        *handler
+	r        *http.Request
+	attempts []reservedLoginAttempt
+	done     bool
+}
+
+type reservedLoginAttempt struct {
+	loginAttemptKey
+	// * счетчик после учета этой попытки
+	attempt LoginAttempt
+	// * время предыдущей неудачи, нулевое - между проверкой и учетом были другие попытки
+	previousAt time.Time
+}
+
+// * reserveLoginAttempt учитывает попытку входа до проверки пароля, wait > 0 - попытка отклонена.
+// * Недоступность хранилища счетчиков вход не блокирует
+func (h *handler) reserveLoginAttempt(r *http.Request, keys []loginAttemptKey) (*loginReservation, time.Duration) {
+	ctx := r.Context()
+	now := time.Now()
+	window := h.loginThrottle.window()
+
+	//* уже заблокированные попытки не учитываются, иначе каждая из них продлевала бы паузу
+	var wait time.Duration
+	previous := make([]LoginAttempt, len(keys))
+
+	for i, k := range keys {
+		a, err := h.loginAttempts.Get(ctx, k.key)
+		if err != nil {
+			log.Printf("error getting login attempts for %s: %v", k.key, err)
+			continue
+		}
+
+		previous[i] = a
+		wait = max(wait, h.loginThrottle.blockedFor(a, now, k.lockout))
+	}
+
+	if wait > 0 {
+		return nil, wait
+	}
+
+	res := &loginReservation{h: h, r: r}
+
+	for i, k := range keys {
+		a, err := h.loginAttempts.AddFailure(ctx, k.key, window)
+		if err != nil {
+			log.Printf("error adding login failure for %s: %v", k.key, err)
+			continue
+		}
+
+		ra := reservedLoginAttempt{loginAttemptKey: k, attempt: a}
+
+		prev := previous[i]
+		if now.Sub(prev.LastFailure) > window {
+			prev = LoginAttempt{}
+		}
+
+		//* попытки других запросов, учтенные после проверки, только что записали неудачу
+		if a.Failures-1 > prev.Failures {
+			wait = max(wait, h.loginThrottle.delay(a.Failures-1, k.lockout))
+		} else {
+			ra.previousAt = prev.LastFailure
+		}
+
+		res.attempts = append(res.attempts, ra)
+	}
+
+	if wait > 0 {
+		res.release()
+		return nil, wait
+	}
+
+	return res, 0
+}
+
+// * failed оставляет попытку учтенной неудачей, блокировка ключа записывается в журнал аудита
+func (res *loginReservation) failed() {
+	res.done = true
+
+	for _, ra := range res.attempts {
+		if ra.lockout && res.h.loginThrottle.locked(ra.attempt.Failures) {
+			res.h.audit(newAuditEvent(res.r, "login.lockout", map[string]any{
+				"key":          ra.key,
+				"failures":     ra.attempt.Failures,
+				"locked_until": ra.attempt.LastFailure.Add(res.h.loginThrottle.LockoutDuration),
+			}))
+		}
+	}
+}
+
+// * успешный вход сбрасывает счетчики аккаунта, счетчик IP продолжает действовать
+func (res *loginReservation) succeeded() {
+	if res.done {
+		return
+	}
+	res.done = true
+
+	for _, ra := range res.attempts {
+		if !ra.account {
+			res.remove(ra)
+			continue
+		}
+
+		if err := res.h.loginAttempts.Reset(res.r.Context(), ra.key); err != nil {
+			log.Printf("error resetting login attempts for %s: %v", ra.key, err)
+		}
+	}
+}
+
+// * release отменяет попытку, которая не дошла до проверки пароля или прошла ее без входа
+func (res *loginReservation) release() {
+	if res.done {
+		return
+	}
+	res.done = true
+
+	for _, ra := range res.attempts {
+		res.remove(ra)
+	}
+}
+
+func (res *loginReservation) remove(ra reservedLoginAttempt) {
+	if err := res.h.loginAttempts.RemoveFailure(res.r.Context(), ra.key, ra.attempt.LastFailure, ra.previousAt); err != nil {
+		log.Printf("error removing login failure for %s: %v", ra.key, err)
+	}
+}
+
+func writeLoginThrottled(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeProblem(w, r, http.StatusTooManyRequests, ErrCodeTooManyRequests, "too many failed login attempts, try again later")
+}
+
+// * один ответ для неизвестного email и неверного пароля
+func writeInvalidCredentials(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidCredentials, "invalid email or password")
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/token"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottleDelay(t *testing.T) {
+	throttle := LoginThrottle{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+	}
+
+	tcs := []struct {
+		failures int64
+		delay    time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{7, 8 * time.Second},
+		{8, 10 * time.Second},
+		{9, 10 * time.Second},
+		{10, 15 * time.Minute},
+		{100, 15 * time.Minute},
+	}
+
+	for _, tc := range tcs {
+		require.Equal(t, tc.delay, throttle.delay(tc.failures, true), "failures %d", tc.failures)
+	}
+
+	//* ключи без блокировки только замедляются
+	require.Equal(t, 10*time.Second, throttle.delay(100, false))
+
+	//* пауза отсчитывается от последней неудачи
+	now := time.Now()
+	a := LoginAttempt{Failures: 5, LastFailure: now.Add(-time.Second)}
+	require.Equal(t, time.Second, throttle.blockedFor(a, now, true))
+	require.Zero(t, throttle.blockedFor(a, now.Add(time.Minute), true))
+
+	//* без блокировки пауза не больше MaxDelay
+	throttle.LockoutAfter = 0
+	require.Equal(t, 10*time.Second, throttle.delay(100, true))
+}
+
+func TestMemoryLoginAttempts(t *testing.T) {
+	store := newMemoryLoginAttempts(LoginThrottle{})
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		_, err := store.AddFailure(ctx, "account:user@example.com", time.Minute)
+		require.NoError(t, err)
+	}
+
+	a, err := store.Get(ctx, "account:user@example.com")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), a.Failures)
+
+	//* после window счетчик начинается заново
+	now = now.Add(2 * time.Minute)
+	a, err = store.AddFailure(ctx, "account:user@example.com", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), a.Failures)
+
+	require.NoError(t, store.Reset(ctx, "account:user@example.com"))
+	a, err = store.Get(ctx, "account:user@example.com")
+	require.NoError(t, err)
+	require.Zero(t, a.Failures)
+
+	//* отмена неудачи возвращает время предыдущей, последняя отмена удаляет ключ
+	first, err := store.AddFailure(ctx, "ip:10.0.0.1", time.Minute)
+	require.NoError(t, err)
+
+	now = now.Add(time.Second)
+	second, err := store.AddFailure(ctx, "ip:10.0.0.1", time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, store.RemoveFailure(ctx, "ip:10.0.0.1", second.LastFailure, first.LastFailure))
+	a, err = store.Get(ctx, "ip:10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, first, a)
+
+	require.NoError(t, store.RemoveFailure(ctx, "ip:10.0.0.1", first.LastFailure, time.Time{}))
+	require.NotContains(t, store.entries, "ip:10.0.0.1")
+}
+
+func TestMemoryLoginAttemptsEviction(t *testing.T) {
+	throttle := LoginThrottle{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Minute, LockoutAfter: 3, LockoutDuration: time.Hour}
+	store := newMemoryLoginAttempts(throttle)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	//* заблокированный ключ, ключ с паузой и ключи, которые вход уже не задерживают
+	store.entries["locked"] = LoginAttempt{Failures: 3, LastFailure: now.Add(-30 * time.Minute)}
+	store.entries["backoff"] = LoginAttempt{Failures: 2, LastFailure: now.Add(-time.Second)}
+	store.entries["expired"] = LoginAttempt{Failures: 3, LastFailure: now.Add(-2 * time.Hour)}
+	for i := range maxLoginAttemptEntries - 3 {
+		store.entries["idle:"+strconv.Itoa(i)] = LoginAttempt{Failures: 1, LastFailure: now.Add(-time.Duration(maxLoginAttemptEntries-i) * time.Millisecond)}
+	}
+
+	//* сначала удаляются устаревшие
+	_, err := store.AddFailure(ctx, "new", throttle.window())
+	require.NoError(t, err)
+	require.NotContains(t, store.entries, "expired")
+	require.Len(t, store.entries, maxLoginAttemptEntries)
+
+	//* затем самые старые из тех, что вход уже не задерживают
+	_, err = store.AddFailure(ctx, "other", throttle.window())
+	require.NoError(t, err)
+	require.Less(t, len(store.entries), maxLoginAttemptEntries)
+	require.NotContains(t, store.entries, "idle:0")
+	require.Contains(t, store.entries, "idle:"+strconv.Itoa(maxLoginAttemptEntries-4))
+
+	for _, k := range []string{"locked", "backoff", "new", "other"} {
+		require.Contains(t, store.entries, k)
+	}
+
+	//* если удалять нечего, новый ключ все равно учитывается
+	clear(store.entries)
+	for i := range maxLoginAttemptEntries {
+		store.entries["locked:"+strconv.Itoa(i)] = LoginAttempt{Failures: 3, LastFailure: now}
+	}
+
+	_, err = store.AddFailure(ctx, "new", throttle.window())
+	require.NoError(t, err)
+	require.Len(t, store.entries, maxLoginAttemptEntries+1)
+}
+
+// * racingLoginAttempts учитывает неудачу другого запроса сразу после каждой проверки счетчика
+type racingLoginAttempts struct {
+	*memoryLoginAttempts
+}
+
+func (r racingLoginAttempts) Get(ctx context.Context, key string) (LoginAttempt, error) {
+	a, err := r.memoryLoginAttempts.Get(ctx, key)
+	if err != nil {
+		return a, err
+	}
+
+	_, err = r.memoryLoginAttempts.AddFailure(ctx, key, time.Hour)
+	return a, err
+}
+
+func TestReserveLoginAttempt(t *testing.T) {
+	throttle := LoginThrottle{FreeAttempts: 1, BaseDelay: time.Hour, MaxDelay: time.Hour, LockoutAfter: 3, LockoutDuration: 2 * time.Hour}
+	h := NewHandler(Config{Client: &fakeMFAClient{}, TokenMaker: token.NewJWTMaker("01234567890123456789012345678901"), Mailer: &fakeMailer{}, SessionCacheTTL: DefaultSessionCacheTTL, LoginThrottle: throttle})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/login", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	keys := loginAttemptKeys(req, "user@example.com")
+
+	//* попытки, которые еще проверяют пароль, уже учтены: вторая проходит как первая неудача, третья ждет паузу
+	first, wait := h.reserveLoginAttempt(req, keys)
+	require.Zero(t, wait)
+	second, wait := h.reserveLoginAttempt(req, keys)
+	require.Zero(t, wait)
+
+	_, wait = h.reserveLoginAttempt(req, keys)
+	require.Equal(t, time.Hour, wait.Round(time.Hour))
+
+	//* отклоненная попытка не учитывается, верный пароль отменяет свою попытку
+	second.failed()
+	first.release()
+
+	a, err := h.loginAttempts.Get(context.Background(), accountAttemptKey("user@example.com"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), a.Failures)
+
+	//* неудача другого запроса между проверкой и учетом отклоняет попытку, хотя проверка ее пропустила
+	h.loginThrottle.FreeAttempts = 0
+	racing := racingLoginAttempts{newMemoryLoginAttempts(h.loginThrottle)}
+	h.loginAttempts = racing
+
+	_, wait = h.reserveLoginAttempt(req, keys)
+	require.Equal(t, time.Hour, wait)
+
+	//* отклоненная попытка отменена, осталась только неудача другого запроса
+	a, err = racing.memoryLoginAttempts.Get(context.Background(), accountAttemptKey("user@example.com"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), a.Failures)
+}
+
+func TestLoginLockout(t *testing.T) {
+	client := &fakeMFAClient{user: &pb.UserRes{Id: 7, Name: "user", Email: "user@example.com"}, password: "password"}
+
+	h := NewHandler(Config{Client: client, TokenMaker: token.NewJWTMaker("01234567890123456789012345678901"), Mailer: &fakeMailer{}, SessionCacheTTL: DefaultSessionCacheTTL,
+		LoginThrottle: LoginThrottle{FreeAttempts: 1, BaseDelay: time.Hour, MaxDelay: time.Hour, LockoutAfter: 2, LockoutDuration: 2 * time.Hour}})
+
+	var events []AuditEvent
+	h.audit = func(e AuditEvent) { events = append(events, e) }
+
+	login := func(body, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body))
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		h.loginUser(w, req)
+		return w
+	}
+
+	//* неизвестный email и неверный пароль неразличимы
+	unknown := login(`{"email":"nobody@example.com","password":"password"}`, "10.0.0.1")
+	wrong := login(`{"email":"user@example.com","password":"wrong-password"}`, "10.0.0.2")
+	require.Equal(t, http.StatusUnauthorized, unknown.Code)
+	require.Equal(t, http.StatusUnauthorized, wrong.Code)
+	require.Contains(t, unknown.Body.String(), `"code":"`+ErrCodeInvalidCredentials+`"`)
+	require.Contains(t, wrong.Body.String(), `"code":"`+ErrCodeInvalidCredentials+`"`)
+	require.Contains(t, unknown.Body.String(), `"detail":"invalid email or password"`)
+	require.Contains(t, wrong.Body.String(), `"detail":"invalid email or password"`)
+
+	//* вторая неудача с того же IP блокирует аккаунт только для этого IP
+	w := login(`{"email":"user@example.com","password":"wrong-password"}`, "10.0.0.2")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Len(t, events, 1)
+	require.Equal(t, "login.lockout", events[0].Type)
+	require.Equal(t, "account:user@example.com|ip:10.0.0.2", events[0].Fields["key"])
+
+	//* с другого IP действует только пауза по email
+	w = login(`{"email":"user@example.com","password":"password"}`, "10.0.0.3")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeTooManyRequests+`"`)
+	require.Equal(t, "3600", w.Header().Get("Retry-After"))
+
+	//* после паузы вход с другого IP проходит, а с IP перебора аккаунт еще заблокирован
+	h.loginAttempts.Reset(context.Background(), accountAttemptKey("user@example.com"))
+	w = login(`{"email":"user@example.com","password":"password"}`, "10.0.0.3")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	//* успешный вход и отклоненная попытка не остаются неудачами в счетчике IP
+	a, err := h.loginAttempts.Get(context.Background(), "ip:10.0.0.3")
+	require.NoError(t, err)
+	require.Zero(t, a.Failures)
+
+	w = login(`{"email":"user@example.com","password":"password"}`, "10.0.0.2")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "7200", w.Header().Get("Retry-After"))
+}
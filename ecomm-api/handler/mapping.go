@@ -1,8 +1,10 @@
 package handler
 
 import (
-	// "fmt"
-	// "strings"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"davidHwang/ecomm/ecomm-grpc/pb"
 )
@@ -34,6 +36,104 @@ func toProductRes(p *pb.ProductRes) ProductRes {
 	}
 }
 
+var productSortFields = map[string]pb.ProductSortField{
+	"id":         pb.ProductSortField_SORT_BY_ID,
+	"price":      pb.ProductSortField_SORT_BY_PRICE,
+	"rating":     pb.ProductSortField_SORT_BY_RATING,
+	"created_at": pb.ProductSortField_SORT_BY_CREATED_AT,
+}
+
+// * параметры запроса GET /products:
+// * page_size, cursor, category, min_price, max_price, in_stock, sort (price, -price, rating, -created_at ...)
+func toPBListProductsReq(q url.Values) (*pb.ListProductsReq, error) {
+	req := &pb.ListProductsReq{
+		Cursor:   q.Get("cursor"),
+		Category: q.Get("category"),
+	}
+
+	if v := q.Get("page_size"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid page_size: %s", v)
+		}
+		req.PageSize = int32(size)
+	}
+
+	if v := q.Get("min_price"); v != "" {
+		price, err := strconv.ParseFloat(v, 32)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid min_price: %s", v)
+		}
+		req.MinPrice = float32(price)
+	}
+
+	if v := q.Get("max_price"); v != "" {
+		price, err := strconv.ParseFloat(v, 32)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid max_price: %s", v)
+		}
+		req.MaxPrice = float32(price)
+	}
+
+	if req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		return nil, fmt.Errorf("min_price is greater than max_price")
+	}
+
+	if v := q.Get("in_stock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid in_stock: %s", v)
+		}
+		req.InStockOnly = inStock
+	}
+
+	if v := q.Get("sort"); v != "" {
+		field := strings.TrimPrefix(v, "-")
+
+		sortBy, ok := productSortFields[field]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort: %s", v)
+		}
+		req.SortBy = sortBy
+		req.SortDesc = strings.HasPrefix(v, "-")
+	}
+
+	return req, nil
+}
+
+// * параметры запроса GET /products/search: q, limit
+func toPBSearchProductsReq(q url.Values) (*pb.SearchProductsReq, error) {
+	req := &pb.SearchProductsReq{Query: strings.TrimSpace(q.Get("q"))}
+
+	if req.Query == "" {
+		return nil, fmt.Errorf("missing search query q")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit: %s", v)
+		}
+		req.Limit = int32(limit)
+	}
+
+	return req, nil
+}
+
+func toProductSearchRes(res *pb.SearchProductsRes) ProductSearchRes {
+	out := ProductSearchRes{Hits: []ProductSearchHit{}}
+
+	for _, h := range res.GetHits() {
+		out.Hits = append(out.Hits, ProductSearchHit{
+			Product:   toProductRes(h.GetProduct()),
+			Relevance: h.GetRelevance(),
+			Snippet:   h.GetSnippet(),
+		})
+	}
+
+	return out
+}
+
 func toPBOrderReq(o OrderReq) *pb.OrderReq {
 	return &pb.OrderReq{
 		PaymentMethod: o.PaymentMethod,
@@ -58,26 +158,35 @@ func toPBOrderItems(oi []*OrderItem) []*pb.OrderItem {
 	return res
 }
 
-// type OrderStatus string
+type OrderStatus string
 
-// const (
-// 	Pending   OrderStatus = "pending"
-// 	Shipped   OrderStatus = "shipped"
-// 	Delivered OrderStatus = "delivered"
-// )
+const (
+	Pending   OrderStatus = "pending"
+	Paid      OrderStatus = "paid"
+	Shipped   OrderStatus = "shipped"
+	Delivered OrderStatus = "delivered"
+	Cancelled OrderStatus = "cancelled"
+	Refunded  OrderStatus = "refunded"
+)
 
-// func toPBOrderStatus(s OrderStatus) (pb.OrderStatus, error) {
-// 	switch s {
-// 	case Pending:
-// 		return pb.OrderStatus_PENDING, nil
-// 	case Shipped:
-// 		return pb.OrderStatus_SHIPPED, nil
-// 	case Delivered:
-// 		return pb.OrderStatus_DELIVERED, nil
-// 	default:
-// 		return 0, fmt.Errorf("unknown order status: %s", s)
-// 	}
-// }
+func toPBOrderStatus(s OrderStatus) (pb.OrderStatus, error) {
+	switch s {
+	case Pending:
+		return pb.OrderStatus_PENDING, nil
+	case Paid:
+		return pb.OrderStatus_PAID, nil
+	case Shipped:
+		return pb.OrderStatus_SHIPPED, nil
+	case Delivered:
+		return pb.OrderStatus_DELIVERED, nil
+	case Cancelled:
+		return pb.OrderStatus_CANCELLED, nil
+	case Refunded:
+		return pb.OrderStatus_REFUNDED, nil
+	default:
+		return 0, fmt.Errorf("unknown order status: %s", s)
+	}
+}
 
 func toOrderRes(o *pb.OrderRes) OrderRes {
 	return OrderRes{
@@ -87,7 +196,17 @@ func toOrderRes(o *pb.OrderRes) OrderRes {
 		ShippingPrice: o.ShippingPrice,
 		TotalPrice:    o.TotalPrice,
 		Items:         toOrderItems(o.Items),
-		// Status:        strings.ToLower(o.GetStatus().String()),
+		Status:        strings.ToLower(o.GetStatus().String()),
+		Breakdown:     toPriceBreakdown(o.GetBreakdown()),
+	}
+}
+
+func toPriceBreakdown(b *pb.PriceBreakdown) PriceBreakdown {
+	return PriceBreakdown{
+		ItemsPrice:    b.GetItemsPrice(),
+		TaxPrice:      b.GetTaxPrice(),
+		ShippingPrice: b.GetShippingPrice(),
+		TotalPrice:    b.GetTotalPrice(),
 	}
 }
 
@@ -114,10 +233,53 @@ func toPBUserReq(u UserReq) *pb.UserReq {
 	}
 }
 
+func toPBAdminUserReq(u AdminUserReq) *pb.UserReq {
+	return &pb.UserReq{
+		Name:     u.Name,
+		Email:    u.Email,
+		Password: u.Password,
+		IsAdmin:  u.IsAdmin,
+		Roles:    u.Roles,
+	}
+}
+
 func toUserRes(u *pb.UserRes) UserRes {
 	return UserRes{
-		Name:    u.Name,
-		Email:   u.Email,
-		IsAdmin: u.IsAdmin,
+		Name:        u.Name,
+		Email:       u.Email,
+		IsAdmin:     u.IsAdmin,
+		Roles:       u.GetRoles(),
+		Permissions: u.GetPermissions(),
+		MFAEnabled:  u.GetMfaEnabled(),
+	}
+}
+
+func toListRoleRes(l *pb.ListRoleRes) ListRoleRes {
+	res := ListRoleRes{Roles: make([]RoleRes, 0, len(l.GetRoles()))}
+	for _, r := range l.GetRoles() {
+		res.Roles = append(res.Roles, RoleRes{
+			Name:        r.GetName(),
+			Description: r.GetDescription(),
+			Permissions: r.GetPermissions(),
+		})
+	}
+
+	return res
+}
+
+func toSessionRes(s *pb.SessionRes) SessionRes {
+	res := SessionRes{
+		ID:        s.GetId(),
+		Device:    deviceName(s.GetUserAgent()),
+		UserAgent: s.GetUserAgent(),
+		IPAddress: s.GetIpAddress(),
+		CreatedAt: s.GetCreatedAt().AsTime(),
 	}
+
+	if s.GetExpiresAt() != nil {
+		expiresAt := s.GetExpiresAt().AsTime()
+		res.ExpiresAt = &expiresAt
+	}
+
+	return res
 }
\ No newline at end of file
@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/token"
+	"davidHwang/ecomm/totp"
+	"davidHwang/ecomm/validate"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// * издатель в otpauth URI, так аккаунт подписан в приложении-аутентификаторе
+	totpIssuer = "ecomm"
+
+	// * сколько действует токен второго шага входа
+	mfaChallengeDuration = 5 * time.Minute
+
+	recoveryCodeCount = 10
+	// * 80 бит на код: 16 символов base32. С такой энтропией несоленого sha256 достаточно,
+	// * перебрать коды по утекшим хэшам невозможно
+	recoveryCodeSize = 10
+	// * символов base32 в группе кода через дефис
+	recoveryCodeGroup = 4
+)
+
+type TOTPEnrollRes struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// * код из приложения или одноразовый код восстановления
+type TOTPCodeReq struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// * коды восстановления показываются один раз, в ecomm-grpc хранятся только их хэши
+type TOTPConfirmRes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// * ответ входа, когда нужен второй фактор
+type MFAChallengeRes struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"mfa_token_expires_at"`
+}
+
+type LoginMFAReq struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+var totpConfirmRules = validate.Rules{
+	validate.Field("code", validate.Required()),
+}
+
+var loginMFARules = validate.Rules{
+	validate.Field("mfa_token", validate.Required()),
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// * коды восстановления вида abcd-efgh-ijkl-mnop и их хэши
+func newRecoveryCodes() ([]string, []string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("error generating recovery code: %w", err)
+		}
+
+		s := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+
+		groups := make([]string, 0, len(s)/recoveryCodeGroup)
+		for i := 0; i < len(s); i += recoveryCodeGroup {
+			groups = append(groups, s[i:i+recoveryCodeGroup])
+		}
+
+		code := strings.Join(groups, "-")
+
+		plain = append(plain, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return plain, hashes, nil
+}
+
+// * регистр, пробелы и дефисы при вводе кода не важны
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// * запрос к ecomm-grpc с кодом TOTP или хэшем кода восстановления
+func toPBTOTPReq(userID int64, code, recoveryCode string) *pb.TOTPReq {
+	req := &pb.TOTPReq{UserId: userID, Code: code}
+
+	if recoveryCode != "" {
+		req.RecoveryCodeHash = hashRecoveryCode(recoveryCode)
+	}
+
+	return req
+}
+
+// * начало подключения TOTP: новый секрет и otpauth URI для QR кода.
+// * TOTP включается только после POST /users/me/mfa/totp/confirm
+func (h *handler) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error generating secret")
+		return
+	}
+
+	_, err = h.client.EnrollTOTP(r.Context(), &pb.TOTPReq{UserId: claims.ID, Secret: secret})
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error enrolling totp")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TOTPEnrollRes{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, claims.Email, secret),
+	})
+}
+
+// * подтверждение первым кодом из приложения, в ответе - коды восстановления
+func (h *handler) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+
+	var req TOTPCodeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, req, totpConfirmRules) {
+		return
+	}
+
+	recoveryCodes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error generating recovery codes")
+		return
+	}
+
+	_, err = h.client.ConfirmTOTP(r.Context(), &pb.TOTPReq{UserId: claims.ID, Code: req.Code, RecoveryCodeHashes: hashes})
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error confirming totp")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TOTPConfirmRes{RecoveryCodes: recoveryCodes})
+}
+
+func (h *handler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+
+	var req TOTPCodeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	_, err := h.client.DisableTOTP(r.Context(), toPBTOTPReq(claims.ID, req.Code, req.RecoveryCode))
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error disabling totp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// * первый шаг входа для пользователя с TOTP: вместо токенов доступа - короткоживущий токен,
+// * который обменивается на них вместе с кодом в POST /users/login/mfa
+func (h *handler) writeMFAChallenge(w http.ResponseWriter, r *http.Request, gu *pb.UserRes) {
+	tokenStr, claims, err := h.actionTokens.CreateToken(token.PurposeMFALogin, gu.GetId(), gu.GetEmail(), mfaChallengeDuration)
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(MFAChallengeRes{
+		MFARequired: true,
+		MFAToken:    tokenStr,
+		ExpiresAt:   claims.ExpiresAt.Time,
+	})
+}
+
+// * второй шаг входа: токен из ответа POST /users/login и код TOTP или код восстановления
+func (h *handler) loginMFA(w http.ResponseWriter, r *http.Request) {
+	var req LoginMFAReq
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, req, loginMFARules) {
+		return
+	}
+
+	claims, err := h.actionTokens.VerifyToken(token.PurposeMFALogin, req.MFAToken)
+
+	if err != nil {
+		writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "invalid or expired mfa token")
+		return
+	}
+
+	//* неверные коды считаются вместе с неверными паролями
+	attemptKeys := loginAttemptKeys(r, claims.Email)
+
+	attempt, wait := h.reserveLoginAttempt(r, attemptKeys)
+	if wait > 0 {
+		writeLoginThrottled(w, r, wait)
+		return
+	}
+	defer attempt.release()
+
+	ctx, err := h.serviceContext(r.Context())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	_, err = h.client.VerifyTOTP(ctx, toPBTOTPReq(claims.UserID, req.Code, req.RecoveryCode))
+
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			attempt.failed()
+			writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidMFACode, "invalid or already used code")
+			return
+		}
+
+		writeGRPCError(w, r, err, "error verifying mfa code")
+		return
+	}
+
+	gu, err := h.client.GetUser(ctx, &pb.UserReq{Email: claims.Email})
+
+	//* email сменили после первого шага
+	if status.Code(err) == codes.NotFound || (err == nil && gu.GetId() != claims.UserID) {
+		writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "invalid or expired mfa token")
+		return
+	}
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error getting user")
+		return
+	}
+
+	attempt.succeeded()
+	h.completeLogin(ctx, w, r, gu)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/token"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// * ecomm-grpc с одним пользователем, у которого включен TOTP с кодом 123456
+type fakeMFAClient struct {
+	pb.EcommClient
+
+	user     *pb.UserRes
+	password string
+}
+
+func (f *fakeMFAClient) VerifyCredentials(_ context.Context, req *pb.UserReq, _ ...grpc.CallOption) (*pb.UserRes, error) {
+	if req.GetEmail() != f.user.GetEmail() || req.GetPassword() != f.password {
+		return nil, invalidCredentialsError()
+	}
+
+	return f.user, nil
+}
+
+// * ошибка ecomm-grpc для неверного email или пароля
+func invalidCredentialsError() error {
+	st, _ := status.New(codes.Unauthenticated, "invalid email or password").WithDetails(&errdetails.ErrorInfo{Reason: reasonInvalidCredentials})
+	return st.Err()
+}
+
+func (f *fakeMFAClient) GetUser(_ context.Context, req *pb.UserReq, _ ...grpc.CallOption) (*pb.UserRes, error) {
+	if req.GetEmail() != f.user.GetEmail() {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return f.user, nil
+}
+
+func (f *fakeMFAClient) VerifyTOTP(_ context.Context, req *pb.TOTPReq, _ ...grpc.CallOption) (*pb.TOTPRes, error) {
+	if req.GetUserId() != f.user.GetId() || (req.GetCode() != "123456" && req.GetRecoveryCodeHash() != hashRecoveryCode("abcd-efgh")) {
+		return nil, status.Error(codes.InvalidArgument, "invalid or already used code")
+	}
+
+	return &pb.TOTPRes{Enabled: true}, nil
+}
+
+func (f *fakeMFAClient) CreateSession(_ context.Context, req *pb.SessionReq, _ ...grpc.CallOption) (*pb.SessionRes, error) {
+	return &pb.SessionRes{Id: req.GetId(), UserEmail: req.GetUserEmail()}, nil
+}
+
+func TestLoginMFA(t *testing.T) {
+	client := &fakeMFAClient{user: &pb.UserRes{Id: 7, Name: "user", Email: "user@example.com", MfaEnabled: true}, password: "password"}
+
+	actionTokens, err := token.NewActionTokenMaker("01234567890123456789012345678901")
+	require.NoError(t, err)
+
+	h := NewHandler(Config{Client: client, TokenMaker: token.NewJWTMaker("01234567890123456789012345678901"), ActionTokens: actionTokens, Mailer: &fakeMailer{}, SessionCacheTTL: DefaultSessionCacheTTL})
+
+	post := func(handle http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body)))
+		return w
+	}
+
+	//* после пароля - только токен второго шага
+	w := post(h.loginUser, `{"email":"user@example.com","password":"password"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var challenge MFAChallengeRes
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&challenge))
+	require.True(t, challenge.MFARequired)
+	require.NotEmpty(t, challenge.MFAToken)
+	require.NotContains(t, w.Body.String(), "access_token")
+
+	w = post(h.loginMFA, `{"mfa_token":"`+challenge.MFAToken+`","code":"000000"}`)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeInvalidMFACode+`"`)
+
+	//* токен другого назначения не подходит
+	verifyTok, _, err := actionTokens.CreateToken(token.PurposeVerifyEmail, 7, "user@example.com", mfaChallengeDuration)
+	require.NoError(t, err)
+
+	w = post(h.loginMFA, `{"mfa_token":"`+verifyTok+`","code":"123456"}`)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeInvalidToken+`"`)
+
+	for _, body := range []string{
+		`{"mfa_token":"` + challenge.MFAToken + `","code":"123456"}`,
+		`{"mfa_token":"` + challenge.MFAToken + `","recovery_code":"ABCD EFGH"}`,
+	} {
+		w = post(h.loginMFA, body)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res LoginUserRes
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+		require.NotEmpty(t, res.AccessToken)
+		require.True(t, res.User.MFAEnabled)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	plain, hashes, err := newRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, plain, recoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for i, code := range plain {
+		require.Regexp(t, `^[a-z2-7]{4}(-[a-z2-7]{4}){3}$`, code)
+		require.Equal(t, hashes[i], hashRecoveryCode(strings.ToUpper(code)))
+
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+}
@@ -11,52 +11,58 @@ import (
 type authKey struct {
 }
 
-// * middleware администратора
-func GetAdminMiddlewareFunc(tokenMaker *token.JWTMaker) func(http.Handler) http.Handler {
+// * исходный токен доступа, передается в ecomm-grpc через MetadataInterceptor
+type authTokenKey struct {
+}
+
+// * RequirePermission пропускает запрос, только если у пользователя есть право perm.
+// * Используется после GetAuthMiddlewareFunc, который кладет claims в контекст
+func RequirePermission(perm string) func(http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-			//* сначала прочитаем заголовок авторизации
-			//* проверим токен на валидность
-			claims, err := verifyClaimsFromAuthHeader(r, tokenMaker)
+			claims, ok := r.Context().Value(authKey{}).(*token.UserClaims)
 
-			if err != nil {
-				http.Error(w, fmt.Sprintf("error verifying token: %w", err), http.StatusUnauthorized)
+			if !ok {
+				writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "authorization token is missing")
 				return
 			}
 
-			//! проверка на то что является ли пользователь администратором
-			if !claims.IsAdmin {
-				http.Error(w, "user is not admin", http.StatusForbidden)
+			//! проверка прав роли пользователя
+			if !claims.HasPermission(perm) {
+				writeProblem(w, r, http.StatusForbidden, ErrCodeForbidden, fmt.Sprintf("permission %q is required", perm))
 				return
 			}
 
-
-			//* передадим в контекст запроса токен и пользователя
-			ctx := context.WithValue(r.Context(), authKey{}, claims)
-
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r)
 
 		})
 	}
 
 }
-func GetAuthMiddlewareFunc(tokenMaker *token.JWTMaker) func(http.Handler) http.Handler {
+
+func GetAuthMiddlewareFunc(tokenMaker token.Maker, sessions SessionChecker) func(http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 			//* сначала прочитаем заголовок авторизации
 			//* проверим токен на валидность
-			claims, err := verifyClaimsFromAuthHeader(r, tokenMaker)
+			claims, tokenStr, err := verifyClaimsFromAuthHeader(r, tokenMaker)
 
 			if err != nil {
-				http.Error(w, fmt.Sprintf("error verifying token: %w", err), http.StatusUnauthorized)
+				writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, fmt.Sprintf("error verifying token: %v", err))
+				return
+			}
+
+			//* токен отклоняется, если его сессия отозвана (выход из системы)
+			if !checkSession(w, r, sessions, claims) {
 				return
 			}
 			//* передадим в контекст запроса токен и пользователя
 			ctx := context.WithValue(r.Context(), authKey{}, claims)
+			ctx = context.WithValue(ctx, authTokenKey{}, tokenStr)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 
@@ -65,27 +71,50 @@ func GetAuthMiddlewareFunc(tokenMaker *token.JWTMaker) func(http.Handler) http.H
 
 }
 
+// * токен доступа действует, пока действует его сессия.
+// * Токен без сессии (например токен обновления) не принимается
+func checkSession(w http.ResponseWriter, r *http.Request, sessions SessionChecker, claims *token.UserClaims) bool {
+	if claims.SessionID == "" {
+		writeProblem(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "token is not linked to a session")
+		return false
+	}
+
+	revoked, err := sessions.IsRevoked(r.Context(), claims.SessionID)
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error checking session")
+		return false
+	}
+
+	if revoked {
+		writeProblem(w, r, http.StatusUnauthorized, ErrCodeSessionRevoked, "session revoked")
+		return false
+	}
+
+	return true
+}
+
 // * вспомогательная функция
-func verifyClaimsFromAuthHeader(r *http.Request, tokenMaker *token.JWTMaker) (*token.UserClaims, error) {
+func verifyClaimsFromAuthHeader(r *http.Request, tokenMaker token.Maker) (*token.UserClaims, string, error) {
 	authHeader := r.Header.Get("Authorization")
 
 	if authHeader == "" {
-		return nil, fmt.Errorf("authorization header is missing")
+		return nil, "", fmt.Errorf("authorization header is missing")
 	}
 
 	fields := strings.Fields(authHeader)
 
 	if len(fields) != 2 || fields[0] != "Bearer" {
-		return nil, fmt.Errorf("invalid authorization header")
+		return nil, "", fmt.Errorf("invalid authorization header")
 	}
 
 	token := fields[1]
 	claims, err := tokenMaker.VerifyToken(token)
 
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		return nil, "", fmt.Errorf("invalid token: %w", err)
 	}
 
-	return claims, nil
+	return claims, token, nil
 
 }
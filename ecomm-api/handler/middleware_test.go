@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"davidHwang/ecomm/rbac"
+	"davidHwang/ecomm/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirePermission(t *testing.T) {
+	maker := token.NewJWTMaker("01234567890123456789012345678901")
+	sessions := fakeSessionChecker{}
+
+	mw := func(next http.Handler) http.Handler {
+		return GetAuthMiddlewareFunc(maker, sessions)(RequirePermission(rbac.PermProductsWrite)(next))
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tcs := []struct {
+		name    string
+		isAdmin bool
+		perms   []string
+		status  int
+	}{
+		{"customer", false, nil, http.StatusForbidden},
+		{"other permission", false, []string{rbac.PermOrdersRead}, http.StatusForbidden},
+		{"catalog manager", false, []string{rbac.PermProductsWrite}, http.StatusNoContent},
+		{"superadmin", false, []string{rbac.PermAll}, http.StatusNoContent},
+		{"admin", true, []string{rbac.PermAll}, http.StatusNoContent},
+		//* права администратора приходят в perms при выдаче токена, сам флаг прав не дает
+		{"admin flag without permissions", true, nil, http.StatusForbidden},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tok, _, err := maker.CreateToken(1, "user@example.com", tc.isAdmin, tc.perms, "session", time.Minute)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/products", nil)
+			req.Header.Set("Authorization", "Bearer "+tok)
+			w := httptest.NewRecorder()
+
+			mw(next).ServeHTTP(w, req)
+
+			require.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusForbidden {
+				require.Contains(t, w.Body.String(), `"code":"`+ErrCodeForbidden+`"`)
+			}
+		})
+	}
+
+	//* без GetAuthMiddlewareFunc claims в контексте нет
+	w := httptest.NewRecorder()
+	RequirePermission(rbac.PermProductsWrite)(next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/token"
+	"davidHwang/ecomm/validate"
+	"encoding/json"
+	"net/http"
+)
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+var changePasswordRules = validate.Rules{
+	validate.Field("current_password", validate.Required()),
+}
+
+// * смена пароля вошедшим пользователем. Неверный текущий пароль учитывается как неудачная
+// * попытка входа, все сессии, кроме текущей, отзываются
+func (h *handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	var req ChangePasswordReq
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, req, changePasswordRules, h.passwordPolicy.Rules("new_password")) {
+		return
+	}
+
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	attemptKeys := loginAttemptKeys(r, claims.Email)
+
+	attempt, wait := h.reserveLoginAttempt(r, attemptKeys)
+	if wait > 0 {
+		writeLoginThrottled(w, r, wait)
+		return
+	}
+	defer attempt.release()
+
+	ctx, err := h.serviceContext(r.Context())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	//* активные сессии нужны, чтобы сразу отклонять их токены доступа в этом экземпляре
+	sessions, err := h.client.ListSessions(ctx, &pb.SessionReq{UserEmail: claims.Email})
+	if err != nil {
+		writeGRPCError(w, r, err, "error listing sessions")
+		return
+	}
+
+	//* текущий пароль проверяет и новый хэширует ecomm-grpc
+	_, err = h.client.ChangePassword(ctx, &pb.PasswordChangeReq{
+		Email:           claims.Email,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+		KeepSessionId:   claims.SessionID,
+	})
+
+	if err != nil {
+		if grpcErrorReason(err) == reasonInvalidCredentials {
+			attempt.failed()
+			writeProblem(w, r, http.StatusForbidden, ErrCodeInvalidCredentials, "current password is incorrect")
+			return
+		}
+
+		writeGRPCError(w, r, err, "error changing password")
+		return
+	}
+
+	for _, s := range sessions.GetSessions() {
+		if s.GetId() != claims.SessionID {
+			h.sessions.Revoke(s.GetId())
+		}
+	}
+
+	attempt.succeeded()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/mailer"
+	"davidHwang/ecomm/validate"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	DefaultPasswordResetTTL    = time.Hour
+	DefaultPasswordResetLimit  = 3
+	DefaultPasswordResetWindow = time.Hour
+)
+
+// * PasswordReset - настройки сброса пароля.
+// * URL - страница сброса, токен добавляется параметром token, TTL - сколько действует ссылка,
+// * Limit - сколько запросов сброса разрешено для одного email за Window
+type PasswordReset struct {
+	URL    string
+	TTL    time.Duration
+	Limit  int
+	Window time.Duration
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+var forgotPasswordRules = validate.Rules{
+	validate.Field("email", validate.Required(), validate.Email()),
+}
+
+var resetPasswordRules = validate.Rules{
+	validate.Field("token", validate.Required()),
+}
+
+// * токен сброса - 32 случайных байта. В ecomm-grpc попадает только его sha256 хэш,
+// * поэтому утечка таблицы password_resets не позволяет сменить пароль
+func newPasswordResetToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating password reset token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashPasswordResetToken(tokenStr string) string {
+	sum := sha256.Sum256([]byte(tokenStr))
+	return hex.EncodeToString(sum[:])
+}
+
+func (h *handler) sendPasswordResetEmail(ctx context.Context, u *pb.UserRes, tokenStr string) {
+	link, err := url.Parse(h.passwordReset.URL)
+
+	if err != nil {
+		log.Printf("invalid password reset URL %q: %v", h.passwordReset.URL, err)
+		return
+	}
+
+	q := link.Query()
+	q.Set("token", tokenStr)
+	link.RawQuery = q.Encode()
+
+	err = h.mailer.Send(ctx, mailer.Message{
+		To:      u.GetEmail(),
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo set a new password open the link:\n%s\n\nThe link is valid for %s and can be used once. If you did not request a password reset, ignore this email.\n",
+			u.GetName(), link.String(), h.passwordReset.TTL),
+	})
+
+	if err != nil {
+		log.Printf("error sending password reset email to user %d: %v", u.GetId(), err)
+	}
+}
+
+// * запрос ссылки сброса пароля. Ответ всегда 202 (или 429 при превышении лимита для email),
+// * письмо отправляется в фоне, чтобы ни ответ, ни время ответа не выдавали, зарегистрирован ли email
+func (h *handler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordReq
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, req, forgotPasswordRules) {
+		return
+	}
+
+	//* лимит считается и для незарегистрированных email
+	if ok, retryAfter := h.resetLimiter.Allow(strings.ToLower(req.Email)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeProblem(w, r, http.StatusTooManyRequests, ErrCodeTooManyRequests, "too many password reset requests for this email, try again later")
+		return
+	}
+
+	tokenStr, err := newPasswordResetToken()
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	ctx, err := h.serviceContext(r.Context())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	u, err := h.client.CreatePasswordReset(ctx, &pb.PasswordResetReq{
+		Email:     req.Email,
+		TokenHash: hashPasswordResetToken(tokenStr),
+		ExpiresAt: timestamppb.New(time.Now().Add(h.passwordReset.TTL)),
+	})
+
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case err != nil:
+		writeGRPCError(w, r, err, "error creating password reset")
+		return
+	default:
+		go h.sendPasswordResetEmail(context.WithoutCancel(r.Context()), u, tokenStr)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// * новый пароль по токену из письма. Токен одноразовый, все сессии пользователя отзываются;
+// * экземпляры ecomm-api перестают принимать их токены доступа не позже чем через SESSION_CACHE_TTL
+func (h *handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordReq
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, req, resetPasswordRules, h.passwordPolicy.Rules("password")) {
+		return
+	}
+
+	ctx, err := h.serviceContext(r.Context())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	_, err = h.client.ResetPassword(ctx, &pb.PasswordResetReq{
+		TokenHash: hashPasswordResetToken(req.Token),
+		Password:  req.Password,
+	})
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidToken, "invalid or expired password reset token")
+			return
+		}
+
+		writeGRPCError(w, r, err, "error resetting password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// * ecomm-grpc с одним пользователем и одним токеном сброса
+type fakeResetClient struct {
+	pb.EcommClient
+
+	user      *pb.UserRes
+	tokenHash string
+	password  string
+}
+
+func (f *fakeResetClient) CreatePasswordReset(_ context.Context, req *pb.PasswordResetReq, _ ...grpc.CallOption) (*pb.UserRes, error) {
+	if req.GetEmail() != f.user.GetEmail() {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	f.tokenHash = req.GetTokenHash()
+	return f.user, nil
+}
+
+func (f *fakeResetClient) ResetPassword(_ context.Context, req *pb.PasswordResetReq, _ ...grpc.CallOption) (*pb.UserRes, error) {
+	if f.tokenHash == "" || req.GetTokenHash() != f.tokenHash {
+		return nil, status.Error(codes.NotFound, "password reset not found")
+	}
+
+	f.tokenHash = ""
+	f.password = req.GetPassword()
+	return f.user, nil
+}
+
+func TestPasswordReset(t *testing.T) {
+	client := &fakeResetClient{user: &pb.UserRes{Id: 7, Name: "user", Email: "user@example.com"}}
+	mail := &fakeMailer{}
+
+	h := NewHandler(Config{Client: client, TokenMaker: token.NewJWTMaker("01234567890123456789012345678901"), Mailer: mail, SessionCacheTTL: DefaultSessionCacheTTL,
+		PasswordReset: PasswordReset{URL: "https://shop.example.com/reset", TTL: time.Hour, Limit: 2, Window: time.Hour}})
+
+	post := func(handle http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest(http.MethodPost, "/users/password", strings.NewReader(body)))
+		return w
+	}
+
+	//* ответ для неизвестного email не отличается
+	w := post(h.forgotPassword, `{"email":"unknown@example.com"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	w = post(h.forgotPassword, `{"email":"user@example.com"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	require.Eventually(t, func() bool { return len(mail.messages()) == 1 }, time.Second, 10*time.Millisecond)
+
+	//* в ecomm-grpc передается только хэш токена из ссылки
+	tokenStr := mailLink(t, mail.messages()[0]).Query().Get("token")
+	require.NotEmpty(t, tokenStr)
+	require.Equal(t, hashPasswordResetToken(tokenStr), client.tokenHash)
+
+	w = post(h.resetPassword, `{"token":"`+tokenStr+`","password":"short"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeValidation+`"`)
+
+	w = post(h.resetPassword, `{"token":"`+tokenStr+`","password":"new-password"}`)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	//* пароль хэширует ecomm-grpc
+	require.Equal(t, "new-password", client.password)
+
+	//* токен одноразовый
+	w = post(h.resetPassword, `{"token":"`+tokenStr+`","password":"other-password"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeInvalidToken+`"`)
+
+	//* лимит по email без учета регистра
+	post(h.forgotPassword, `{"email":"user@example.com"}`)
+	w = post(h.forgotPassword, `{"email":"USER@example.com"}`)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeTooManyRequests+`"`)
+}
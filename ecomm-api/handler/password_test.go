@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/token"
+	"davidHwang/ecomm/validate"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type fakePasswordClient struct {
+	fakeMFAClient
+
+	changed *pb.PasswordChangeReq
+}
+
+func (f *fakePasswordClient) ListSessions(_ context.Context, _ *pb.SessionReq, _ ...grpc.CallOption) (*pb.ListSessionRes, error) {
+	return &pb.ListSessionRes{Sessions: []*pb.SessionRes{{Id: "current"}, {Id: "other"}}}, nil
+}
+
+func (f *fakePasswordClient) ChangePassword(_ context.Context, req *pb.PasswordChangeReq, _ ...grpc.CallOption) (*pb.UserRes, error) {
+	if req.GetEmail() != f.user.GetEmail() || req.GetCurrentPassword() != f.password {
+		return nil, invalidCredentialsError()
+	}
+
+	f.changed = req
+	return f.user, nil
+}
+
+func TestChangePassword(t *testing.T) {
+	client := &fakePasswordClient{fakeMFAClient: fakeMFAClient{user: &pb.UserRes{Id: 7, Name: "user", Email: "user@example.com"}, password: "password"}}
+
+	policy, err := validate.NewPasswordPolicy(10, "")
+	require.NoError(t, err)
+
+	h := NewHandler(Config{Client: client, TokenMaker: token.NewJWTMaker("01234567890123456789012345678901"), Mailer: &fakeMailer{}, SessionCacheTTL: DefaultSessionCacheTTL, PasswordPolicy: policy})
+
+	change := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/me/password", strings.NewReader(body))
+		claims := &token.UserClaims{ID: 7, Email: "user@example.com", SessionID: "current"}
+		w := httptest.NewRecorder()
+		h.changePassword(w, req.WithContext(context.WithValue(req.Context(), authKey{}, claims)))
+		return w
+	}
+
+	w := change(`{"current_password":"password","new_password":"too-short"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), `"field":"new_password"`)
+
+	w = change(`{"current_password":"wrong-password","new_password":"new long password"}`)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeInvalidCredentials+`"`)
+	require.Nil(t, client.changed)
+
+	w = change(`{"current_password":"password","new_password":"new long password"}`)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "password", client.changed.GetCurrentPassword())
+	require.Equal(t, "current", client.changed.GetKeepSessionId())
+	require.Equal(t, "new long password", client.changed.GetNewPassword())
+
+	//* токены других сессий сразу отклоняются этим экземпляром
+	revoked, err := h.sessions.IsRevoked(context.Background(), "other")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	//* пароль нельзя сменить через изменение профиля
+	req := httptest.NewRequest(http.MethodPatch, "/users", strings.NewReader(`{"name":"user","password":"new long password"}`))
+	req = req.WithContext(context.WithValue(req.Context(), authKey{}, &token.UserClaims{ID: 7, Email: "user@example.com"}))
+	w = httptest.NewRecorder()
+	h.UpdateUser(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), `"field":"password"`)
+}
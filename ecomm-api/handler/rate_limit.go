@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"sync"
+	"time"
+)
+
+// * при переполнении удаляются истекшие окна. Действующие окна не удаляются, иначе переполнение
+// * сбрасывало бы лимиты, поэтому записей может стать больше - до конца самого раннего из окон
+const maxRateLimitEntries = 10000
+
+// * rateLimiter - не больше limit событий по одному ключу (например email) за окно window.
+// * Счетчики хранятся в памяти процесса, каждый экземпляр ecomm-api считает отдельно
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	entries map[string]rateLimitEntry
+	// * до этого времени ни одно окно не истечет, очистка ничего не удалит
+	nextPrune time.Time
+
+	now func() time.Time
+}
+
+type rateLimitEntry struct {
+	count   int
+	resetAt time.Time
+}
+
+// * limit <= 0 отключает ограничение
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		entries: make(map[string]rateLimitEntry),
+		now:     time.Now,
+	}
+}
+
+// * Allow учитывает событие по ключу. Если лимит исчерпан, событие не учитывается,
+// * а вторым значением возвращается время до начала следующего окна
+func (l *rateLimiter) Allow(key string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok || !now.Before(e.resetAt) {
+		if len(l.entries) >= maxRateLimitEntries && !now.Before(l.nextPrune) {
+			l.prune(now)
+		}
+
+		l.entries[key] = rateLimitEntry{count: 1, resetAt: now.Add(l.window)}
+		return true, 0
+	}
+
+	if e.count >= l.limit {
+		return false, e.resetAt.Sub(now)
+	}
+
+	e.count++
+	l.entries[key] = e
+
+	return true, 0
+}
+
+func (l *rateLimiter) prune(now time.Time) {
+	l.nextPrune = now.Add(l.window)
+
+	for key, e := range l.entries {
+		switch {
+		case !now.Before(e.resetAt):
+			delete(l.entries, key)
+		case e.resetAt.Before(l.nextPrune):
+			l.nextPrune = e.resetAt
+		}
+	}
+}
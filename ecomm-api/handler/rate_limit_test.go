@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, time.Hour)
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for range 2 {
+		ok, _ := l.Allow("user@example.com")
+		require.True(t, ok)
+	}
+
+	ok, retryAfter := l.Allow("user@example.com")
+	require.False(t, ok)
+	require.Equal(t, time.Hour, retryAfter)
+
+	// * ключи считаются отдельно
+	ok, _ = l.Allow("other@example.com")
+	require.True(t, ok)
+
+	// * отклоненные события не продлевают окно
+	now = now.Add(40 * time.Minute)
+	ok, retryAfter = l.Allow("user@example.com")
+	require.False(t, ok)
+	require.Equal(t, 20*time.Minute, retryAfter)
+
+	now = now.Add(20 * time.Minute)
+	ok, _ = l.Allow("user@example.com")
+	require.True(t, ok)
+
+	// * нулевой лимит отключает ограничение
+	l = newRateLimiter(0, time.Hour)
+	for range 10 {
+		ok, _ = l.Allow("user@example.com")
+		require.True(t, ok)
+	}
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	l := newRateLimiter(1, time.Hour)
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("blocked@example.com")
+	require.True(t, ok)
+
+	now = now.Add(30 * time.Minute)
+	for i := range maxRateLimitEntries - 1 {
+		ok, _ = l.Allow("user" + strconv.Itoa(i) + "@example.com")
+		require.True(t, ok)
+	}
+
+	// * переполнение не сбрасывает действующие окна
+	ok, _ = l.Allow("new@example.com")
+	require.True(t, ok)
+	require.Len(t, l.entries, maxRateLimitEntries+1)
+
+	ok, _ = l.Allow("blocked@example.com")
+	require.False(t, ok)
+
+	// * истекшие окна удаляются
+	now = now.Add(30 * time.Minute)
+	ok, _ = l.Allow("other@example.com")
+	require.True(t, ok)
+	require.Len(t, l.entries, maxRateLimitEntries+1)
+	require.NotContains(t, l.entries, "blocked@example.com")
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"davidHwang/ecomm/rbac"
 	"log"
 	"net/http"
 
@@ -12,18 +13,26 @@ var r *chi.Mux
 
 func RegisterRoutes(handler *handler) *chi.Mux {
 	r = chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(trustedRealIP(handler.trustedProxies))
 	r.Use(middleware.Logger)
+	r.Use(getTimeoutMiddlewareFunc(r, handler.timeouts))
 	tokenMaker := handler.TokenMaker
+	sessions := handler.sessions
+	auth := GetAuthMiddlewareFunc(tokenMaker, sessions)
+
+	r.Get("/.well-known/jwks.json", handler.jwks)
 
 	r.Route("/products", func(r chi.Router) {
-		r.With(GetAdminMiddlewareFunc(tokenMaker)).Post("/", handler.CreateProduct)
+		r.With(auth, RequirePermission(rbac.PermProductsWrite)).Post("/", handler.CreateProduct)
 		r.Get("/", handler.ListProducts)
+		r.Get("/search", handler.SearchProducts)
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", handler.getProduct)
 
 			r.Group(func(r chi.Router) {
-				r.Use(GetAdminMiddlewareFunc(tokenMaker))
+				r.Use(auth, RequirePermission(rbac.PermProductsWrite))
 				r.Patch("/", handler.UpdateProduct)
 				r.Delete("/", handler.DeleteProduct)
 			})
@@ -32,15 +41,16 @@ func RegisterRoutes(handler *handler) *chi.Mux {
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Use(GetAuthMiddlewareFunc(tokenMaker))
+		r.Use(auth)
 		r.Get("/myorder", handler.getOrder)
 
 		r.Route("/orders", func(r chi.Router) {
 			r.Post("/", handler.CreateOrder)
-			r.With(GetAdminMiddlewareFunc(tokenMaker)).Get("/", handler.ListOrders)
+			r.With(RequirePermission(rbac.PermOrdersRead)).Get("/", handler.ListOrders)
 
 			r.Route("/{id}", func(r chi.Router) {
 				r.Delete("/", handler.DeleteOrder)
+				r.With(RequirePermission(rbac.PermOrdersWrite)).Patch("/status", handler.UpdateOrderStatus)
 			})
 		})
 
@@ -50,35 +60,76 @@ func RegisterRoutes(handler *handler) *chi.Mux {
 		r.Post("/", handler.CreateUser)
 
 		r.Post("/login", handler.loginUser)
+		//* второй шаг входа с двухфакторной аутентификацией
+		r.Post("/login/mfa", handler.loginMFA)
+
+		//* подтверждение email
+		r.Post("/verify", handler.verifyEmail)
+		r.Post("/verify/resend", handler.resendVerification)
+
+		//* сброс забытого пароля по ссылке из письма
+		r.Post("/password/forgot", handler.forgotPassword)
+		r.Post("/password/reset", handler.resetPassword)
 
 		r.Group(func(r chi.Router) {
-			r.Use(GetAdminMiddlewareFunc(tokenMaker))
-			r.Get("/", handler.ListUsers)
+			r.Use(auth)
+			r.With(RequirePermission(rbac.PermUsersRead)).Get("/", handler.ListUsers)
 
 			r.Route("/{id}", func(r chi.Router) {
-				r.Delete("/", handler.DeleteUser)
+				r.With(RequirePermission(rbac.PermUsersWrite)).Delete("/", handler.DeleteUser)
 			})
 
 		})
 
 		r.Group(func(r chi.Router) {
-			r.Use(GetAuthMiddlewareFunc(tokenMaker))
+			r.Use(auth)
 
 			r.Patch("/", handler.UpdateUser)
 			r.Post("/logout", handler.logoutUser)
+
+			//* смена пароля с проверкой текущего
+			r.Post("/me/password", handler.changePassword)
+
+			//* устройства, на которых выполнен вход
+			r.Route("/me/sessions", func(r chi.Router) {
+				r.Get("/", handler.listSessions)
+				r.Delete("/", handler.revokeAllSessions)
+				r.Delete("/{id}", handler.revokeUserSession)
+			})
+
+			//* двухфакторная аутентификация (TOTP)
+			r.Route("/me/mfa/totp", func(r chi.Router) {
+				r.Post("/", handler.enrollTOTP)
+				r.Post("/confirm", handler.confirmTOTP)
+				r.Delete("/", handler.disableTOTP)
+			})
 		})
 
 	})
 
-	r.Group(func(r chi.Router) {
-		r.Use(GetAuthMiddlewareFunc(tokenMaker))
+	//* управление пользователями и их ролями
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(auth)
+
+		r.With(RequirePermission(rbac.PermUsersRead)).Get("/roles", handler.listRoles)
+		r.With(RequirePermission(rbac.PermUsersWrite)).Post("/users", handler.createAdminUser)
+
+		r.Route("/users/{id}/roles", func(r chi.Router) {
+			r.With(RequirePermission(rbac.PermUsersRead)).Get("/", handler.getUserRoles)
 
-		r.Route("/tokens", func(r chi.Router) {
-			//* обновление токена доступа
-			r.Post("/renew", handler.renewAccessToken)
-			r.Post("/revoke", handler.revokeSession)
+			r.Group(func(r chi.Router) {
+				r.Use(RequirePermission(rbac.PermRolesWrite))
+				r.Put("/{role}", handler.assignRole)
+				r.Delete("/{role}", handler.unassignRole)
+			})
 		})
+	})
 
+	r.Route("/tokens", func(r chi.Router) {
+		//* обновление токена доступа: токен доступа к этому времени обычно истек,
+		//* поэтому проверяется только токен обновления из тела запроса
+		r.Post("/renew", handler.renewAccessToken)
+		r.With(auth).Post("/revoke", handler.revokeSession)
 	})
 
 	return r
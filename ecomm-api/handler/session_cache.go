@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const DefaultSessionCacheTTL = 30 * time.Second
+
+// * SessionChecker проверяет, не отозвана ли сессия токена доступа
+type SessionChecker interface {
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+// * состояние сессии в ecomm-grpc, удаленная сессия (выход из системы) считается отозванной
+func (h *handler) lookupSession(ctx context.Context, sessionID string) (bool, error) {
+	ctx, err := h.serviceContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	session, err := h.client.GetSession(ctx, &pb.SessionReq{Id: sessionID})
+
+	if status.Code(err) == codes.NotFound {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return session.GetIsRevoked(), nil
+}
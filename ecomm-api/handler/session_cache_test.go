@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSessionChecker map[string]bool
+
+func (f fakeSessionChecker) IsRevoked(_ context.Context, id string) (bool, error) {
+	return f[id], nil
+}
+
+func TestAuthMiddlewareSessions(t *testing.T) {
+	maker := token.NewJWTMaker("01234567890123456789012345678901")
+	sessions := fakeSessionChecker{"revoked": true}
+
+	mw := GetAuthMiddlewareFunc(maker, sessions)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tcs := []struct {
+		name      string
+		sessionID string
+		status    int
+		code      string
+	}{
+		{"active session", "active", http.StatusNoContent, ""},
+		{"revoked session", "revoked", http.StatusUnauthorized, ErrCodeSessionRevoked},
+		{"token without session", "", http.StatusUnauthorized, ErrCodeInvalidToken},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tok, _, err := maker.CreateToken(1, "user@example.com", false, nil, tc.sessionID, time.Minute)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/myorder", nil)
+			req.Header.Set("Authorization", "Bearer "+tok)
+			w := httptest.NewRecorder()
+
+			mw(next).ServeHTTP(w, req)
+
+			require.Equal(t, tc.status, w.Code)
+			if tc.code != "" {
+				require.Contains(t, w.Body.String(), `"code":"`+tc.code+`"`)
+			}
+		})
+	}
+}
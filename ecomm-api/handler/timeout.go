@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const DefaultRouteTimeout = 10 * time.Second
+
+// * RouteTimeouts - дедлайны запросов: Default для всех маршрутов и переопределения
+// * по ключу "METHOD /pattern" (например "GET /products/search" или "POST /orders")
+type RouteTimeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// * разбирает строку вида "GET /products/search=3s,POST /orders=15s"
+func ParseRouteTimeouts(def time.Duration, s string) (RouteTimeouts, error) {
+	rt := RouteTimeouts{Default: def, Routes: make(map[string]time.Duration)}
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return RouteTimeouts{}, fmt.Errorf("invalid route timeout %q, expected \"METHOD /pattern=duration\"", entry)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return RouteTimeouts{}, fmt.Errorf("invalid duration in route timeout %q", entry)
+		}
+
+		method, pattern, ok := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok {
+			return RouteTimeouts{}, fmt.Errorf("invalid route timeout %q, expected \"METHOD /pattern=duration\"", entry)
+		}
+
+		rt.Routes[routeKey(method, pattern)] = d
+	}
+
+	return rt, nil
+}
+
+func routeKey(method, pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	return strings.ToUpper(strings.TrimSpace(method)) + " " + pattern
+}
+
+func (rt RouteTimeouts) timeout(method, pattern string) time.Duration {
+	if d, ok := rt.Routes[routeKey(method, pattern)]; ok {
+		return d
+	}
+
+	return rt.Default
+}
+
+// * middleware дедлайна запроса: контекст отменяется по таймауту маршрута или при отключении клиента,
+// * вместе с ним отменяются вызовы ecomm-grpc и запросы к базе данных
+func getTimeoutMiddlewareFunc(mux *chi.Mux, timeouts RouteTimeouts) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern := mux.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+
+			d := timeouts.timeout(r.Method, pattern)
+			if d <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	rt, err := ParseRouteTimeouts(time.Second, "GET /products/search=3s, post /orders/=15s")
+	require.NoError(t, err)
+	require.Equal(t, 3*time.Second, rt.timeout(http.MethodGet, "/products/search"))
+	require.Equal(t, 15*time.Second, rt.timeout(http.MethodPost, "/orders/"))
+	require.Equal(t, time.Second, rt.timeout(http.MethodGet, "/orders/"))
+
+	for _, s := range []string{"GET /products", "/products=1s", "GET /products=soon", "GET /products=-1s"} {
+		_, err := ParseRouteTimeouts(time.Second, s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	timeouts := RouteTimeouts{
+		Default: time.Minute,
+		Routes:  map[string]time.Duration{"GET /products/{id}": time.Second},
+	}
+
+	var remaining time.Duration
+	record := func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		require.True(t, ok)
+		remaining = time.Until(deadline)
+	}
+
+	mux := chi.NewRouter()
+	mux.Use(getTimeoutMiddlewareFunc(mux, timeouts))
+	mux.Route("/products", func(r chi.Router) {
+		r.Get("/", record)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", record)
+		})
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/1", nil))
+	require.LessOrEqual(t, remaining, time.Second)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products", nil))
+	require.Greater(t, remaining, time.Second)
+}
@@ -28,6 +28,21 @@ type ProductRes struct {
 	UpdatedAt    *time.Time `json:"updated_at"`
 }
 
+type ListProductRes struct {
+	Products   []ProductRes `json:"products"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type ProductSearchHit struct {
+	Product   ProductRes `json:"product"`
+	Relevance float64    `json:"relevance"`
+	Snippet   string     `json:"snippet"`
+}
+
+type ProductSearchRes struct {
+	Hits []ProductSearchHit `json:"hits"`
+}
+
 //* ORDERS
 type OrderReq struct {
 	Items         []*OrderItem `json:"items"`
@@ -52,10 +67,24 @@ type OrderRes struct {
 	TaxPrice      float32     `json:"tax_price"`
 	ShippingPrice float32     `json:"shipping_price"`
 	TotalPrice    float32     `json:"total_price"`
+	Status        string      `json:"status"`
+	Breakdown     PriceBreakdown `json:"breakdown"`
 	CreatedAt     time.Time   `json:"created_at"`
 	UpdatedAt     *time.Time  `json:"updated_at"`
 }
 
+// * расчет стоимости заказа на сервере
+type PriceBreakdown struct {
+	ItemsPrice    float32 `json:"items_price"`
+	TaxPrice      float32 `json:"tax_price"`
+	ShippingPrice float32 `json:"shipping_price"`
+	TotalPrice    float32 `json:"total_price"`
+}
+
+type UpdateOrderStatusReq struct {
+	Status OrderStatus `json:"status"`
+}
+
 //* USERS
 
 type UserReq struct {
@@ -65,16 +94,40 @@ type UserReq struct {
 	IsAdmin  bool   `json:"is_admin"`
 }
 
+// * создание пользователя администратором: можно выдать права администратора и роли
+type AdminUserReq struct {
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+	Password string   `json:"password"`
+	IsAdmin  bool     `json:"is_admin"`
+	Roles    []string `json:"roles"`
+}
+
 type UserRes struct {
-	Name    string `json:"name"`
-	Email   string `json:"email"`
-	IsAdmin bool   `json:"is_admin"`
+	Name        string   `json:"name"`
+	Email       string   `json:"email"`
+	IsAdmin     bool     `json:"is_admin"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	MFAEnabled  bool     `json:"mfa_enabled"`
 }
 
 type ListUserRes struct {
 	Users []UserRes `json:"users"`
 }
 
+//* ROLES
+
+type RoleRes struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type ListRoleRes struct {
+	Roles []RoleRes `json:"roles"`
+}
+
 //! AUTh USERS TYPE
 
 type LoginUserReq struct {
@@ -108,7 +161,26 @@ type RefreshTokenReq struct {
 // * структуру ответа токена  обновления доступа 
 // * где вернем токен доступа
 // * также срок действия токена доступа истекает 
+// * при каждом обновлении выдается и новый токен обновления, старый перестает действовать
 type RenewAccessTokenRes struct {
-	AccessToken string `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             string    `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	RefreshToken          string    `json:"refresh_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// * активная сессия пользователя - устройство, на котором выполнен вход
+type SessionRes struct {
+	ID        string    `json:"id"`
+	Device    string    `json:"device"`
+	UserAgent string    `json:"user_agent"`
+	IPAddress string    `json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+	// * nil - сессия создана без срока
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ListSessionRes struct {
+	Sessions []SessionRes `json:"sessions"`
 }
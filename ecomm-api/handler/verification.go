@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/mailer"
+	"davidHwang/ecomm/token"
+	"davidHwang/ecomm/validate"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// * сколько действует ссылка подтверждения email
+const verificationTokenDuration = 24 * time.Hour
+
+// * EmailVerificationMode - что нельзя делать до подтверждения email
+type EmailVerificationMode string
+
+const (
+	// * подтверждение не требуется
+	VerificationOff EmailVerificationMode = "off"
+	// * вход (и все остальное) только после подтверждения
+	VerificationLogin EmailVerificationMode = "login"
+	// * вход разрешен, заказы только после подтверждения
+	VerificationOrder EmailVerificationMode = "order"
+)
+
+func ParseEmailVerificationMode(s string) (EmailVerificationMode, error) {
+	switch m := EmailVerificationMode(s); m {
+	case VerificationOff, VerificationLogin, VerificationOrder:
+		return m, nil
+	case "":
+		return VerificationOff, nil
+	}
+
+	return "", fmt.Errorf("unknown email verification mode %q, expected off, login or order", s)
+}
+
+const (
+	DefaultVerificationResendLimit  = 3
+	DefaultVerificationResendWindow = time.Hour
+)
+
+// * EmailVerification - настройки подтверждения email.
+// * URL - страница подтверждения, токен добавляется параметром token,
+// * ResendLimit - сколько повторных писем разрешено для одного email за ResendWindow
+type EmailVerification struct {
+	Mode         EmailVerificationMode
+	URL          string
+	ResendLimit  int
+	ResendWindow time.Duration
+}
+
+type VerifyEmailReq struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationReq struct {
+	Email string `json:"email"`
+}
+
+var resendVerificationRules = validate.Rules{
+	validate.Field("email", validate.Required(), validate.Email()),
+}
+
+// * письмо со ссылкой подтверждения, ошибки отправки только логируются:
+// * пользователь может запросить письмо повторно. Обработчики отправляют его в отдельной горутине,
+// * чтобы медленный почтовый сервер не задерживал ответ
+func (h *handler) sendVerificationEmail(ctx context.Context, u *pb.UserRes) {
+	tokenStr, _, err := h.actionTokens.CreateToken(token.PurposeVerifyEmail, u.GetId(), u.GetEmail(), verificationTokenDuration)
+
+	if err != nil {
+		log.Printf("error creating verification token for user %d: %v", u.GetId(), err)
+		return
+	}
+
+	link, err := url.Parse(h.verification.URL)
+
+	if err != nil {
+		log.Printf("invalid verification URL %q: %v", h.verification.URL, err)
+		return
+	}
+
+	q := link.Query()
+	q.Set("token", tokenStr)
+	link.RawQuery = q.Encode()
+
+	err = h.mailer.Send(ctx, mailer.Message{
+		To:      u.GetEmail(),
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello, %s!\n\nConfirm your email by opening the link:\n%s\n\nThe link is valid for %s.\n",
+			u.GetName(), link.String(), verificationTokenDuration),
+	})
+
+	if err != nil {
+		log.Printf("error sending verification email to user %d: %v", u.GetId(), err)
+	}
+}
+
+// * подтверждение email по токену из письма. Токен одноразовый: после подтверждения
+// * повторный вызов возвращает email_already_verified, а смена email делает токен недействительным
+func (h *handler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailReq
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	claims, err := h.actionTokens.VerifyToken(token.PurposeVerifyEmail, req.Token)
+
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidToken, "invalid or expired verification token")
+		return
+	}
+
+	ctx, err := h.serviceContext(r.Context())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	u, err := h.client.VerifyEmail(ctx, &pb.UserReq{Id: claims.UserID, Email: claims.Email})
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidToken, "invalid or expired verification token")
+			return
+		}
+
+		writeGRPCError(w, r, err, "error verifying email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toUserRes(u))
+}
+
+// * повторная отправка письма. Ответ всегда 202, чтобы по нему нельзя было узнать,
+// * зарегистрирован ли email
+func (h *handler) resendVerification(w http.ResponseWriter, r *http.Request) {
+	var req ResendVerificationReq
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "error decoding request body")
+		return
+	}
+
+	if !validateRequest(w, r, req, resendVerificationRules) {
+		return
+	}
+
+	//* лимит считается и для незарегистрированных email
+	if ok, retryAfter := h.resendLimiter.Allow(strings.ToLower(req.Email)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeProblem(w, r, http.StatusTooManyRequests, ErrCodeTooManyRequests, "too many verification emails for this email, try again later")
+		return
+	}
+
+	ctx, err := h.serviceContext(r.Context())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return
+	}
+
+	u, err := h.client.GetUser(ctx, &pb.UserReq{Email: req.Email})
+
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case err != nil:
+		writeGRPCError(w, r, err, "error getting user")
+		return
+	case u.GetEmailVerifiedAt() == nil:
+		go h.sendVerificationEmail(context.WithoutCancel(r.Context()), u)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// * для режима VerificationOrder: заказ только с подтвержденным email
+func (h *handler) checkEmailVerifiedForOrder(w http.ResponseWriter, r *http.Request, email string) bool {
+	if h.verification.Mode != VerificationOrder {
+		return true
+	}
+
+	ctx, err := h.serviceContext(r.Context())
+
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, "error creating token")
+		return false
+	}
+
+	u, err := h.client.GetUser(ctx, &pb.UserReq{Email: email})
+
+	if err != nil {
+		writeGRPCError(w, r, err, "error getting user")
+		return false
+	}
+
+	if u.GetEmailVerifiedAt() == nil {
+		writeProblem(w, r, http.StatusForbidden, ErrCodeEmailNotVerified, "email must be verified before ordering")
+		return false
+	}
+
+	return true
+}
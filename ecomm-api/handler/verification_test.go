@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/mailer"
+	"davidHwang/ecomm/token"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (f *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func (f *fakeMailer) messages() []mailer.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]mailer.Message(nil), f.sent...)
+}
+
+// * ссылка из письма
+func mailLink(t *testing.T, msg mailer.Message) *url.URL {
+	link, err := url.Parse(regexp.MustCompile(`https://\S+`).FindString(msg.Body))
+	require.NoError(t, err)
+
+	return link
+}
+
+func TestParseEmailVerificationMode(t *testing.T) {
+	for s, want := range map[string]EmailVerificationMode{"": VerificationOff, "off": VerificationOff, "login": VerificationLogin, "order": VerificationOrder} {
+		mode, err := ParseEmailVerificationMode(s)
+		require.NoError(t, err)
+		require.Equal(t, want, mode)
+	}
+
+	_, err := ParseEmailVerificationMode("always")
+	require.Error(t, err)
+}
+
+func TestVerificationEmail(t *testing.T) {
+	actionTokens, err := token.NewActionTokenMaker("01234567890123456789012345678901")
+	require.NoError(t, err)
+
+	mail := &fakeMailer{}
+	h := NewHandler(Config{TokenMaker: token.NewJWTMaker("01234567890123456789012345678901"), ActionTokens: actionTokens, Mailer: mail, SessionCacheTTL: DefaultSessionCacheTTL,
+		Verification: EmailVerification{Mode: VerificationLogin, URL: "https://shop.example.com/verify?lang=en"}})
+
+	h.sendVerificationEmail(context.Background(), &pb.UserRes{Id: 7, Name: "user", Email: "user@example.com"})
+	sent := mail.messages()
+	require.Len(t, sent, 1)
+	require.Equal(t, "user@example.com", sent[0].To)
+
+	//* ссылка сохраняет параметры страницы и содержит токен подтверждения
+	link := mailLink(t, sent[0])
+	require.Equal(t, "en", link.Query().Get("lang"))
+
+	claims, err := actionTokens.VerifyToken(token.PurposeVerifyEmail, link.Query().Get("token"))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), claims.UserID)
+	require.Equal(t, "user@example.com", claims.Email)
+
+	//* токен доступа не подходит как токен подтверждения
+	accessTok, _, err := h.TokenMaker.CreateToken(7, "user@example.com", false, nil, "session", DefaultSessionCacheTTL)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/verify", strings.NewReader(`{"token":"`+accessTok+`"}`))
+	w := httptest.NewRecorder()
+	h.verifyEmail(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), `"code":"`+ErrCodeInvalidToken+`"`)
+}
+
+func TestResendVerificationLimit(t *testing.T) {
+	actionTokens, err := token.NewActionTokenMaker("01234567890123456789012345678901")
+	require.NoError(t, err)
+
+	client := &fakeMFAClient{user: &pb.UserRes{Id: 7, Name: "user", Email: "user@example.com"}}
+	mail := &fakeMailer{}
+	h := NewHandler(Config{Client: client, TokenMaker: token.NewJWTMaker("01234567890123456789012345678901"), ActionTokens: actionTokens, Mailer: mail, SessionCacheTTL: DefaultSessionCacheTTL,
+		Verification: EmailVerification{Mode: VerificationLogin, URL: "https://shop.example.com/verify", ResendLimit: 1, ResendWindow: time.Hour}})
+
+	resend := func(email string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.resendVerification(w, httptest.NewRequest(http.MethodPost, "/users/verify/resend", strings.NewReader(`{"email":"`+email+`"}`)))
+		return w
+	}
+
+	require.Equal(t, http.StatusAccepted, resend("user@example.com").Code)
+	require.Eventually(t, func() bool { return len(mail.messages()) == 1 }, time.Second, 10*time.Millisecond)
+
+	//* лимит по email без учета регистра, в том числе для незарегистрированных адресов
+	w := resend("USER@example.com")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusAccepted, resend("other@example.com").Code)
+	require.Equal(t, http.StatusTooManyRequests, resend("other@example.com").Code)
+	require.Len(t, mail.messages(), 1)
+}
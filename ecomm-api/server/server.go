@@ -6,10 +6,10 @@ import (
 )
 
 type Server struct {
-	storer *storer.MySQLStorer
+	storer storer.Storer
 }
 
-func NewServer(storer *storer.MySQLStorer) *Server {
+func NewServer(storer storer.Storer) *Server {
 	return &Server{
 		storer: storer,
 	}
@@ -0,0 +1,30 @@
+package storer
+
+import "context"
+
+// * Storer - общий интерфейс хранилища для REST сервера
+type Storer interface {
+	CreateProduct(ctx context.Context, p *Product) (*Product, error)
+	GetProduct(ctx context.Context, id int64) (*Product, error)
+	ListProducts(ctx context.Context) ([]Product, error)
+	UpdateProduct(ctx context.Context, p *Product) (*Product, error)
+	DeleteProduct(ctx context.Context, id int64) error
+
+	CreateOrder(ctx context.Context, o *Order) (*Order, error)
+	GetOrder(ctx context.Context, userID int64) (*Order, error)
+	ListOrders(ctx context.Context) ([]Order, error)
+	DeleteOrder(ctx context.Context, id int64) error
+
+	CreateUser(ctx context.Context, u *User) (*User, error)
+	GetUser(ctx context.Context, email string) (*User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	UpdateUser(ctx context.Context, u *User) (*User, error)
+	DeleteUser(ctx context.Context, id int64) error
+
+	CreateSession(ctx context.Context, s *Session) (*Session, error)
+	GetSession(ctx context.Context, id string) (*Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	DeleteSession(ctx context.Context, id string) error
+}
+
+var _ Storer = (*MySQLStorer)(nil)
@@ -292,7 +292,7 @@ func (ms *MySQLStorer) ListUsers(ctx context.Context) ([]User, error) {
 }
 
 func (ms *MySQLStorer) UpdateUser(ctx context.Context, u *User) (*User, error) {
-	_, err := ms.db.NamedExecContext(ctx, `UPDATE users SET name=:name, email=:email, password=:password, is_admin=:is_admin, updated_at=:updated_at WHERE id=:id`, u)
+	_, err := ms.db.NamedExecContext(ctx, `UPDATE users SET name=:name, email=:email, password=:password, is_admin=:is_admin, updated_at=:updated_at, email_verified_at=:email_verified_at WHERE id=:id`, u)
 
 	if err != nil {
 		return nil, fmt.Errorf("error updating user: %w", err)
@@ -313,7 +313,7 @@ func (ms *MySQLStorer) DeleteUser(ctx context.Context, id int64) error {
 //* SESSIONS
 
 func (ms *MySQLStorer) CreateSession(ctx context.Context, s *Session) (*Session, error) {
-	_, err := ms.db.NamedExecContext(ctx, "INSERT INTO sessions (id, user_email, refresh_token, is_revoked) VALUES (:id, :user_email, :refresh_token, :is_revoked)", s)
+	_, err := ms.db.NamedExecContext(ctx, "INSERT INTO sessions (id, user_email, refresh_token, is_revoked, expires_at) VALUES (:id, :user_email, :refresh_token, :is_revoked, :expires_at)", s)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating session: %w", err)
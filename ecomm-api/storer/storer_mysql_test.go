@@ -379,12 +379,12 @@ func TestCreateOrder(t *testing.T) {
 			name: "success",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id) VALUES (?, ?, ?, ?, ?)`).
 					WillReturnResult(sqlmock.NewResult(1, 1))
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
@@ -401,7 +401,7 @@ func TestCreateOrder(t *testing.T) {
 			name: "failed creating order",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order"))
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id) VALUES (?, ?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order"))
 				mock.ExpectRollback()
 
 				_, err := st.CreateOrder(context.Background(), o)
@@ -417,9 +417,9 @@ func TestCreateOrder(t *testing.T) {
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id) VALUES (?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order item"))
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order item"))
 
 				mock.ExpectRollback()
 
@@ -479,7 +479,7 @@ func TestGetOrder(t *testing.T) {
 
 				orows := sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price"}).AddRow(1, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice)
 
-				mock.ExpectQuery(`SELECT * FROM orders WHERE id=?`).WithArgs(1).WillReturnRows(orows)
+				mock.ExpectQuery(`SELECT * FROM orders WHERE user_id=?`).WithArgs(1).WillReturnRows(orows)
 
 				oirows := sqlmock.NewRows([]string{"id", "name", "quantity", "image", "price", "product_id"}).AddRow(1, ois[0].Name, ois[0].Quantity, ois[0].Image, ois[0].Price, ois[0].ProductID).AddRow(2, ois[1].Name, ois[1].Quantity, ois[1].Image, ois[1].Price, ois[1].ProductID)
 
@@ -506,7 +506,7 @@ func TestGetOrder(t *testing.T) {
 			name: "failed getting order",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 
-				mock.ExpectQuery(`SELECT * FROM orders WHERE id=?`).WithArgs(1).WillReturnError(fmt.Errorf("error getting order"))
+				mock.ExpectQuery(`SELECT * FROM orders WHERE user_id=?`).WithArgs(1).WillReturnError(fmt.Errorf("error getting order"))
 
 				_, err := st.GetOrder(context.Background(), 1)
 				require.Error(t, err)
@@ -519,7 +519,7 @@ func TestGetOrder(t *testing.T) {
 		{
 			name: "failed getting order items",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT * FROM orders WHERE id=?`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price"}).AddRow(1, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice))
+				mock.ExpectQuery(`SELECT * FROM orders WHERE user_id=?`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price"}).AddRow(1, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice))
 
 				mock.ExpectQuery(`SELECT * FROM order_items WHERE order_id=?`).WithArgs(1).WillReturnError(fmt.Errorf("error getting order items"))
 
@@ -535,11 +535,11 @@ func TestGetOrder(t *testing.T) {
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 
 				mock.ExpectBegin()
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id) VALUES (?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectCommit().WillReturnError(fmt.Errorf("error commiting transaction"))
 
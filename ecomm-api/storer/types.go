@@ -23,6 +23,7 @@ type Order struct {
 	ShippingPrice float64    `db:"shipping_price"`
 	TotalPrice    float64    `db:"total_price"`
 	UserID        int64      `db:"user_id"`
+	Status        string     `db:"status"`
 	CreatedAt     time.Time  `db:"created_at"`
 	UpdatedAt     *time.Time `db:"updated_at"`
 	Items         []OrderItem
@@ -47,6 +48,8 @@ type User struct {
 	IsAdmin   bool       `db:"is_admin"`
 	CreatedAt time.Time  `db:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at"`
+	//* когда пользователь подтвердил email, nil - не подтвержден
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
 }
 
 //* SESSIONS
@@ -58,4 +61,9 @@ type Session struct {
 	IsRevoked    bool       `db:"is_revoked"`
 	CreatedAt    time.Time  `db:"created_at"`
 	ExpiresAt    *time.Time `db:"expires_at"`
+	FamilyID     string     `db:"family_id"`
+	ParentID     string     `db:"parent_id"`
+	RotatedAt    *time.Time `db:"rotated_at"`
+	UserAgent    string     `db:"user_agent"`
+	IPAddress    string     `db:"ip_address"`
 }
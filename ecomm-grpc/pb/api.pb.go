@@ -9,6 +9,7 @@ package pb
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -22,6 +23,119 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ProductSortField int32
+
+const (
+	ProductSortField_SORT_BY_ID         ProductSortField = 0
+	ProductSortField_SORT_BY_PRICE      ProductSortField = 1
+	ProductSortField_SORT_BY_RATING     ProductSortField = 2
+	ProductSortField_SORT_BY_CREATED_AT ProductSortField = 3
+)
+
+// Enum value maps for ProductSortField.
+var (
+	ProductSortField_name = map[int32]string{
+		0: "SORT_BY_ID",
+		1: "SORT_BY_PRICE",
+		2: "SORT_BY_RATING",
+		3: "SORT_BY_CREATED_AT",
+	}
+	ProductSortField_value = map[string]int32{
+		"SORT_BY_ID":         0,
+		"SORT_BY_PRICE":      1,
+		"SORT_BY_RATING":     2,
+		"SORT_BY_CREATED_AT": 3,
+	}
+)
+
+func (x ProductSortField) Enum() *ProductSortField {
+	p := new(ProductSortField)
+	*p = x
+	return p
+}
+
+func (x ProductSortField) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProductSortField) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_proto_enumTypes[0].Descriptor()
+}
+
+func (ProductSortField) Type() protoreflect.EnumType {
+	return &file_api_proto_enumTypes[0]
+}
+
+func (x ProductSortField) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProductSortField.Descriptor instead.
+func (ProductSortField) EnumDescriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{0}
+}
+
+type OrderStatus int32
+
+const (
+	OrderStatus_UNSPECIFIED OrderStatus = 0
+	OrderStatus_PENDING     OrderStatus = 1
+	OrderStatus_PAID        OrderStatus = 2
+	OrderStatus_SHIPPED     OrderStatus = 3
+	OrderStatus_DELIVERED   OrderStatus = 4
+	OrderStatus_CANCELLED   OrderStatus = 5
+	OrderStatus_REFUNDED    OrderStatus = 6
+)
+
+// Enum value maps for OrderStatus.
+var (
+	OrderStatus_name = map[int32]string{
+		0: "UNSPECIFIED",
+		1: "PENDING",
+		2: "PAID",
+		3: "SHIPPED",
+		4: "DELIVERED",
+		5: "CANCELLED",
+		6: "REFUNDED",
+	}
+	OrderStatus_value = map[string]int32{
+		"UNSPECIFIED": 0,
+		"PENDING":     1,
+		"PAID":        2,
+		"SHIPPED":     3,
+		"DELIVERED":   4,
+		"CANCELLED":   5,
+		"REFUNDED":    6,
+	}
+)
+
+func (x OrderStatus) Enum() *OrderStatus {
+	p := new(OrderStatus)
+	*p = x
+	return p
+}
+
+func (x OrderStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_proto_enumTypes[1].Descriptor()
+}
+
+func (OrderStatus) Type() protoreflect.EnumType {
+	return &file_api_proto_enumTypes[1]
+}
+
+func (x OrderStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderStatus.Descriptor instead.
+func (OrderStatus) EnumDescriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{1}
+}
+
 type ProductReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -254,16 +368,117 @@ func (x *ProductRes) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+type ListProductsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Category      string                 `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	MinPrice      float32                `protobuf:"fixed32,4,opt,name=min_price,json=minPrice,proto3" json:"min_price,omitempty"`
+	MaxPrice      float32                `protobuf:"fixed32,5,opt,name=max_price,json=maxPrice,proto3" json:"max_price,omitempty"`
+	InStockOnly   bool                   `protobuf:"varint,6,opt,name=in_stock_only,json=inStockOnly,proto3" json:"in_stock_only,omitempty"`
+	SortBy        ProductSortField       `protobuf:"varint,7,opt,name=sort_by,json=sortBy,proto3,enum=pb.ProductSortField" json:"sort_by,omitempty"`
+	SortDesc      bool                   `protobuf:"varint,8,opt,name=sort_desc,json=sortDesc,proto3" json:"sort_desc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProductsReq) Reset() {
+	*x = ListProductsReq{}
+	mi := &file_api_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProductsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProductsReq) ProtoMessage() {}
+
+func (x *ListProductsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProductsReq.ProtoReflect.Descriptor instead.
+func (*ListProductsReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListProductsReq) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListProductsReq) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListProductsReq) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ListProductsReq) GetMinPrice() float32 {
+	if x != nil {
+		return x.MinPrice
+	}
+	return 0
+}
+
+func (x *ListProductsReq) GetMaxPrice() float32 {
+	if x != nil {
+		return x.MaxPrice
+	}
+	return 0
+}
+
+func (x *ListProductsReq) GetInStockOnly() bool {
+	if x != nil {
+		return x.InStockOnly
+	}
+	return false
+}
+
+func (x *ListProductsReq) GetSortBy() ProductSortField {
+	if x != nil {
+		return x.SortBy
+	}
+	return ProductSortField_SORT_BY_ID
+}
+
+func (x *ListProductsReq) GetSortDesc() bool {
+	if x != nil {
+		return x.SortDesc
+	}
+	return false
+}
+
 type ListProductRes struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Products      []*ProductRes          `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListProductRes) Reset() {
 	*x = ListProductRes{}
-	mi := &file_api_proto_msgTypes[2]
+	mi := &file_api_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -275,7 +490,7 @@ func (x *ListProductRes) String() string {
 func (*ListProductRes) ProtoMessage() {}
 
 func (x *ListProductRes) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[2]
+	mi := &file_api_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -288,7 +503,7 @@ func (x *ListProductRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListProductRes.ProtoReflect.Descriptor instead.
 func (*ListProductRes) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{2}
+	return file_api_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ListProductRes) GetProducts() []*ProductRes {
@@ -298,6 +513,169 @@ func (x *ListProductRes) GetProducts() []*ProductRes {
 	return nil
 }
 
+func (x *ListProductRes) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type SearchProductsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchProductsReq) Reset() {
+	*x = SearchProductsReq{}
+	mi := &file_api_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchProductsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchProductsReq) ProtoMessage() {}
+
+func (x *SearchProductsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchProductsReq.ProtoReflect.Descriptor instead.
+func (*SearchProductsReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SearchProductsReq) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchProductsReq) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ProductSearchHit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Product       *ProductRes            `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	Relevance     float64                `protobuf:"fixed64,2,opt,name=relevance,proto3" json:"relevance,omitempty"`
+	Snippet       string                 `protobuf:"bytes,3,opt,name=snippet,proto3" json:"snippet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductSearchHit) Reset() {
+	*x = ProductSearchHit{}
+	mi := &file_api_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductSearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductSearchHit) ProtoMessage() {}
+
+func (x *ProductSearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductSearchHit.ProtoReflect.Descriptor instead.
+func (*ProductSearchHit) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ProductSearchHit) GetProduct() *ProductRes {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *ProductSearchHit) GetRelevance() float64 {
+	if x != nil {
+		return x.Relevance
+	}
+	return 0
+}
+
+func (x *ProductSearchHit) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+type SearchProductsRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hits          []*ProductSearchHit    `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchProductsRes) Reset() {
+	*x = SearchProductsRes{}
+	mi := &file_api_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchProductsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchProductsRes) ProtoMessage() {}
+
+func (x *SearchProductsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchProductsRes.ProtoReflect.Descriptor instead.
+func (*SearchProductsRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchProductsRes) GetHits() []*ProductSearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
 type OrderItem struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -311,7 +689,7 @@ type OrderItem struct {
 
 func (x *OrderItem) Reset() {
 	*x = OrderItem{}
-	mi := &file_api_proto_msgTypes[3]
+	mi := &file_api_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -323,7 +701,7 @@ func (x *OrderItem) String() string {
 func (*OrderItem) ProtoMessage() {}
 
 func (x *OrderItem) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[3]
+	mi := &file_api_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -336,7 +714,7 @@ func (x *OrderItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderItem.ProtoReflect.Descriptor instead.
 func (*OrderItem) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{3}
+	return file_api_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *OrderItem) GetName() string {
@@ -389,7 +767,7 @@ type OrderReq struct {
 
 func (x *OrderReq) Reset() {
 	*x = OrderReq{}
-	mi := &file_api_proto_msgTypes[4]
+	mi := &file_api_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -401,7 +779,7 @@ func (x *OrderReq) String() string {
 func (*OrderReq) ProtoMessage() {}
 
 func (x *OrderReq) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[4]
+	mi := &file_api_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -414,7 +792,7 @@ func (x *OrderReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderReq.ProtoReflect.Descriptor instead.
 func (*OrderReq) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{4}
+	return file_api_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *OrderReq) GetId() int64 {
@@ -477,13 +855,15 @@ type OrderRes struct {
 	UserId        int64                  `protobuf:"varint,7,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Status        OrderStatus            `protobuf:"varint,10,opt,name=status,proto3,enum=pb.OrderStatus" json:"status,omitempty"`
+	Breakdown     *PriceBreakdown        `protobuf:"bytes,11,opt,name=breakdown,proto3" json:"breakdown,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *OrderRes) Reset() {
 	*x = OrderRes{}
-	mi := &file_api_proto_msgTypes[5]
+	mi := &file_api_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -495,7 +875,7 @@ func (x *OrderRes) String() string {
 func (*OrderRes) ProtoMessage() {}
 
 func (x *OrderRes) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[5]
+	mi := &file_api_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -508,7 +888,7 @@ func (x *OrderRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderRes.ProtoReflect.Descriptor instead.
 func (*OrderRes) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{5}
+	return file_api_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *OrderRes) GetId() int64 {
@@ -574,28 +954,732 @@ func (x *OrderRes) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
-type ListOrderRes struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Orders        []*OrderRes            `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+func (x *OrderRes) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_UNSPECIFIED
+}
+
+func (x *OrderRes) GetBreakdown() *PriceBreakdown {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+type PriceBreakdown struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ItemsPrice    float32                `protobuf:"fixed32,1,opt,name=items_price,json=itemsPrice,proto3" json:"items_price,omitempty"`
+	TaxPrice      float32                `protobuf:"fixed32,2,opt,name=tax_price,json=taxPrice,proto3" json:"tax_price,omitempty"`
+	ShippingPrice float32                `protobuf:"fixed32,3,opt,name=shipping_price,json=shippingPrice,proto3" json:"shipping_price,omitempty"`
+	TotalPrice    float32                `protobuf:"fixed32,4,opt,name=total_price,json=totalPrice,proto3" json:"total_price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceBreakdown) Reset() {
+	*x = PriceBreakdown{}
+	mi := &file_api_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceBreakdown) ProtoMessage() {}
+
+func (x *PriceBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceBreakdown.ProtoReflect.Descriptor instead.
+func (*PriceBreakdown) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PriceBreakdown) GetItemsPrice() float32 {
+	if x != nil {
+		return x.ItemsPrice
+	}
+	return 0
+}
+
+func (x *PriceBreakdown) GetTaxPrice() float32 {
+	if x != nil {
+		return x.TaxPrice
+	}
+	return 0
+}
+
+func (x *PriceBreakdown) GetShippingPrice() float32 {
+	if x != nil {
+		return x.ShippingPrice
+	}
+	return 0
+}
+
+func (x *PriceBreakdown) GetTotalPrice() float32 {
+	if x != nil {
+		return x.TotalPrice
+	}
+	return 0
+}
+
+type UpdateOrderStatusReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        OrderStatus            `protobuf:"varint,2,opt,name=status,proto3,enum=pb.OrderStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOrderStatusReq) Reset() {
+	*x = UpdateOrderStatusReq{}
+	mi := &file_api_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOrderStatusReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOrderStatusReq) ProtoMessage() {}
+
+func (x *UpdateOrderStatusReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOrderStatusReq.ProtoReflect.Descriptor instead.
+func (*UpdateOrderStatusReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateOrderStatusReq) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateOrderStatusReq) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_UNSPECIFIED
+}
+
+type ListOrderRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*OrderRes            `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrderRes) Reset() {
+	*x = ListOrderRes{}
+	mi := &file_api_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrderRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrderRes) ProtoMessage() {}
+
+func (x *ListOrderRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrderRes.ProtoReflect.Descriptor instead.
+func (*ListOrderRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListOrderRes) GetOrders() []*OrderRes {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+type UserReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	IsAdmin       bool                   `protobuf:"varint,5,opt,name=is_admin,json=isAdmin,proto3" json:"is_admin,omitempty"`
+	Roles         []string               `protobuf:"bytes,6,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserReq) Reset() {
+	*x = UserReq{}
+	mi := &file_api_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserReq) ProtoMessage() {}
+
+func (x *UserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserReq.ProtoReflect.Descriptor instead.
+func (*UserReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UserReq) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UserReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UserReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserReq) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *UserReq) GetIsAdmin() bool {
+	if x != nil {
+		return x.IsAdmin
+	}
+	return false
+}
+
+func (x *UserReq) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+type UserRes struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email           string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	IsAdmin         bool                   `protobuf:"varint,5,opt,name=is_admin,json=isAdmin,proto3" json:"is_admin,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Roles           []string               `protobuf:"bytes,7,rep,name=roles,proto3" json:"roles,omitempty"`
+	Permissions     []string               `protobuf:"bytes,8,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	EmailVerifiedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=email_verified_at,json=emailVerifiedAt,proto3" json:"email_verified_at,omitempty"`
+	MfaEnabled      bool                   `protobuf:"varint,10,opt,name=mfa_enabled,json=mfaEnabled,proto3" json:"mfa_enabled,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UserRes) Reset() {
+	*x = UserRes{}
+	mi := &file_api_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRes) ProtoMessage() {}
+
+func (x *UserRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRes.ProtoReflect.Descriptor instead.
+func (*UserRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UserRes) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UserRes) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UserRes) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserRes) GetIsAdmin() bool {
+	if x != nil {
+		return x.IsAdmin
+	}
+	return false
+}
+
+func (x *UserRes) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *UserRes) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *UserRes) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *UserRes) GetEmailVerifiedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EmailVerifiedAt
+	}
+	return nil
+}
+
+func (x *UserRes) GetMfaEnabled() bool {
+	if x != nil {
+		return x.MfaEnabled
+	}
+	return false
+}
+
+type PasswordResetReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	TokenHash     string                 `protobuf:"bytes,2,opt,name=token_hash,json=tokenHash,proto3" json:"token_hash,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PasswordResetReq) Reset() {
+	*x = PasswordResetReq{}
+	mi := &file_api_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PasswordResetReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PasswordResetReq) ProtoMessage() {}
+
+func (x *PasswordResetReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PasswordResetReq.ProtoReflect.Descriptor instead.
+func (*PasswordResetReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PasswordResetReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *PasswordResetReq) GetTokenHash() string {
+	if x != nil {
+		return x.TokenHash
+	}
+	return ""
+}
+
+func (x *PasswordResetReq) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *PasswordResetReq) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type PasswordChangeReq struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Email           string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	CurrentPassword string                 `protobuf:"bytes,2,opt,name=current_password,json=currentPassword,proto3" json:"current_password,omitempty"`
+	NewPassword     string                 `protobuf:"bytes,3,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	KeepSessionId   string                 `protobuf:"bytes,4,opt,name=keep_session_id,json=keepSessionId,proto3" json:"keep_session_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PasswordChangeReq) Reset() {
+	*x = PasswordChangeReq{}
+	mi := &file_api_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PasswordChangeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PasswordChangeReq) ProtoMessage() {}
+
+func (x *PasswordChangeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PasswordChangeReq.ProtoReflect.Descriptor instead.
+func (*PasswordChangeReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PasswordChangeReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *PasswordChangeReq) GetCurrentPassword() string {
+	if x != nil {
+		return x.CurrentPassword
+	}
+	return ""
+}
+
+func (x *PasswordChangeReq) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+func (x *PasswordChangeReq) GetKeepSessionId() string {
+	if x != nil {
+		return x.KeepSessionId
+	}
+	return ""
+}
+
+type TOTPReq struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	UserId             int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Secret             string                 `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	Code               string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	RecoveryCodeHashes []string               `protobuf:"bytes,4,rep,name=recovery_code_hashes,json=recoveryCodeHashes,proto3" json:"recovery_code_hashes,omitempty"`
+	RecoveryCodeHash   string                 `protobuf:"bytes,5,opt,name=recovery_code_hash,json=recoveryCodeHash,proto3" json:"recovery_code_hash,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *TOTPReq) Reset() {
+	*x = TOTPReq{}
+	mi := &file_api_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TOTPReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TOTPReq) ProtoMessage() {}
+
+func (x *TOTPReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TOTPReq.ProtoReflect.Descriptor instead.
+func (*TOTPReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TOTPReq) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *TOTPReq) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *TOTPReq) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *TOTPReq) GetRecoveryCodeHashes() []string {
+	if x != nil {
+		return x.RecoveryCodeHashes
+	}
+	return nil
+}
+
+func (x *TOTPReq) GetRecoveryCodeHash() string {
+	if x != nil {
+		return x.RecoveryCodeHash
+	}
+	return ""
+}
+
+type TOTPRes struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Enabled           bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	RecoveryCodesLeft int64                  `protobuf:"varint,2,opt,name=recovery_codes_left,json=recoveryCodesLeft,proto3" json:"recovery_codes_left,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *TOTPRes) Reset() {
+	*x = TOTPRes{}
+	mi := &file_api_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TOTPRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TOTPRes) ProtoMessage() {}
+
+func (x *TOTPRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TOTPRes.ProtoReflect.Descriptor instead.
+func (*TOTPRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TOTPRes) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *TOTPRes) GetRecoveryCodesLeft() int64 {
+	if x != nil {
+		return x.RecoveryCodesLeft
+	}
+	return 0
+}
+
+type LoginAttemptReq struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Key               string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Window            *durationpb.Duration   `protobuf:"bytes,2,opt,name=window,proto3" json:"window,omitempty"`
+	FailureAt         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=failure_at,json=failureAt,proto3" json:"failure_at,omitempty"`
+	PreviousFailureAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=previous_failure_at,json=previousFailureAt,proto3" json:"previous_failure_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *LoginAttemptReq) Reset() {
+	*x = LoginAttemptReq{}
+	mi := &file_api_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginAttemptReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginAttemptReq) ProtoMessage() {}
+
+func (x *LoginAttemptReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginAttemptReq.ProtoReflect.Descriptor instead.
+func (*LoginAttemptReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *LoginAttemptReq) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *LoginAttemptReq) GetWindow() *durationpb.Duration {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+func (x *LoginAttemptReq) GetFailureAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FailureAt
+	}
+	return nil
+}
+
+func (x *LoginAttemptReq) GetPreviousFailureAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PreviousFailureAt
+	}
+	return nil
+}
+
+type LoginAttemptRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Failures      int64                  `protobuf:"varint,2,opt,name=failures,proto3" json:"failures,omitempty"`
+	LastFailureAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_failure_at,json=lastFailureAt,proto3" json:"last_failure_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListOrderRes) Reset() {
-	*x = ListOrderRes{}
-	mi := &file_api_proto_msgTypes[6]
+func (x *LoginAttemptRes) Reset() {
+	*x = LoginAttemptRes{}
+	mi := &file_api_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListOrderRes) String() string {
+func (x *LoginAttemptRes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListOrderRes) ProtoMessage() {}
+func (*LoginAttemptRes) ProtoMessage() {}
 
-func (x *ListOrderRes) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[6]
+func (x *LoginAttemptRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -606,44 +1690,55 @@ func (x *ListOrderRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListOrderRes.ProtoReflect.Descriptor instead.
-func (*ListOrderRes) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use LoginAttemptRes.ProtoReflect.Descriptor instead.
+func (*LoginAttemptRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *ListOrderRes) GetOrders() []*OrderRes {
+func (x *LoginAttemptRes) GetKey() string {
 	if x != nil {
-		return x.Orders
+		return x.Key
+	}
+	return ""
+}
+
+func (x *LoginAttemptRes) GetFailures() int64 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *LoginAttemptRes) GetLastFailureAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastFailureAt
 	}
 	return nil
 }
 
-type UserReq struct {
+type RoleReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
-	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
-	IsAdmin       bool                   `protobuf:"varint,5,opt,name=is_admin,json=isAdmin,proto3" json:"is_admin,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UserReq) Reset() {
-	*x = UserReq{}
-	mi := &file_api_proto_msgTypes[7]
+func (x *RoleReq) Reset() {
+	*x = RoleReq{}
+	mi := &file_api_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UserReq) String() string {
+func (x *RoleReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserReq) ProtoMessage() {}
+func (*RoleReq) ProtoMessage() {}
 
-func (x *UserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[7]
+func (x *RoleReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -654,73 +1749,49 @@ func (x *UserReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserReq.ProtoReflect.Descriptor instead.
-func (*UserReq) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use RoleReq.ProtoReflect.Descriptor instead.
+func (*RoleReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *UserReq) GetId() int64 {
+func (x *RoleReq) GetUserId() int64 {
 	if x != nil {
-		return x.Id
+		return x.UserId
 	}
 	return 0
 }
 
-func (x *UserReq) GetName() string {
+func (x *RoleReq) GetName() string {
 	if x != nil {
 		return x.Name
 	}
 	return ""
 }
 
-func (x *UserReq) GetEmail() string {
-	if x != nil {
-		return x.Email
-	}
-	return ""
-}
-
-func (x *UserReq) GetPassword() string {
-	if x != nil {
-		return x.Password
-	}
-	return ""
-}
-
-func (x *UserReq) GetIsAdmin() bool {
-	if x != nil {
-		return x.IsAdmin
-	}
-	return false
-}
-
-type UserRes struct {
+type RoleRes struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
-	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
-	IsAdmin       bool                   `protobuf:"varint,5,opt,name=is_admin,json=isAdmin,proto3" json:"is_admin,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Permissions   []string               `protobuf:"bytes,3,rep,name=permissions,proto3" json:"permissions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UserRes) Reset() {
-	*x = UserRes{}
-	mi := &file_api_proto_msgTypes[8]
+func (x *RoleRes) Reset() {
+	*x = RoleRes{}
+	mi := &file_api_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UserRes) String() string {
+func (x *RoleRes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserRes) ProtoMessage() {}
+func (*RoleRes) ProtoMessage() {}
 
-func (x *UserRes) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[8]
+func (x *RoleRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -731,49 +1802,72 @@ func (x *UserRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserRes.ProtoReflect.Descriptor instead.
-func (*UserRes) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{8}
-}
-
-func (x *UserRes) GetId() int64 {
-	if x != nil {
-		return x.Id
-	}
-	return 0
+// Deprecated: Use RoleRes.ProtoReflect.Descriptor instead.
+func (*RoleRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *UserRes) GetName() string {
+func (x *RoleRes) GetName() string {
 	if x != nil {
 		return x.Name
 	}
 	return ""
 }
 
-func (x *UserRes) GetEmail() string {
+func (x *RoleRes) GetDescription() string {
 	if x != nil {
-		return x.Email
+		return x.Description
 	}
 	return ""
 }
 
-func (x *UserRes) GetPassword() string {
+func (x *RoleRes) GetPermissions() []string {
 	if x != nil {
-		return x.Password
+		return x.Permissions
 	}
-	return ""
+	return nil
 }
 
-func (x *UserRes) GetIsAdmin() bool {
+type ListRoleRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Roles         []*RoleRes             `protobuf:"bytes,1,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoleRes) Reset() {
+	*x = ListRoleRes{}
+	mi := &file_api_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoleRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoleRes) ProtoMessage() {}
+
+func (x *ListRoleRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[23]
 	if x != nil {
-		return x.IsAdmin
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *UserRes) GetCreatedAt() *timestamppb.Timestamp {
+// Deprecated: Use ListRoleRes.ProtoReflect.Descriptor instead.
+func (*ListRoleRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListRoleRes) GetRoles() []*RoleRes {
 	if x != nil {
-		return x.CreatedAt
+		return x.Roles
 	}
 	return nil
 }
@@ -787,7 +1881,7 @@ type ListUserRes struct {
 
 func (x *ListUserRes) Reset() {
 	*x = ListUserRes{}
-	mi := &file_api_proto_msgTypes[9]
+	mi := &file_api_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -799,7 +1893,7 @@ func (x *ListUserRes) String() string {
 func (*ListUserRes) ProtoMessage() {}
 
 func (x *ListUserRes) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[9]
+	mi := &file_api_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -812,7 +1906,7 @@ func (x *ListUserRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListUserRes.ProtoReflect.Descriptor instead.
 func (*ListUserRes) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{9}
+	return file_api_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *ListUserRes) GetUsers() []*UserRes {
@@ -829,13 +1923,15 @@ type SessionReq struct {
 	RefreshToken  string                 `protobuf:"bytes,3,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
 	IsRevoked     bool                   `protobuf:"varint,4,opt,name=is_revoked,json=isRevoked,proto3" json:"is_revoked,omitempty"`
 	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,6,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	IpAddress     string                 `protobuf:"bytes,7,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SessionReq) Reset() {
 	*x = SessionReq{}
-	mi := &file_api_proto_msgTypes[10]
+	mi := &file_api_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -847,7 +1943,7 @@ func (x *SessionReq) String() string {
 func (*SessionReq) ProtoMessage() {}
 
 func (x *SessionReq) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[10]
+	mi := &file_api_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -860,7 +1956,7 @@ func (x *SessionReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionReq.ProtoReflect.Descriptor instead.
 func (*SessionReq) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{10}
+	return file_api_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *SessionReq) GetId() string {
@@ -898,6 +1994,20 @@ func (x *SessionReq) GetExpiresAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *SessionReq) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *SessionReq) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
 type SessionRes struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -905,13 +2015,19 @@ type SessionRes struct {
 	RefreshToken  string                 `protobuf:"bytes,3,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
 	IsRevoked     bool                   `protobuf:"varint,4,opt,name=is_revoked,json=isRevoked,proto3" json:"is_revoked,omitempty"`
 	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	FamilyId      string                 `protobuf:"bytes,6,opt,name=family_id,json=familyId,proto3" json:"family_id,omitempty"`
+	ParentId      string                 `protobuf:"bytes,7,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	RotatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=rotated_at,json=rotatedAt,proto3" json:"rotated_at,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,9,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	IpAddress     string                 `protobuf:"bytes,10,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SessionRes) Reset() {
 	*x = SessionRes{}
-	mi := &file_api_proto_msgTypes[11]
+	mi := &file_api_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -923,7 +2039,7 @@ func (x *SessionRes) String() string {
 func (*SessionRes) ProtoMessage() {}
 
 func (x *SessionRes) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[11]
+	mi := &file_api_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -936,7 +2052,7 @@ func (x *SessionRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionRes.ProtoReflect.Descriptor instead.
 func (*SessionRes) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{11}
+	return file_api_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *SessionRes) GetId() string {
@@ -974,11 +2090,149 @@ func (x *SessionRes) GetExpiresAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *SessionRes) GetFamilyId() string {
+	if x != nil {
+		return x.FamilyId
+	}
+	return ""
+}
+
+func (x *SessionRes) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *SessionRes) GetRotatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RotatedAt
+	}
+	return nil
+}
+
+func (x *SessionRes) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *SessionRes) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *SessionRes) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListSessionRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*SessionRes          `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionRes) Reset() {
+	*x = ListSessionRes{}
+	mi := &file_api_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionRes) ProtoMessage() {}
+
+func (x *ListSessionRes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionRes.ProtoReflect.Descriptor instead.
+func (*ListSessionRes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListSessionRes) GetSessions() []*SessionRes {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type RotateSessionReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Session       *SessionReq            `protobuf:"bytes,2,opt,name=session,proto3" json:"session,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateSessionReq) Reset() {
+	*x = RotateSessionReq{}
+	mi := &file_api_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateSessionReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateSessionReq) ProtoMessage() {}
+
+func (x *RotateSessionReq) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateSessionReq.ProtoReflect.Descriptor instead.
+func (*RotateSessionReq) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RotateSessionReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RotateSessionReq) GetSession() *SessionReq {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
 var File_api_proto protoreflect.FileDescriptor
 
 const file_api_proto_rawDesc = "" +
 	"\n" +
-	"\tapi.proto\x12\x02pb\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf9\x01\n" +
+	"\tapi.proto\x12\x02pb\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf9\x01\n" +
 	"\n" +
 	"ProductReq\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
@@ -1007,9 +2261,29 @@ const file_api_proto_rawDesc = "" +
 	"created_at\x18\n" +
 	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"<\n" +
+	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x8c\x02\n" +
+	"\x0fListProductsReq\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12\x1b\n" +
+	"\tmin_price\x18\x04 \x01(\x02R\bminPrice\x12\x1b\n" +
+	"\tmax_price\x18\x05 \x01(\x02R\bmaxPrice\x12\"\n" +
+	"\rin_stock_only\x18\x06 \x01(\bR\vinStockOnly\x12-\n" +
+	"\asort_by\x18\a \x01(\x0e2\x14.pb.ProductSortFieldR\x06sortBy\x12\x1b\n" +
+	"\tsort_desc\x18\b \x01(\bR\bsortDesc\"]\n" +
 	"\x0eListProductRes\x12*\n" +
-	"\bproducts\x18\x01 \x03(\v2\x0e.pb.ProductResR\bproducts\"\x86\x01\n" +
+	"\bproducts\x18\x01 \x03(\v2\x0e.pb.ProductResR\bproducts\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"?\n" +
+	"\x11SearchProductsReq\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"t\n" +
+	"\x10ProductSearchHit\x12(\n" +
+	"\aproduct\x18\x01 \x01(\v2\x0e.pb.ProductResR\aproduct\x12\x1c\n" +
+	"\trelevance\x18\x02 \x01(\x01R\trelevance\x12\x18\n" +
+	"\asnippet\x18\x03 \x01(\tR\asnippet\"=\n" +
+	"\x11SearchProductsRes\x12(\n" +
+	"\x04hits\x18\x01 \x03(\v2\x14.pb.ProductSearchHitR\x04hits\"\x86\x01\n" +
 	"\tOrderItem\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
 	"\bquantity\x18\x02 \x01(\x03R\bquantity\x12\x14\n" +
@@ -1025,7 +2299,7 @@ const file_api_proto_rawDesc = "" +
 	"\x0eshipping_price\x18\x05 \x01(\x02R\rshippingPrice\x12\x1f\n" +
 	"\vtotal_price\x18\x06 \x01(\x02R\n" +
 	"totalPrice\x12\x17\n" +
-	"\auser_id\x18\a \x01(\x03R\x06userId\"\xda\x02\n" +
+	"\auser_id\x18\a \x01(\x03R\x06userId\"\xb5\x03\n" +
 	"\bOrderRes\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12#\n" +
 	"\x05items\x18\x02 \x03(\v2\r.pb.OrderItemR\x05items\x12%\n" +
@@ -1038,25 +2312,84 @@ const file_api_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"4\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12'\n" +
+	"\x06status\x18\n" +
+	" \x01(\x0e2\x0f.pb.OrderStatusR\x06status\x120\n" +
+	"\tbreakdown\x18\v \x01(\v2\x12.pb.PriceBreakdownR\tbreakdown\"\x96\x01\n" +
+	"\x0ePriceBreakdown\x12\x1f\n" +
+	"\vitems_price\x18\x01 \x01(\x02R\n" +
+	"itemsPrice\x12\x1b\n" +
+	"\ttax_price\x18\x02 \x01(\x02R\btaxPrice\x12%\n" +
+	"\x0eshipping_price\x18\x03 \x01(\x02R\rshippingPrice\x12\x1f\n" +
+	"\vtotal_price\x18\x04 \x01(\x02R\n" +
+	"totalPrice\"O\n" +
+	"\x14UpdateOrderStatusReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12'\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x0f.pb.OrderStatusR\x06status\"4\n" +
 	"\fListOrderRes\x12$\n" +
-	"\x06orders\x18\x01 \x03(\v2\f.pb.OrderResR\x06orders\"z\n" +
+	"\x06orders\x18\x01 \x03(\v2\f.pb.OrderResR\x06orders\"\x90\x01\n" +
 	"\aUserReq\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
 	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1a\n" +
 	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\x19\n" +
-	"\bis_admin\x18\x05 \x01(\bR\aisAdmin\"\xb5\x01\n" +
+	"\bis_admin\x18\x05 \x01(\bR\aisAdmin\x12\x14\n" +
+	"\x05roles\x18\x06 \x03(\tR\x05roles\"\xca\x02\n" +
 	"\aUserRes\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
-	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\x19\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x19\n" +
 	"\bis_admin\x18\x05 \x01(\bR\aisAdmin\x129\n" +
 	"\n" +
-	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"0\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x14\n" +
+	"\x05roles\x18\a \x03(\tR\x05roles\x12 \n" +
+	"\vpermissions\x18\b \x03(\tR\vpermissions\x12F\n" +
+	"\x11email_verified_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x0femailVerifiedAt\x12\x1f\n" +
+	"\vmfa_enabled\x18\n" +
+	" \x01(\bR\n" +
+	"mfaEnabledJ\x04\b\x04\x10\x05R\bpassword\"\x9e\x01\n" +
+	"\x10PasswordResetReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1d\n" +
+	"\n" +
+	"token_hash\x18\x02 \x01(\tR\ttokenHash\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1a\n" +
+	"\bpassword\x18\x04 \x01(\tR\bpassword\"\x9f\x01\n" +
+	"\x11PasswordChangeReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12)\n" +
+	"\x10current_password\x18\x02 \x01(\tR\x0fcurrentPassword\x12!\n" +
+	"\fnew_password\x18\x03 \x01(\tR\vnewPassword\x12&\n" +
+	"\x0fkeep_session_id\x18\x04 \x01(\tR\rkeepSessionId\"\xae\x01\n" +
+	"\aTOTPReq\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x120\n" +
+	"\x14recovery_code_hashes\x18\x04 \x03(\tR\x12recoveryCodeHashes\x12,\n" +
+	"\x12recovery_code_hash\x18\x05 \x01(\tR\x10recoveryCodeHash\"S\n" +
+	"\aTOTPRes\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12.\n" +
+	"\x13recovery_codes_left\x18\x02 \x01(\x03R\x11recoveryCodesLeft\"\xdd\x01\n" +
+	"\x0fLoginAttemptReq\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x121\n" +
+	"\x06window\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x06window\x129\n" +
+	"\n" +
+	"failure_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tfailureAt\x12J\n" +
+	"\x13previous_failure_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x11previousFailureAt\"\x83\x01\n" +
+	"\x0fLoginAttemptRes\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1a\n" +
+	"\bfailures\x18\x02 \x01(\x03R\bfailures\x12B\n" +
+	"\x0flast_failure_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\rlastFailureAt\"6\n" +
+	"\aRoleReq\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"a\n" +
+	"\aRoleRes\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12 \n" +
+	"\vpermissions\x18\x03 \x03(\tR\vpermissions\"0\n" +
+	"\vListRoleRes\x12!\n" +
+	"\x05roles\x18\x01 \x03(\v2\v.pb.RoleResR\x05roles\"0\n" +
 	"\vListUserRes\x12!\n" +
-	"\x05users\x18\x01 \x03(\v2\v.pb.UserResR\x05users\"\xba\x01\n" +
+	"\x05users\x18\x01 \x03(\v2\v.pb.UserResR\x05users\"\xf8\x01\n" +
 	"\n" +
 	"SessionReq\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
@@ -1066,7 +2399,11 @@ const file_api_proto_rawDesc = "" +
 	"\n" +
 	"is_revoked\x18\x04 \x01(\bR\tisRevoked\x129\n" +
 	"\n" +
-	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xba\x01\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x06 \x01(\tR\tuserAgent\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\a \x01(\tR\tipAddress\"\xa8\x03\n" +
 	"\n" +
 	"SessionRes\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
@@ -1076,18 +2413,50 @@ const file_api_proto_rawDesc = "" +
 	"\n" +
 	"is_revoked\x18\x04 \x01(\bR\tisRevoked\x129\n" +
 	"\n" +
-	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt2\xd5\x06\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1b\n" +
+	"\tfamily_id\x18\x06 \x01(\tR\bfamilyId\x12\x1b\n" +
+	"\tparent_id\x18\a \x01(\tR\bparentId\x129\n" +
+	"\n" +
+	"rotated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\trotatedAt\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\t \x01(\tR\tuserAgent\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\n" +
+	" \x01(\tR\tipAddress\x129\n" +
+	"\n" +
+	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"<\n" +
+	"\x0eListSessionRes\x12*\n" +
+	"\bsessions\x18\x01 \x03(\v2\x0e.pb.SessionResR\bsessions\"L\n" +
+	"\x10RotateSessionReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12(\n" +
+	"\asession\x18\x02 \x01(\v2\x0e.pb.SessionReqR\asession*a\n" +
+	"\x10ProductSortField\x12\x0e\n" +
+	"\n" +
+	"SORT_BY_ID\x10\x00\x12\x11\n" +
+	"\rSORT_BY_PRICE\x10\x01\x12\x12\n" +
+	"\x0eSORT_BY_RATING\x10\x02\x12\x16\n" +
+	"\x12SORT_BY_CREATED_AT\x10\x03*n\n" +
+	"\vOrderStatus\x12\x0f\n" +
+	"\vUNSPECIFIED\x10\x00\x12\v\n" +
+	"\aPENDING\x10\x01\x12\b\n" +
+	"\x04PAID\x10\x02\x12\v\n" +
+	"\aSHIPPED\x10\x03\x12\r\n" +
+	"\tDELIVERED\x10\x04\x12\r\n" +
+	"\tCANCELLED\x10\x05\x12\f\n" +
+	"\bREFUNDED\x10\x062\xef\x0f\n" +
 	"\x05ecomm\x121\n" +
 	"\rCreateProduct\x12\x0e.pb.ProductReq\x1a\x0e.pb.ProductRes\"\x00\x12.\n" +
 	"\n" +
-	"GetProduct\x12\x0e.pb.ProductReq\x1a\x0e.pb.ProductRes\"\x00\x124\n" +
-	"\fListProducts\x12\x0e.pb.ProductReq\x1a\x12.pb.ListProductRes\"\x00\x121\n" +
+	"GetProduct\x12\x0e.pb.ProductReq\x1a\x0e.pb.ProductRes\"\x00\x129\n" +
+	"\fListProducts\x12\x13.pb.ListProductsReq\x1a\x12.pb.ListProductRes\"\x00\x12@\n" +
+	"\x0eSearchProducts\x12\x15.pb.SearchProductsReq\x1a\x15.pb.SearchProductsRes\"\x00\x121\n" +
 	"\rUpdateProduct\x12\x0e.pb.ProductReq\x1a\x0e.pb.ProductRes\"\x00\x121\n" +
 	"\rDeleteProduct\x12\x0e.pb.ProductReq\x1a\x0e.pb.ProductRes\"\x00\x12+\n" +
 	"\vCreateOrder\x12\f.pb.OrderReq\x1a\f.pb.OrderRes\"\x00\x12(\n" +
 	"\bGetOrder\x12\f.pb.OrderReq\x1a\f.pb.OrderRes\"\x00\x12.\n" +
 	"\n" +
-	"ListOrders\x12\f.pb.OrderReq\x1a\x10.pb.ListOrderRes\"\x00\x12+\n" +
+	"ListOrders\x12\f.pb.OrderReq\x1a\x10.pb.ListOrderRes\"\x00\x12=\n" +
+	"\x11UpdateOrderStatus\x12\x18.pb.UpdateOrderStatusReq\x1a\f.pb.OrderRes\"\x00\x12+\n" +
 	"\vDeleteOrder\x12\f.pb.OrderReq\x1a\f.pb.OrderRes\"\x00\x12(\n" +
 	"\n" +
 	"CreateUser\x12\v.pb.UserReq\x1a\v.pb.UserRes\"\x00\x12%\n" +
@@ -1096,11 +2465,34 @@ const file_api_proto_rawDesc = "" +
 	"\n" +
 	"UpdateUser\x12\v.pb.UserReq\x1a\v.pb.UserRes\"\x00\x12(\n" +
 	"\n" +
-	"DeleteUser\x12\v.pb.UserReq\x1a\v.pb.UserRes\"\x00\x121\n" +
+	"DeleteUser\x12\v.pb.UserReq\x1a\v.pb.UserRes\"\x00\x12)\n" +
+	"\vVerifyEmail\x12\v.pb.UserReq\x1a\v.pb.UserRes\"\x00\x12:\n" +
+	"\x13CreatePasswordReset\x12\x14.pb.PasswordResetReq\x1a\v.pb.UserRes\"\x00\x124\n" +
+	"\rResetPassword\x12\x14.pb.PasswordResetReq\x1a\v.pb.UserRes\"\x00\x12/\n" +
+	"\x11VerifyCredentials\x12\v.pb.UserReq\x1a\v.pb.UserRes\"\x00\x126\n" +
+	"\x0eChangePassword\x12\x15.pb.PasswordChangeReq\x1a\v.pb.UserRes\"\x00\x12(\n" +
+	"\n" +
+	"EnrollTOTP\x12\v.pb.TOTPReq\x1a\v.pb.TOTPRes\"\x00\x12)\n" +
+	"\vConfirmTOTP\x12\v.pb.TOTPReq\x1a\v.pb.TOTPRes\"\x00\x12(\n" +
+	"\n" +
+	"VerifyTOTP\x12\v.pb.TOTPReq\x1a\v.pb.TOTPRes\"\x00\x12)\n" +
+	"\vDisableTOTP\x12\v.pb.TOTPReq\x1a\v.pb.TOTPRes\"\x00\x12=\n" +
+	"\x0fGetLoginAttempt\x12\x13.pb.LoginAttemptReq\x1a\x13.pb.LoginAttemptRes\"\x00\x12=\n" +
+	"\x0fAddLoginFailure\x12\x13.pb.LoginAttemptReq\x1a\x13.pb.LoginAttemptRes\"\x00\x12@\n" +
+	"\x12RemoveLoginFailure\x12\x13.pb.LoginAttemptReq\x1a\x13.pb.LoginAttemptRes\"\x00\x12@\n" +
+	"\x12ResetLoginAttempts\x12\x13.pb.LoginAttemptReq\x1a\x13.pb.LoginAttemptRes\"\x00\x12+\n" +
+	"\tListRoles\x12\v.pb.RoleReq\x1a\x0f.pb.ListRoleRes\"\x00\x12.\n" +
+	"\fGetUserRoles\x12\v.pb.RoleReq\x1a\x0f.pb.ListRoleRes\"\x00\x12,\n" +
+	"\n" +
+	"AssignRole\x12\v.pb.RoleReq\x1a\x0f.pb.ListRoleRes\"\x00\x12.\n" +
+	"\fUnassignRole\x12\v.pb.RoleReq\x1a\x0f.pb.ListRoleRes\"\x00\x121\n" +
 	"\rCreateSession\x12\x0e.pb.SessionReq\x1a\x0e.pb.SessionRes\"\x00\x12.\n" +
 	"\n" +
-	"GetSession\x12\x0e.pb.SessionReq\x1a\x0e.pb.SessionRes\"\x00\x121\n" +
-	"\rRevokeSession\x12\x0e.pb.SessionReq\x1a\x0e.pb.SessionRes\"\x00\x121\n" +
+	"GetSession\x12\x0e.pb.SessionReq\x1a\x0e.pb.SessionRes\"\x00\x127\n" +
+	"\rRotateSession\x12\x14.pb.RotateSessionReq\x1a\x0e.pb.SessionRes\"\x00\x124\n" +
+	"\fListSessions\x12\x0e.pb.SessionReq\x1a\x12.pb.ListSessionRes\"\x00\x121\n" +
+	"\rRevokeSession\x12\x0e.pb.SessionReq\x1a\x0e.pb.SessionRes\"\x00\x126\n" +
+	"\x12RevokeUserSessions\x12\x0e.pb.SessionReq\x1a\x0e.pb.SessionRes\"\x00\x121\n" +
 	"\rDeleteSession\x12\x0e.pb.SessionReq\x1a\x0e.pb.SessionRes\"\x00B Z\x1edavidHwang/ecomm/ecomm-grpc/pbb\x06proto3"
 
 var (
@@ -1115,76 +2507,158 @@ func file_api_proto_rawDescGZIP() []byte {
 	return file_api_proto_rawDescData
 }
 
-var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_api_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_api_proto_goTypes = []any{
-	(*ProductReq)(nil),            // 0: pb.ProductReq
-	(*ProductRes)(nil),            // 1: pb.ProductRes
-	(*ListProductRes)(nil),        // 2: pb.ListProductRes
-	(*OrderItem)(nil),             // 3: pb.OrderItem
-	(*OrderReq)(nil),              // 4: pb.OrderReq
-	(*OrderRes)(nil),              // 5: pb.OrderRes
-	(*ListOrderRes)(nil),          // 6: pb.ListOrderRes
-	(*UserReq)(nil),               // 7: pb.UserReq
-	(*UserRes)(nil),               // 8: pb.UserRes
-	(*ListUserRes)(nil),           // 9: pb.ListUserRes
-	(*SessionReq)(nil),            // 10: pb.SessionReq
-	(*SessionRes)(nil),            // 11: pb.SessionRes
-	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+	(ProductSortField)(0),         // 0: pb.ProductSortField
+	(OrderStatus)(0),              // 1: pb.OrderStatus
+	(*ProductReq)(nil),            // 2: pb.ProductReq
+	(*ProductRes)(nil),            // 3: pb.ProductRes
+	(*ListProductsReq)(nil),       // 4: pb.ListProductsReq
+	(*ListProductRes)(nil),        // 5: pb.ListProductRes
+	(*SearchProductsReq)(nil),     // 6: pb.SearchProductsReq
+	(*ProductSearchHit)(nil),      // 7: pb.ProductSearchHit
+	(*SearchProductsRes)(nil),     // 8: pb.SearchProductsRes
+	(*OrderItem)(nil),             // 9: pb.OrderItem
+	(*OrderReq)(nil),              // 10: pb.OrderReq
+	(*OrderRes)(nil),              // 11: pb.OrderRes
+	(*PriceBreakdown)(nil),        // 12: pb.PriceBreakdown
+	(*UpdateOrderStatusReq)(nil),  // 13: pb.UpdateOrderStatusReq
+	(*ListOrderRes)(nil),          // 14: pb.ListOrderRes
+	(*UserReq)(nil),               // 15: pb.UserReq
+	(*UserRes)(nil),               // 16: pb.UserRes
+	(*PasswordResetReq)(nil),      // 17: pb.PasswordResetReq
+	(*PasswordChangeReq)(nil),     // 18: pb.PasswordChangeReq
+	(*TOTPReq)(nil),               // 19: pb.TOTPReq
+	(*TOTPRes)(nil),               // 20: pb.TOTPRes
+	(*LoginAttemptReq)(nil),       // 21: pb.LoginAttemptReq
+	(*LoginAttemptRes)(nil),       // 22: pb.LoginAttemptRes
+	(*RoleReq)(nil),               // 23: pb.RoleReq
+	(*RoleRes)(nil),               // 24: pb.RoleRes
+	(*ListRoleRes)(nil),           // 25: pb.ListRoleRes
+	(*ListUserRes)(nil),           // 26: pb.ListUserRes
+	(*SessionReq)(nil),            // 27: pb.SessionReq
+	(*SessionRes)(nil),            // 28: pb.SessionRes
+	(*ListSessionRes)(nil),        // 29: pb.ListSessionRes
+	(*RotateSessionReq)(nil),      // 30: pb.RotateSessionReq
+	(*timestamppb.Timestamp)(nil), // 31: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 32: google.protobuf.Duration
 }
 var file_api_proto_depIdxs = []int32{
-	12, // 0: pb.ProductRes.created_at:type_name -> google.protobuf.Timestamp
-	12, // 1: pb.ProductRes.updated_at:type_name -> google.protobuf.Timestamp
-	1,  // 2: pb.ListProductRes.products:type_name -> pb.ProductRes
-	3,  // 3: pb.OrderReq.items:type_name -> pb.OrderItem
-	3,  // 4: pb.OrderRes.items:type_name -> pb.OrderItem
-	12, // 5: pb.OrderRes.created_at:type_name -> google.protobuf.Timestamp
-	12, // 6: pb.OrderRes.updated_at:type_name -> google.protobuf.Timestamp
-	5,  // 7: pb.ListOrderRes.orders:type_name -> pb.OrderRes
-	12, // 8: pb.UserRes.created_at:type_name -> google.protobuf.Timestamp
-	8,  // 9: pb.ListUserRes.users:type_name -> pb.UserRes
-	12, // 10: pb.SessionReq.expires_at:type_name -> google.protobuf.Timestamp
-	12, // 11: pb.SessionRes.expires_at:type_name -> google.protobuf.Timestamp
-	0,  // 12: pb.ecomm.CreateProduct:input_type -> pb.ProductReq
-	0,  // 13: pb.ecomm.GetProduct:input_type -> pb.ProductReq
-	0,  // 14: pb.ecomm.ListProducts:input_type -> pb.ProductReq
-	0,  // 15: pb.ecomm.UpdateProduct:input_type -> pb.ProductReq
-	0,  // 16: pb.ecomm.DeleteProduct:input_type -> pb.ProductReq
-	4,  // 17: pb.ecomm.CreateOrder:input_type -> pb.OrderReq
-	4,  // 18: pb.ecomm.GetOrder:input_type -> pb.OrderReq
-	4,  // 19: pb.ecomm.ListOrders:input_type -> pb.OrderReq
-	4,  // 20: pb.ecomm.DeleteOrder:input_type -> pb.OrderReq
-	7,  // 21: pb.ecomm.CreateUser:input_type -> pb.UserReq
-	7,  // 22: pb.ecomm.GetUser:input_type -> pb.UserReq
-	7,  // 23: pb.ecomm.ListUsers:input_type -> pb.UserReq
-	7,  // 24: pb.ecomm.UpdateUser:input_type -> pb.UserReq
-	7,  // 25: pb.ecomm.DeleteUser:input_type -> pb.UserReq
-	10, // 26: pb.ecomm.CreateSession:input_type -> pb.SessionReq
-	10, // 27: pb.ecomm.GetSession:input_type -> pb.SessionReq
-	10, // 28: pb.ecomm.RevokeSession:input_type -> pb.SessionReq
-	10, // 29: pb.ecomm.DeleteSession:input_type -> pb.SessionReq
-	1,  // 30: pb.ecomm.CreateProduct:output_type -> pb.ProductRes
-	1,  // 31: pb.ecomm.GetProduct:output_type -> pb.ProductRes
-	2,  // 32: pb.ecomm.ListProducts:output_type -> pb.ListProductRes
-	1,  // 33: pb.ecomm.UpdateProduct:output_type -> pb.ProductRes
-	1,  // 34: pb.ecomm.DeleteProduct:output_type -> pb.ProductRes
-	5,  // 35: pb.ecomm.CreateOrder:output_type -> pb.OrderRes
-	5,  // 36: pb.ecomm.GetOrder:output_type -> pb.OrderRes
-	6,  // 37: pb.ecomm.ListOrders:output_type -> pb.ListOrderRes
-	5,  // 38: pb.ecomm.DeleteOrder:output_type -> pb.OrderRes
-	8,  // 39: pb.ecomm.CreateUser:output_type -> pb.UserRes
-	8,  // 40: pb.ecomm.GetUser:output_type -> pb.UserRes
-	9,  // 41: pb.ecomm.ListUsers:output_type -> pb.ListUserRes
-	8,  // 42: pb.ecomm.UpdateUser:output_type -> pb.UserRes
-	8,  // 43: pb.ecomm.DeleteUser:output_type -> pb.UserRes
-	11, // 44: pb.ecomm.CreateSession:output_type -> pb.SessionRes
-	11, // 45: pb.ecomm.GetSession:output_type -> pb.SessionRes
-	11, // 46: pb.ecomm.RevokeSession:output_type -> pb.SessionRes
-	11, // 47: pb.ecomm.DeleteSession:output_type -> pb.SessionRes
-	30, // [30:48] is the sub-list for method output_type
-	12, // [12:30] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	31, // 0: pb.ProductRes.created_at:type_name -> google.protobuf.Timestamp
+	31, // 1: pb.ProductRes.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: pb.ListProductsReq.sort_by:type_name -> pb.ProductSortField
+	3,  // 3: pb.ListProductRes.products:type_name -> pb.ProductRes
+	3,  // 4: pb.ProductSearchHit.product:type_name -> pb.ProductRes
+	7,  // 5: pb.SearchProductsRes.hits:type_name -> pb.ProductSearchHit
+	9,  // 6: pb.OrderReq.items:type_name -> pb.OrderItem
+	9,  // 7: pb.OrderRes.items:type_name -> pb.OrderItem
+	31, // 8: pb.OrderRes.created_at:type_name -> google.protobuf.Timestamp
+	31, // 9: pb.OrderRes.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 10: pb.OrderRes.status:type_name -> pb.OrderStatus
+	12, // 11: pb.OrderRes.breakdown:type_name -> pb.PriceBreakdown
+	1,  // 12: pb.UpdateOrderStatusReq.status:type_name -> pb.OrderStatus
+	11, // 13: pb.ListOrderRes.orders:type_name -> pb.OrderRes
+	31, // 14: pb.UserRes.created_at:type_name -> google.protobuf.Timestamp
+	31, // 15: pb.UserRes.email_verified_at:type_name -> google.protobuf.Timestamp
+	31, // 16: pb.PasswordResetReq.expires_at:type_name -> google.protobuf.Timestamp
+	32, // 17: pb.LoginAttemptReq.window:type_name -> google.protobuf.Duration
+	31, // 18: pb.LoginAttemptReq.failure_at:type_name -> google.protobuf.Timestamp
+	31, // 19: pb.LoginAttemptReq.previous_failure_at:type_name -> google.protobuf.Timestamp
+	31, // 20: pb.LoginAttemptRes.last_failure_at:type_name -> google.protobuf.Timestamp
+	24, // 21: pb.ListRoleRes.roles:type_name -> pb.RoleRes
+	16, // 22: pb.ListUserRes.users:type_name -> pb.UserRes
+	31, // 23: pb.SessionReq.expires_at:type_name -> google.protobuf.Timestamp
+	31, // 24: pb.SessionRes.expires_at:type_name -> google.protobuf.Timestamp
+	31, // 25: pb.SessionRes.rotated_at:type_name -> google.protobuf.Timestamp
+	31, // 26: pb.SessionRes.created_at:type_name -> google.protobuf.Timestamp
+	28, // 27: pb.ListSessionRes.sessions:type_name -> pb.SessionRes
+	27, // 28: pb.RotateSessionReq.session:type_name -> pb.SessionReq
+	2,  // 29: pb.ecomm.CreateProduct:input_type -> pb.ProductReq
+	2,  // 30: pb.ecomm.GetProduct:input_type -> pb.ProductReq
+	4,  // 31: pb.ecomm.ListProducts:input_type -> pb.ListProductsReq
+	6,  // 32: pb.ecomm.SearchProducts:input_type -> pb.SearchProductsReq
+	2,  // 33: pb.ecomm.UpdateProduct:input_type -> pb.ProductReq
+	2,  // 34: pb.ecomm.DeleteProduct:input_type -> pb.ProductReq
+	10, // 35: pb.ecomm.CreateOrder:input_type -> pb.OrderReq
+	10, // 36: pb.ecomm.GetOrder:input_type -> pb.OrderReq
+	10, // 37: pb.ecomm.ListOrders:input_type -> pb.OrderReq
+	13, // 38: pb.ecomm.UpdateOrderStatus:input_type -> pb.UpdateOrderStatusReq
+	10, // 39: pb.ecomm.DeleteOrder:input_type -> pb.OrderReq
+	15, // 40: pb.ecomm.CreateUser:input_type -> pb.UserReq
+	15, // 41: pb.ecomm.GetUser:input_type -> pb.UserReq
+	15, // 42: pb.ecomm.ListUsers:input_type -> pb.UserReq
+	15, // 43: pb.ecomm.UpdateUser:input_type -> pb.UserReq
+	15, // 44: pb.ecomm.DeleteUser:input_type -> pb.UserReq
+	15, // 45: pb.ecomm.VerifyEmail:input_type -> pb.UserReq
+	17, // 46: pb.ecomm.CreatePasswordReset:input_type -> pb.PasswordResetReq
+	17, // 47: pb.ecomm.ResetPassword:input_type -> pb.PasswordResetReq
+	15, // 48: pb.ecomm.VerifyCredentials:input_type -> pb.UserReq
+	18, // 49: pb.ecomm.ChangePassword:input_type -> pb.PasswordChangeReq
+	19, // 50: pb.ecomm.EnrollTOTP:input_type -> pb.TOTPReq
+	19, // 51: pb.ecomm.ConfirmTOTP:input_type -> pb.TOTPReq
+	19, // 52: pb.ecomm.VerifyTOTP:input_type -> pb.TOTPReq
+	19, // 53: pb.ecomm.DisableTOTP:input_type -> pb.TOTPReq
+	21, // 54: pb.ecomm.GetLoginAttempt:input_type -> pb.LoginAttemptReq
+	21, // 55: pb.ecomm.AddLoginFailure:input_type -> pb.LoginAttemptReq
+	21, // 56: pb.ecomm.RemoveLoginFailure:input_type -> pb.LoginAttemptReq
+	21, // 57: pb.ecomm.ResetLoginAttempts:input_type -> pb.LoginAttemptReq
+	23, // 58: pb.ecomm.ListRoles:input_type -> pb.RoleReq
+	23, // 59: pb.ecomm.GetUserRoles:input_type -> pb.RoleReq
+	23, // 60: pb.ecomm.AssignRole:input_type -> pb.RoleReq
+	23, // 61: pb.ecomm.UnassignRole:input_type -> pb.RoleReq
+	27, // 62: pb.ecomm.CreateSession:input_type -> pb.SessionReq
+	27, // 63: pb.ecomm.GetSession:input_type -> pb.SessionReq
+	30, // 64: pb.ecomm.RotateSession:input_type -> pb.RotateSessionReq
+	27, // 65: pb.ecomm.ListSessions:input_type -> pb.SessionReq
+	27, // 66: pb.ecomm.RevokeSession:input_type -> pb.SessionReq
+	27, // 67: pb.ecomm.RevokeUserSessions:input_type -> pb.SessionReq
+	27, // 68: pb.ecomm.DeleteSession:input_type -> pb.SessionReq
+	3,  // 69: pb.ecomm.CreateProduct:output_type -> pb.ProductRes
+	3,  // 70: pb.ecomm.GetProduct:output_type -> pb.ProductRes
+	5,  // 71: pb.ecomm.ListProducts:output_type -> pb.ListProductRes
+	8,  // 72: pb.ecomm.SearchProducts:output_type -> pb.SearchProductsRes
+	3,  // 73: pb.ecomm.UpdateProduct:output_type -> pb.ProductRes
+	3,  // 74: pb.ecomm.DeleteProduct:output_type -> pb.ProductRes
+	11, // 75: pb.ecomm.CreateOrder:output_type -> pb.OrderRes
+	11, // 76: pb.ecomm.GetOrder:output_type -> pb.OrderRes
+	14, // 77: pb.ecomm.ListOrders:output_type -> pb.ListOrderRes
+	11, // 78: pb.ecomm.UpdateOrderStatus:output_type -> pb.OrderRes
+	11, // 79: pb.ecomm.DeleteOrder:output_type -> pb.OrderRes
+	16, // 80: pb.ecomm.CreateUser:output_type -> pb.UserRes
+	16, // 81: pb.ecomm.GetUser:output_type -> pb.UserRes
+	26, // 82: pb.ecomm.ListUsers:output_type -> pb.ListUserRes
+	16, // 83: pb.ecomm.UpdateUser:output_type -> pb.UserRes
+	16, // 84: pb.ecomm.DeleteUser:output_type -> pb.UserRes
+	16, // 85: pb.ecomm.VerifyEmail:output_type -> pb.UserRes
+	16, // 86: pb.ecomm.CreatePasswordReset:output_type -> pb.UserRes
+	16, // 87: pb.ecomm.ResetPassword:output_type -> pb.UserRes
+	16, // 88: pb.ecomm.VerifyCredentials:output_type -> pb.UserRes
+	16, // 89: pb.ecomm.ChangePassword:output_type -> pb.UserRes
+	20, // 90: pb.ecomm.EnrollTOTP:output_type -> pb.TOTPRes
+	20, // 91: pb.ecomm.ConfirmTOTP:output_type -> pb.TOTPRes
+	20, // 92: pb.ecomm.VerifyTOTP:output_type -> pb.TOTPRes
+	20, // 93: pb.ecomm.DisableTOTP:output_type -> pb.TOTPRes
+	22, // 94: pb.ecomm.GetLoginAttempt:output_type -> pb.LoginAttemptRes
+	22, // 95: pb.ecomm.AddLoginFailure:output_type -> pb.LoginAttemptRes
+	22, // 96: pb.ecomm.RemoveLoginFailure:output_type -> pb.LoginAttemptRes
+	22, // 97: pb.ecomm.ResetLoginAttempts:output_type -> pb.LoginAttemptRes
+	25, // 98: pb.ecomm.ListRoles:output_type -> pb.ListRoleRes
+	25, // 99: pb.ecomm.GetUserRoles:output_type -> pb.ListRoleRes
+	25, // 100: pb.ecomm.AssignRole:output_type -> pb.ListRoleRes
+	25, // 101: pb.ecomm.UnassignRole:output_type -> pb.ListRoleRes
+	28, // 102: pb.ecomm.CreateSession:output_type -> pb.SessionRes
+	28, // 103: pb.ecomm.GetSession:output_type -> pb.SessionRes
+	28, // 104: pb.ecomm.RotateSession:output_type -> pb.SessionRes
+	29, // 105: pb.ecomm.ListSessions:output_type -> pb.ListSessionRes
+	28, // 106: pb.ecomm.RevokeSession:output_type -> pb.SessionRes
+	28, // 107: pb.ecomm.RevokeUserSessions:output_type -> pb.SessionRes
+	28, // 108: pb.ecomm.DeleteSession:output_type -> pb.SessionRes
+	69, // [69:109] is the sub-list for method output_type
+	29, // [29:69] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_init() }
@@ -1197,13 +2671,14 @@ func file_api_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_rawDesc), len(file_api_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   12,
+			NumEnums:      2,
+			NumMessages:   29,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_proto_goTypes,
 		DependencyIndexes: file_api_proto_depIdxs,
+		EnumInfos:         file_api_proto_enumTypes,
 		MessageInfos:      file_api_proto_msgTypes,
 	}.Build()
 	File_api_proto = out.File
@@ -19,24 +19,46 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Ecomm_CreateProduct_FullMethodName = "/pb.ecomm/CreateProduct"
-	Ecomm_GetProduct_FullMethodName    = "/pb.ecomm/GetProduct"
-	Ecomm_ListProducts_FullMethodName  = "/pb.ecomm/ListProducts"
-	Ecomm_UpdateProduct_FullMethodName = "/pb.ecomm/UpdateProduct"
-	Ecomm_DeleteProduct_FullMethodName = "/pb.ecomm/DeleteProduct"
-	Ecomm_CreateOrder_FullMethodName   = "/pb.ecomm/CreateOrder"
-	Ecomm_GetOrder_FullMethodName      = "/pb.ecomm/GetOrder"
-	Ecomm_ListOrders_FullMethodName    = "/pb.ecomm/ListOrders"
-	Ecomm_DeleteOrder_FullMethodName   = "/pb.ecomm/DeleteOrder"
-	Ecomm_CreateUser_FullMethodName    = "/pb.ecomm/CreateUser"
-	Ecomm_GetUser_FullMethodName       = "/pb.ecomm/GetUser"
-	Ecomm_ListUsers_FullMethodName     = "/pb.ecomm/ListUsers"
-	Ecomm_UpdateUser_FullMethodName    = "/pb.ecomm/UpdateUser"
-	Ecomm_DeleteUser_FullMethodName    = "/pb.ecomm/DeleteUser"
-	Ecomm_CreateSession_FullMethodName = "/pb.ecomm/CreateSession"
-	Ecomm_GetSession_FullMethodName    = "/pb.ecomm/GetSession"
-	Ecomm_RevokeSession_FullMethodName = "/pb.ecomm/RevokeSession"
-	Ecomm_DeleteSession_FullMethodName = "/pb.ecomm/DeleteSession"
+	Ecomm_CreateProduct_FullMethodName       = "/pb.ecomm/CreateProduct"
+	Ecomm_GetProduct_FullMethodName          = "/pb.ecomm/GetProduct"
+	Ecomm_ListProducts_FullMethodName        = "/pb.ecomm/ListProducts"
+	Ecomm_SearchProducts_FullMethodName      = "/pb.ecomm/SearchProducts"
+	Ecomm_UpdateProduct_FullMethodName       = "/pb.ecomm/UpdateProduct"
+	Ecomm_DeleteProduct_FullMethodName       = "/pb.ecomm/DeleteProduct"
+	Ecomm_CreateOrder_FullMethodName         = "/pb.ecomm/CreateOrder"
+	Ecomm_GetOrder_FullMethodName            = "/pb.ecomm/GetOrder"
+	Ecomm_ListOrders_FullMethodName          = "/pb.ecomm/ListOrders"
+	Ecomm_UpdateOrderStatus_FullMethodName   = "/pb.ecomm/UpdateOrderStatus"
+	Ecomm_DeleteOrder_FullMethodName         = "/pb.ecomm/DeleteOrder"
+	Ecomm_CreateUser_FullMethodName          = "/pb.ecomm/CreateUser"
+	Ecomm_GetUser_FullMethodName             = "/pb.ecomm/GetUser"
+	Ecomm_ListUsers_FullMethodName           = "/pb.ecomm/ListUsers"
+	Ecomm_UpdateUser_FullMethodName          = "/pb.ecomm/UpdateUser"
+	Ecomm_DeleteUser_FullMethodName          = "/pb.ecomm/DeleteUser"
+	Ecomm_VerifyEmail_FullMethodName         = "/pb.ecomm/VerifyEmail"
+	Ecomm_CreatePasswordReset_FullMethodName = "/pb.ecomm/CreatePasswordReset"
+	Ecomm_ResetPassword_FullMethodName       = "/pb.ecomm/ResetPassword"
+	Ecomm_VerifyCredentials_FullMethodName   = "/pb.ecomm/VerifyCredentials"
+	Ecomm_ChangePassword_FullMethodName      = "/pb.ecomm/ChangePassword"
+	Ecomm_EnrollTOTP_FullMethodName          = "/pb.ecomm/EnrollTOTP"
+	Ecomm_ConfirmTOTP_FullMethodName         = "/pb.ecomm/ConfirmTOTP"
+	Ecomm_VerifyTOTP_FullMethodName          = "/pb.ecomm/VerifyTOTP"
+	Ecomm_DisableTOTP_FullMethodName         = "/pb.ecomm/DisableTOTP"
+	Ecomm_GetLoginAttempt_FullMethodName     = "/pb.ecomm/GetLoginAttempt"
+	Ecomm_AddLoginFailure_FullMethodName     = "/pb.ecomm/AddLoginFailure"
+	Ecomm_RemoveLoginFailure_FullMethodName  = "/pb.ecomm/RemoveLoginFailure"
+	Ecomm_ResetLoginAttempts_FullMethodName  = "/pb.ecomm/ResetLoginAttempts"
+	Ecomm_ListRoles_FullMethodName           = "/pb.ecomm/ListRoles"
+	Ecomm_GetUserRoles_FullMethodName        = "/pb.ecomm/GetUserRoles"
+	Ecomm_AssignRole_FullMethodName          = "/pb.ecomm/AssignRole"
+	Ecomm_UnassignRole_FullMethodName        = "/pb.ecomm/UnassignRole"
+	Ecomm_CreateSession_FullMethodName       = "/pb.ecomm/CreateSession"
+	Ecomm_GetSession_FullMethodName          = "/pb.ecomm/GetSession"
+	Ecomm_RotateSession_FullMethodName       = "/pb.ecomm/RotateSession"
+	Ecomm_ListSessions_FullMethodName        = "/pb.ecomm/ListSessions"
+	Ecomm_RevokeSession_FullMethodName       = "/pb.ecomm/RevokeSession"
+	Ecomm_RevokeUserSessions_FullMethodName  = "/pb.ecomm/RevokeUserSessions"
+	Ecomm_DeleteSession_FullMethodName       = "/pb.ecomm/DeleteSession"
 )
 
 // EcommClient is the client API for Ecomm service.
@@ -45,21 +67,43 @@ const (
 type EcommClient interface {
 	CreateProduct(ctx context.Context, in *ProductReq, opts ...grpc.CallOption) (*ProductRes, error)
 	GetProduct(ctx context.Context, in *ProductReq, opts ...grpc.CallOption) (*ProductRes, error)
-	ListProducts(ctx context.Context, in *ProductReq, opts ...grpc.CallOption) (*ListProductRes, error)
+	ListProducts(ctx context.Context, in *ListProductsReq, opts ...grpc.CallOption) (*ListProductRes, error)
+	SearchProducts(ctx context.Context, in *SearchProductsReq, opts ...grpc.CallOption) (*SearchProductsRes, error)
 	UpdateProduct(ctx context.Context, in *ProductReq, opts ...grpc.CallOption) (*ProductRes, error)
 	DeleteProduct(ctx context.Context, in *ProductReq, opts ...grpc.CallOption) (*ProductRes, error)
 	CreateOrder(ctx context.Context, in *OrderReq, opts ...grpc.CallOption) (*OrderRes, error)
 	GetOrder(ctx context.Context, in *OrderReq, opts ...grpc.CallOption) (*OrderRes, error)
 	ListOrders(ctx context.Context, in *OrderReq, opts ...grpc.CallOption) (*ListOrderRes, error)
+	UpdateOrderStatus(ctx context.Context, in *UpdateOrderStatusReq, opts ...grpc.CallOption) (*OrderRes, error)
 	DeleteOrder(ctx context.Context, in *OrderReq, opts ...grpc.CallOption) (*OrderRes, error)
 	CreateUser(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error)
 	GetUser(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error)
 	ListUsers(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*ListUserRes, error)
 	UpdateUser(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error)
 	DeleteUser(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error)
+	VerifyEmail(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error)
+	CreatePasswordReset(ctx context.Context, in *PasswordResetReq, opts ...grpc.CallOption) (*UserRes, error)
+	ResetPassword(ctx context.Context, in *PasswordResetReq, opts ...grpc.CallOption) (*UserRes, error)
+	VerifyCredentials(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error)
+	ChangePassword(ctx context.Context, in *PasswordChangeReq, opts ...grpc.CallOption) (*UserRes, error)
+	EnrollTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error)
+	ConfirmTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error)
+	VerifyTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error)
+	DisableTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error)
+	GetLoginAttempt(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error)
+	AddLoginFailure(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error)
+	RemoveLoginFailure(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error)
+	ResetLoginAttempts(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error)
+	ListRoles(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error)
+	GetUserRoles(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error)
+	AssignRole(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error)
+	UnassignRole(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error)
 	CreateSession(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error)
 	GetSession(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error)
+	RotateSession(ctx context.Context, in *RotateSessionReq, opts ...grpc.CallOption) (*SessionRes, error)
+	ListSessions(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*ListSessionRes, error)
 	RevokeSession(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error)
+	RevokeUserSessions(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error)
 	DeleteSession(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error)
 }
 
@@ -91,7 +135,7 @@ func (c *ecommClient) GetProduct(ctx context.Context, in *ProductReq, opts ...gr
 	return out, nil
 }
 
-func (c *ecommClient) ListProducts(ctx context.Context, in *ProductReq, opts ...grpc.CallOption) (*ListProductRes, error) {
+func (c *ecommClient) ListProducts(ctx context.Context, in *ListProductsReq, opts ...grpc.CallOption) (*ListProductRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListProductRes)
 	err := c.cc.Invoke(ctx, Ecomm_ListProducts_FullMethodName, in, out, cOpts...)
@@ -101,6 +145,16 @@ func (c *ecommClient) ListProducts(ctx context.Context, in *ProductReq, opts ...
 	return out, nil
 }
 
+func (c *ecommClient) SearchProducts(ctx context.Context, in *SearchProductsReq, opts ...grpc.CallOption) (*SearchProductsRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchProductsRes)
+	err := c.cc.Invoke(ctx, Ecomm_SearchProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *ecommClient) UpdateProduct(ctx context.Context, in *ProductReq, opts ...grpc.CallOption) (*ProductRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ProductRes)
@@ -151,6 +205,16 @@ func (c *ecommClient) ListOrders(ctx context.Context, in *OrderReq, opts ...grpc
 	return out, nil
 }
 
+func (c *ecommClient) UpdateOrderStatus(ctx context.Context, in *UpdateOrderStatusReq, opts ...grpc.CallOption) (*OrderRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderRes)
+	err := c.cc.Invoke(ctx, Ecomm_UpdateOrderStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *ecommClient) DeleteOrder(ctx context.Context, in *OrderReq, opts ...grpc.CallOption) (*OrderRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(OrderRes)
@@ -211,6 +275,176 @@ func (c *ecommClient) DeleteUser(ctx context.Context, in *UserReq, opts ...grpc.
 	return out, nil
 }
 
+func (c *ecommClient) VerifyEmail(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserRes)
+	err := c.cc.Invoke(ctx, Ecomm_VerifyEmail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) CreatePasswordReset(ctx context.Context, in *PasswordResetReq, opts ...grpc.CallOption) (*UserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserRes)
+	err := c.cc.Invoke(ctx, Ecomm_CreatePasswordReset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) ResetPassword(ctx context.Context, in *PasswordResetReq, opts ...grpc.CallOption) (*UserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserRes)
+	err := c.cc.Invoke(ctx, Ecomm_ResetPassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) VerifyCredentials(ctx context.Context, in *UserReq, opts ...grpc.CallOption) (*UserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserRes)
+	err := c.cc.Invoke(ctx, Ecomm_VerifyCredentials_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) ChangePassword(ctx context.Context, in *PasswordChangeReq, opts ...grpc.CallOption) (*UserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserRes)
+	err := c.cc.Invoke(ctx, Ecomm_ChangePassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) EnrollTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TOTPRes)
+	err := c.cc.Invoke(ctx, Ecomm_EnrollTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) ConfirmTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TOTPRes)
+	err := c.cc.Invoke(ctx, Ecomm_ConfirmTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) VerifyTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TOTPRes)
+	err := c.cc.Invoke(ctx, Ecomm_VerifyTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) DisableTOTP(ctx context.Context, in *TOTPReq, opts ...grpc.CallOption) (*TOTPRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TOTPRes)
+	err := c.cc.Invoke(ctx, Ecomm_DisableTOTP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) GetLoginAttempt(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginAttemptRes)
+	err := c.cc.Invoke(ctx, Ecomm_GetLoginAttempt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) AddLoginFailure(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginAttemptRes)
+	err := c.cc.Invoke(ctx, Ecomm_AddLoginFailure_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) RemoveLoginFailure(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginAttemptRes)
+	err := c.cc.Invoke(ctx, Ecomm_RemoveLoginFailure_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) ResetLoginAttempts(ctx context.Context, in *LoginAttemptReq, opts ...grpc.CallOption) (*LoginAttemptRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginAttemptRes)
+	err := c.cc.Invoke(ctx, Ecomm_ResetLoginAttempts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) ListRoles(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoleRes)
+	err := c.cc.Invoke(ctx, Ecomm_ListRoles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) GetUserRoles(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoleRes)
+	err := c.cc.Invoke(ctx, Ecomm_GetUserRoles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) AssignRole(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoleRes)
+	err := c.cc.Invoke(ctx, Ecomm_AssignRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) UnassignRole(ctx context.Context, in *RoleReq, opts ...grpc.CallOption) (*ListRoleRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoleRes)
+	err := c.cc.Invoke(ctx, Ecomm_UnassignRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *ecommClient) CreateSession(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SessionRes)
@@ -231,6 +465,26 @@ func (c *ecommClient) GetSession(ctx context.Context, in *SessionReq, opts ...gr
 	return out, nil
 }
 
+func (c *ecommClient) RotateSession(ctx context.Context, in *RotateSessionReq, opts ...grpc.CallOption) (*SessionRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionRes)
+	err := c.cc.Invoke(ctx, Ecomm_RotateSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ecommClient) ListSessions(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*ListSessionRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionRes)
+	err := c.cc.Invoke(ctx, Ecomm_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *ecommClient) RevokeSession(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SessionRes)
@@ -241,6 +495,16 @@ func (c *ecommClient) RevokeSession(ctx context.Context, in *SessionReq, opts ..
 	return out, nil
 }
 
+func (c *ecommClient) RevokeUserSessions(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionRes)
+	err := c.cc.Invoke(ctx, Ecomm_RevokeUserSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *ecommClient) DeleteSession(ctx context.Context, in *SessionReq, opts ...grpc.CallOption) (*SessionRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SessionRes)
@@ -257,21 +521,43 @@ func (c *ecommClient) DeleteSession(ctx context.Context, in *SessionReq, opts ..
 type EcommServer interface {
 	CreateProduct(context.Context, *ProductReq) (*ProductRes, error)
 	GetProduct(context.Context, *ProductReq) (*ProductRes, error)
-	ListProducts(context.Context, *ProductReq) (*ListProductRes, error)
+	ListProducts(context.Context, *ListProductsReq) (*ListProductRes, error)
+	SearchProducts(context.Context, *SearchProductsReq) (*SearchProductsRes, error)
 	UpdateProduct(context.Context, *ProductReq) (*ProductRes, error)
 	DeleteProduct(context.Context, *ProductReq) (*ProductRes, error)
 	CreateOrder(context.Context, *OrderReq) (*OrderRes, error)
 	GetOrder(context.Context, *OrderReq) (*OrderRes, error)
 	ListOrders(context.Context, *OrderReq) (*ListOrderRes, error)
+	UpdateOrderStatus(context.Context, *UpdateOrderStatusReq) (*OrderRes, error)
 	DeleteOrder(context.Context, *OrderReq) (*OrderRes, error)
 	CreateUser(context.Context, *UserReq) (*UserRes, error)
 	GetUser(context.Context, *UserReq) (*UserRes, error)
 	ListUsers(context.Context, *UserReq) (*ListUserRes, error)
 	UpdateUser(context.Context, *UserReq) (*UserRes, error)
 	DeleteUser(context.Context, *UserReq) (*UserRes, error)
+	VerifyEmail(context.Context, *UserReq) (*UserRes, error)
+	CreatePasswordReset(context.Context, *PasswordResetReq) (*UserRes, error)
+	ResetPassword(context.Context, *PasswordResetReq) (*UserRes, error)
+	VerifyCredentials(context.Context, *UserReq) (*UserRes, error)
+	ChangePassword(context.Context, *PasswordChangeReq) (*UserRes, error)
+	EnrollTOTP(context.Context, *TOTPReq) (*TOTPRes, error)
+	ConfirmTOTP(context.Context, *TOTPReq) (*TOTPRes, error)
+	VerifyTOTP(context.Context, *TOTPReq) (*TOTPRes, error)
+	DisableTOTP(context.Context, *TOTPReq) (*TOTPRes, error)
+	GetLoginAttempt(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error)
+	AddLoginFailure(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error)
+	RemoveLoginFailure(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error)
+	ResetLoginAttempts(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error)
+	ListRoles(context.Context, *RoleReq) (*ListRoleRes, error)
+	GetUserRoles(context.Context, *RoleReq) (*ListRoleRes, error)
+	AssignRole(context.Context, *RoleReq) (*ListRoleRes, error)
+	UnassignRole(context.Context, *RoleReq) (*ListRoleRes, error)
 	CreateSession(context.Context, *SessionReq) (*SessionRes, error)
 	GetSession(context.Context, *SessionReq) (*SessionRes, error)
+	RotateSession(context.Context, *RotateSessionReq) (*SessionRes, error)
+	ListSessions(context.Context, *SessionReq) (*ListSessionRes, error)
 	RevokeSession(context.Context, *SessionReq) (*SessionRes, error)
+	RevokeUserSessions(context.Context, *SessionReq) (*SessionRes, error)
 	DeleteSession(context.Context, *SessionReq) (*SessionRes, error)
 	mustEmbedUnimplementedEcommServer()
 }
@@ -289,9 +575,12 @@ func (UnimplementedEcommServer) CreateProduct(context.Context, *ProductReq) (*Pr
 func (UnimplementedEcommServer) GetProduct(context.Context, *ProductReq) (*ProductRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProduct not implemented")
 }
-func (UnimplementedEcommServer) ListProducts(context.Context, *ProductReq) (*ListProductRes, error) {
+func (UnimplementedEcommServer) ListProducts(context.Context, *ListProductsReq) (*ListProductRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
 }
+func (UnimplementedEcommServer) SearchProducts(context.Context, *SearchProductsReq) (*SearchProductsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchProducts not implemented")
+}
 func (UnimplementedEcommServer) UpdateProduct(context.Context, *ProductReq) (*ProductRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateProduct not implemented")
 }
@@ -307,6 +596,9 @@ func (UnimplementedEcommServer) GetOrder(context.Context, *OrderReq) (*OrderRes,
 func (UnimplementedEcommServer) ListOrders(context.Context, *OrderReq) (*ListOrderRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListOrders not implemented")
 }
+func (UnimplementedEcommServer) UpdateOrderStatus(context.Context, *UpdateOrderStatusReq) (*OrderRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateOrderStatus not implemented")
+}
 func (UnimplementedEcommServer) DeleteOrder(context.Context, *OrderReq) (*OrderRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrder not implemented")
 }
@@ -325,15 +617,75 @@ func (UnimplementedEcommServer) UpdateUser(context.Context, *UserReq) (*UserRes,
 func (UnimplementedEcommServer) DeleteUser(context.Context, *UserReq) (*UserRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedEcommServer) VerifyEmail(context.Context, *UserReq) (*UserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
+}
+func (UnimplementedEcommServer) CreatePasswordReset(context.Context, *PasswordResetReq) (*UserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePasswordReset not implemented")
+}
+func (UnimplementedEcommServer) ResetPassword(context.Context, *PasswordResetReq) (*UserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedEcommServer) VerifyCredentials(context.Context, *UserReq) (*UserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyCredentials not implemented")
+}
+func (UnimplementedEcommServer) ChangePassword(context.Context, *PasswordChangeReq) (*UserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedEcommServer) EnrollTOTP(context.Context, *TOTPReq) (*TOTPRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTOTP not implemented")
+}
+func (UnimplementedEcommServer) ConfirmTOTP(context.Context, *TOTPReq) (*TOTPRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTOTP not implemented")
+}
+func (UnimplementedEcommServer) VerifyTOTP(context.Context, *TOTPReq) (*TOTPRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyTOTP not implemented")
+}
+func (UnimplementedEcommServer) DisableTOTP(context.Context, *TOTPReq) (*TOTPRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTOTP not implemented")
+}
+func (UnimplementedEcommServer) GetLoginAttempt(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLoginAttempt not implemented")
+}
+func (UnimplementedEcommServer) AddLoginFailure(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddLoginFailure not implemented")
+}
+func (UnimplementedEcommServer) RemoveLoginFailure(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveLoginFailure not implemented")
+}
+func (UnimplementedEcommServer) ResetLoginAttempts(context.Context, *LoginAttemptReq) (*LoginAttemptRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetLoginAttempts not implemented")
+}
+func (UnimplementedEcommServer) ListRoles(context.Context, *RoleReq) (*ListRoleRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRoles not implemented")
+}
+func (UnimplementedEcommServer) GetUserRoles(context.Context, *RoleReq) (*ListRoleRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserRoles not implemented")
+}
+func (UnimplementedEcommServer) AssignRole(context.Context, *RoleReq) (*ListRoleRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignRole not implemented")
+}
+func (UnimplementedEcommServer) UnassignRole(context.Context, *RoleReq) (*ListRoleRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnassignRole not implemented")
+}
 func (UnimplementedEcommServer) CreateSession(context.Context, *SessionReq) (*SessionRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
 }
 func (UnimplementedEcommServer) GetSession(context.Context, *SessionReq) (*SessionRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSession not implemented")
 }
+func (UnimplementedEcommServer) RotateSession(context.Context, *RotateSessionReq) (*SessionRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateSession not implemented")
+}
+func (UnimplementedEcommServer) ListSessions(context.Context, *SessionReq) (*ListSessionRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
 func (UnimplementedEcommServer) RevokeSession(context.Context, *SessionReq) (*SessionRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
 }
+func (UnimplementedEcommServer) RevokeUserSessions(context.Context, *SessionReq) (*SessionRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeUserSessions not implemented")
+}
 func (UnimplementedEcommServer) DeleteSession(context.Context, *SessionReq) (*SessionRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteSession not implemented")
 }
@@ -395,7 +747,7 @@ func _Ecomm_GetProduct_Handler(srv interface{}, ctx context.Context, dec func(in
 }
 
 func _Ecomm_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ProductReq)
+	in := new(ListProductsReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -407,7 +759,25 @@ func _Ecomm_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(
 		FullMethod: Ecomm_ListProducts_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EcommServer).ListProducts(ctx, req.(*ProductReq))
+		return srv.(EcommServer).ListProducts(ctx, req.(*ListProductsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_SearchProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchProductsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).SearchProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_SearchProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).SearchProducts(ctx, req.(*SearchProductsReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -502,6 +872,24 @@ func _Ecomm_ListOrders_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ecomm_UpdateOrderStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOrderStatusReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).UpdateOrderStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_UpdateOrderStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).UpdateOrderStatus(ctx, req.(*UpdateOrderStatusReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Ecomm_DeleteOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(OrderReq)
 	if err := dec(in); err != nil {
@@ -610,101 +998,465 @@ func _Ecomm_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Ecomm_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SessionReq)
+func _Ecomm_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EcommServer).CreateSession(ctx, in)
+		return srv.(EcommServer).VerifyEmail(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Ecomm_CreateSession_FullMethodName,
+		FullMethod: Ecomm_VerifyEmail_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EcommServer).CreateSession(ctx, req.(*SessionReq))
+		return srv.(EcommServer).VerifyEmail(ctx, req.(*UserReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Ecomm_GetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SessionReq)
+func _Ecomm_CreatePasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordResetReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EcommServer).GetSession(ctx, in)
+		return srv.(EcommServer).CreatePasswordReset(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Ecomm_GetSession_FullMethodName,
+		FullMethod: Ecomm_CreatePasswordReset_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EcommServer).GetSession(ctx, req.(*SessionReq))
+		return srv.(EcommServer).CreatePasswordReset(ctx, req.(*PasswordResetReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Ecomm_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SessionReq)
+func _Ecomm_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordResetReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EcommServer).RevokeSession(ctx, in)
+		return srv.(EcommServer).ResetPassword(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Ecomm_RevokeSession_FullMethodName,
+		FullMethod: Ecomm_ResetPassword_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EcommServer).RevokeSession(ctx, req.(*SessionReq))
+		return srv.(EcommServer).ResetPassword(ctx, req.(*PasswordResetReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Ecomm_DeleteSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SessionReq)
+func _Ecomm_VerifyCredentials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EcommServer).DeleteSession(ctx, in)
+		return srv.(EcommServer).VerifyCredentials(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Ecomm_DeleteSession_FullMethodName,
+		FullMethod: Ecomm_VerifyCredentials_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EcommServer).DeleteSession(ctx, req.(*SessionReq))
+		return srv.(EcommServer).VerifyCredentials(ctx, req.(*UserReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// Ecomm_ServiceDesc is the grpc.ServiceDesc for Ecomm service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var Ecomm_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "pb.ecomm",
-	HandlerType: (*EcommServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CreateProduct",
-			Handler:    _Ecomm_CreateProduct_Handler,
-		},
-		{
-			MethodName: "GetProduct",
-			Handler:    _Ecomm_GetProduct_Handler,
-		},
-		{
-			MethodName: "ListProducts",
-			Handler:    _Ecomm_ListProducts_Handler,
-		},
-		{
-			MethodName: "UpdateProduct",
-			Handler:    _Ecomm_UpdateProduct_Handler,
-		},
+func _Ecomm_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordChangeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).ChangePassword(ctx, req.(*PasswordChangeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_EnrollTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).EnrollTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_EnrollTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).EnrollTOTP(ctx, req.(*TOTPReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_ConfirmTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).ConfirmTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_ConfirmTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).ConfirmTOTP(ctx, req.(*TOTPReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_VerifyTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).VerifyTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_VerifyTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).VerifyTOTP(ctx, req.(*TOTPReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_DisableTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).DisableTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_DisableTOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).DisableTOTP(ctx, req.(*TOTPReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_GetLoginAttempt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginAttemptReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).GetLoginAttempt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_GetLoginAttempt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).GetLoginAttempt(ctx, req.(*LoginAttemptReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_AddLoginFailure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginAttemptReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).AddLoginFailure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_AddLoginFailure_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).AddLoginFailure(ctx, req.(*LoginAttemptReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_RemoveLoginFailure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginAttemptReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).RemoveLoginFailure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_RemoveLoginFailure_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).RemoveLoginFailure(ctx, req.(*LoginAttemptReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_ResetLoginAttempts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginAttemptReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).ResetLoginAttempts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_ResetLoginAttempts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).ResetLoginAttempts(ctx, req.(*LoginAttemptReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_ListRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).ListRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_ListRoles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).ListRoles(ctx, req.(*RoleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_GetUserRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).GetUserRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_GetUserRoles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).GetUserRoles(ctx, req.(*RoleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_AssignRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).AssignRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_AssignRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).AssignRole(ctx, req.(*RoleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_UnassignRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).UnassignRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_UnassignRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).UnassignRole(ctx, req.(*RoleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).CreateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_CreateSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).CreateSession(ctx, req.(*SessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_GetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).GetSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_GetSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).GetSession(ctx, req.(*SessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_RotateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateSessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).RotateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_RotateSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).RotateSession(ctx, req.(*RotateSessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).ListSessions(ctx, req.(*SessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_RevokeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).RevokeSession(ctx, req.(*SessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_RevokeUserSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).RevokeUserSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_RevokeUserSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).RevokeUserSessions(ctx, req.(*SessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ecomm_DeleteSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EcommServer).DeleteSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ecomm_DeleteSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EcommServer).DeleteSession(ctx, req.(*SessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Ecomm_ServiceDesc is the grpc.ServiceDesc for Ecomm service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ecomm_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.ecomm",
+	HandlerType: (*EcommServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateProduct",
+			Handler:    _Ecomm_CreateProduct_Handler,
+		},
+		{
+			MethodName: "GetProduct",
+			Handler:    _Ecomm_GetProduct_Handler,
+		},
+		{
+			MethodName: "ListProducts",
+			Handler:    _Ecomm_ListProducts_Handler,
+		},
+		{
+			MethodName: "SearchProducts",
+			Handler:    _Ecomm_SearchProducts_Handler,
+		},
+		{
+			MethodName: "UpdateProduct",
+			Handler:    _Ecomm_UpdateProduct_Handler,
+		},
 		{
 			MethodName: "DeleteProduct",
 			Handler:    _Ecomm_DeleteProduct_Handler,
@@ -721,6 +1473,10 @@ var Ecomm_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListOrders",
 			Handler:    _Ecomm_ListOrders_Handler,
 		},
+		{
+			MethodName: "UpdateOrderStatus",
+			Handler:    _Ecomm_UpdateOrderStatus_Handler,
+		},
 		{
 			MethodName: "DeleteOrder",
 			Handler:    _Ecomm_DeleteOrder_Handler,
@@ -745,6 +1501,74 @@ var Ecomm_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _Ecomm_DeleteUser_Handler,
 		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _Ecomm_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "CreatePasswordReset",
+			Handler:    _Ecomm_CreatePasswordReset_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _Ecomm_ResetPassword_Handler,
+		},
+		{
+			MethodName: "VerifyCredentials",
+			Handler:    _Ecomm_VerifyCredentials_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _Ecomm_ChangePassword_Handler,
+		},
+		{
+			MethodName: "EnrollTOTP",
+			Handler:    _Ecomm_EnrollTOTP_Handler,
+		},
+		{
+			MethodName: "ConfirmTOTP",
+			Handler:    _Ecomm_ConfirmTOTP_Handler,
+		},
+		{
+			MethodName: "VerifyTOTP",
+			Handler:    _Ecomm_VerifyTOTP_Handler,
+		},
+		{
+			MethodName: "DisableTOTP",
+			Handler:    _Ecomm_DisableTOTP_Handler,
+		},
+		{
+			MethodName: "GetLoginAttempt",
+			Handler:    _Ecomm_GetLoginAttempt_Handler,
+		},
+		{
+			MethodName: "AddLoginFailure",
+			Handler:    _Ecomm_AddLoginFailure_Handler,
+		},
+		{
+			MethodName: "RemoveLoginFailure",
+			Handler:    _Ecomm_RemoveLoginFailure_Handler,
+		},
+		{
+			MethodName: "ResetLoginAttempts",
+			Handler:    _Ecomm_ResetLoginAttempts_Handler,
+		},
+		{
+			MethodName: "ListRoles",
+			Handler:    _Ecomm_ListRoles_Handler,
+		},
+		{
+			MethodName: "GetUserRoles",
+			Handler:    _Ecomm_GetUserRoles_Handler,
+		},
+		{
+			MethodName: "AssignRole",
+			Handler:    _Ecomm_AssignRole_Handler,
+		},
+		{
+			MethodName: "UnassignRole",
+			Handler:    _Ecomm_UnassignRole_Handler,
+		},
 		{
 			MethodName: "CreateSession",
 			Handler:    _Ecomm_CreateSession_Handler,
@@ -753,10 +1577,22 @@ var Ecomm_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetSession",
 			Handler:    _Ecomm_GetSession_Handler,
 		},
+		{
+			MethodName: "RotateSession",
+			Handler:    _Ecomm_RotateSession_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _Ecomm_ListSessions_Handler,
+		},
 		{
 			MethodName: "RevokeSession",
 			Handler:    _Ecomm_RevokeSession_Handler,
 		},
+		{
+			MethodName: "RevokeUserSessions",
+			Handler:    _Ecomm_RevokeUserSessions_Handler,
+		},
 		{
 			MethodName: "DeleteSession",
 			Handler:    _Ecomm_DeleteSession_Handler,
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/ecomm-grpc/storer"
+	"davidHwang/ecomm/rbac"
+	"davidHwang/ecomm/token"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationKey = "authorization"
+
+// * Policy - кто может вызывать RPC
+type Policy int
+
+const (
+	// * только администратор (и сервис ecomm-api с сервисным токеном), по умолчанию для неизвестных методов
+	PolicyAdmin Policy = iota
+	// * любой пользователь с действительным токеном доступа
+	PolicyAuthenticated
+	// * без токена
+	PolicyPublic
+	// * только сервис ecomm-api с сервисным токеном, администратору недоступно
+	PolicyService
+)
+
+// * DefaultSessionCacheTTL - сколько хранится состояние сессии для проверки токенов доступа
+const DefaultSessionCacheTTL = 30 * time.Second
+
+// * политики доступа к методам pb.EcommServer.
+// * Для PolicyAuthenticated методы дополнительно проверяют, что пользователь работает со своими данными
+var rpcPolicies = map[string]Policy{
+	pb.Ecomm_CreateProduct_FullMethodName:  PolicyAdmin,
+	pb.Ecomm_GetProduct_FullMethodName:     PolicyPublic,
+	pb.Ecomm_ListProducts_FullMethodName:   PolicyPublic,
+	pb.Ecomm_SearchProducts_FullMethodName: PolicyPublic,
+	pb.Ecomm_UpdateProduct_FullMethodName:  PolicyAdmin,
+	pb.Ecomm_DeleteProduct_FullMethodName:  PolicyAdmin,
+
+	pb.Ecomm_CreateOrder_FullMethodName:       PolicyAuthenticated,
+	pb.Ecomm_GetOrder_FullMethodName:          PolicyAuthenticated,
+	pb.Ecomm_ListOrders_FullMethodName:        PolicyAdmin,
+	pb.Ecomm_UpdateOrderStatus_FullMethodName: PolicyAdmin,
+	pb.Ecomm_DeleteOrder_FullMethodName:       PolicyAuthenticated,
+
+	pb.Ecomm_CreateUser_FullMethodName: PolicyPublic,
+	pb.Ecomm_GetUser_FullMethodName:    PolicyAdmin,
+	pb.Ecomm_ListUsers_FullMethodName:  PolicyAdmin,
+	pb.Ecomm_UpdateUser_FullMethodName: PolicyAuthenticated,
+	pb.Ecomm_DeleteUser_FullMethodName: PolicyAdmin,
+	//* токен подтверждения проверяет ecomm-api и вызывает метод с сервисным токеном
+	pb.Ecomm_VerifyEmail_FullMethodName: PolicyAdmin,
+	//* токены сброса пароля выдает и проверяет только ecomm-api
+	pb.Ecomm_CreatePasswordReset_FullMethodName: PolicyService,
+	pb.Ecomm_ResetPassword_FullMethodName:       PolicyService,
+	//* проверку пароля при входе и смене пароля вызывает только ecomm-api
+	pb.Ecomm_VerifyCredentials_FullMethodName: PolicyService,
+	pb.Ecomm_ChangePassword_FullMethodName:    PolicyService,
+
+	pb.Ecomm_EnrollTOTP_FullMethodName:  PolicyAuthenticated,
+	pb.Ecomm_ConfirmTOTP_FullMethodName: PolicyAuthenticated,
+	pb.Ecomm_DisableTOTP_FullMethodName: PolicyAuthenticated,
+	//* второй шаг входа выполняет ecomm-api до выдачи токена доступа
+	pb.Ecomm_VerifyTOTP_FullMethodName: PolicyService,
+
+	//* счетчики попыток входа ведет только ecomm-api
+	pb.Ecomm_GetLoginAttempt_FullMethodName:    PolicyService,
+	pb.Ecomm_AddLoginFailure_FullMethodName:    PolicyService,
+	pb.Ecomm_RemoveLoginFailure_FullMethodName: PolicyService,
+	pb.Ecomm_ResetLoginAttempts_FullMethodName: PolicyService,
+
+	pb.Ecomm_ListRoles_FullMethodName:    PolicyAdmin,
+	pb.Ecomm_GetUserRoles_FullMethodName: PolicyAdmin,
+	pb.Ecomm_AssignRole_FullMethodName:   PolicyAdmin,
+	pb.Ecomm_UnassignRole_FullMethodName: PolicyAdmin,
+
+	pb.Ecomm_CreateSession_FullMethodName:      PolicyService,
+	pb.Ecomm_GetSession_FullMethodName:         PolicyService,
+	pb.Ecomm_RotateSession_FullMethodName:      PolicyService,
+	pb.Ecomm_ListSessions_FullMethodName:       PolicyAuthenticated,
+	pb.Ecomm_RevokeSession_FullMethodName:      PolicyAuthenticated,
+	pb.Ecomm_RevokeUserSessions_FullMethodName: PolicyAuthenticated,
+	pb.Ecomm_DeleteSession_FullMethodName:      PolicyAuthenticated,
+}
+
+// * право, с которым метод PolicyAdmin доступен не только администратору
+var rpcPermissions = map[string]string{
+	pb.Ecomm_CreateProduct_FullMethodName: rbac.PermProductsWrite,
+	pb.Ecomm_UpdateProduct_FullMethodName: rbac.PermProductsWrite,
+	pb.Ecomm_DeleteProduct_FullMethodName: rbac.PermProductsWrite,
+
+	pb.Ecomm_ListOrders_FullMethodName:        rbac.PermOrdersRead,
+	pb.Ecomm_UpdateOrderStatus_FullMethodName: rbac.PermOrdersWrite,
+
+	pb.Ecomm_GetUser_FullMethodName:     rbac.PermUsersRead,
+	pb.Ecomm_ListUsers_FullMethodName:   rbac.PermUsersRead,
+	pb.Ecomm_DeleteUser_FullMethodName:  rbac.PermUsersWrite,
+	pb.Ecomm_VerifyEmail_FullMethodName: rbac.PermUsersWrite,
+
+	pb.Ecomm_ListRoles_FullMethodName:    rbac.PermUsersRead,
+	pb.Ecomm_GetUserRoles_FullMethodName: rbac.PermUsersRead,
+	pb.Ecomm_AssignRole_FullMethodName:   rbac.PermRolesWrite,
+	pb.Ecomm_UnassignRole_FullMethodName: rbac.PermRolesWrite,
+}
+
+func policyFor(method string) Policy {
+	if p, ok := rpcPolicies[method]; ok {
+		return p
+	}
+
+	return PolicyAdmin
+}
+
+// * SessionChecker проверяет, не отозвана ли сессия токена доступа
+type SessionChecker interface {
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+// * NewSessionChecker - проверка сессий по хранилищу с кэшем на ttl.
+// * Удаленная сессия (выход из системы) считается отозванной
+func NewSessionChecker(st storer.Storer, ttl time.Duration) *token.SessionCache {
+	return token.NewSessionCache(ttl, func(ctx context.Context, sessionID string) (bool, error) {
+		session, err := st.GetSession(ctx, sessionID)
+
+		if errors.Is(err, storer.ErrNotFound) {
+			return true, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		return session.IsRevoked, nil
+	})
+}
+
+// * AuthInterceptor проверяет bearer токен из metadata "authorization" и политику метода.
+// * Токен пользователя принимается только как токен доступа действующей сессии,
+// * токен обновления (без sid) и токены отозванных сессий отклоняются.
+// * Проверенные claims доступны в обработчике через ClaimsFromContext
+func AuthInterceptor(tokenMaker token.Maker, sessions SessionChecker) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		policy := policyFor(info.FullMethod)
+
+		tokenStr, err := bearerToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if tokenStr == "" {
+			if policy != PolicyPublic {
+				return nil, status.Error(codes.Unauthenticated, "authorization token is missing")
+			}
+
+			return handler(ctx, req)
+		}
+
+		claims, err := tokenMaker.VerifyToken(tokenStr)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+		}
+
+		if !claims.IsService() {
+			if err := checkSession(ctx, sessions, claims); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case policy == PolicyService && !claims.IsService():
+			return nil, status.Error(codes.PermissionDenied, "method is only available to ecomm-api")
+		case policy == PolicyAdmin && !claims.HasPermission(rpcPermissions[info.FullMethod]):
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		return handler(contextWithClaims(ctx, claims), req)
+	}
+}
+
+func checkSession(ctx context.Context, sessions SessionChecker, claims *token.UserClaims) error {
+	if claims.SessionID == "" {
+		return status.Error(codes.Unauthenticated, "invalid token: not an access token")
+	}
+
+	revoked, err := sessions.IsRevoked(ctx, claims.SessionID)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	if revoked {
+		return toStatusError(storer.ErrSessionRevoked)
+	}
+
+	return nil
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) != 2 || fields[0] != "Bearer" {
+		return "", status.Error(codes.Unauthenticated, "invalid authorization metadata")
+	}
+
+	return fields[1], nil
+}
+
+type claimsKey struct{}
+
+func contextWithClaims(ctx context.Context, claims *token.UserClaims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func ClaimsFromContext(ctx context.Context) (*token.UserClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*token.UserClaims)
+	return claims, ok
+}
+
+// * суперпользователь (администратор или роль superadmin) работает с данными любых пользователей
+func isSuperuser(claims *token.UserClaims) bool {
+	return claims.HasPermission(rbac.PermAll)
+}
+
+// * пользователь может работать только со своими данными, администратор - с любыми
+func authorizeUser(ctx context.Context, userID int64) error {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authorization token is missing")
+	}
+
+	if isSuperuser(claims) || claims.ID == userID {
+		return nil
+	}
+
+	return status.Error(codes.PermissionDenied, "access to another user's data is denied")
+}
+
+func authorizeEmail(ctx context.Context, email string) error {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authorization token is missing")
+	}
+
+	if isSuperuser(claims) || claims.Email == email {
+		return nil
+	}
+
+	return status.Error(codes.PermissionDenied, "access to another user's data is denied")
+}
+
+// * назначить права администратора может только администратор
+func authorizeAdminFlag(ctx context.Context, isAdmin bool) error {
+	if !isAdmin {
+		return nil
+	}
+
+	if claims, ok := ClaimsFromContext(ctx); ok && isSuperuser(claims) {
+		return nil
+	}
+
+	return status.Error(codes.PermissionDenied, "only an admin can grant admin rights")
+}
+
+// * нельзя выдать права, которых нет у самого вызывающего
+func authorizePermissions(ctx context.Context, perms []string) error {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authorization token is missing")
+	}
+
+	for _, p := range perms {
+		if !claims.HasPermission(p) {
+			return status.Errorf(codes.PermissionDenied, "permission %q can not be granted by this user", p)
+		}
+	}
+
+	return nil
+}
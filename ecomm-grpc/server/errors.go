@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/storer"
+	"errors"
+	"log"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const errorDomain = "ecomm.storer"
+
+// * причины ошибок для errdetails.ErrorInfo
+const (
+	reasonNotFound          = "NOT_FOUND"
+	reasonDuplicateEmail    = "DUPLICATE_EMAIL"
+	reasonForeignKey        = "FOREIGN_KEY_VIOLATION"
+	reasonConflict          = "CONFLICT"
+	reasonInsufficientStock = "INSUFFICIENT_STOCK"
+	reasonInvalidTransition = "INVALID_STATUS_TRANSITION"
+	reasonInvalidArgument   = "INVALID_ARGUMENT"
+	reasonValidationFailed  = "VALIDATION_FAILED"
+	reasonSessionRevoked    = "SESSION_REVOKED"
+	reasonRefreshReused     = "REFRESH_TOKEN_REUSED"
+	reasonEmailVerified     = "EMAIL_ALREADY_VERIFIED"
+	reasonTOTPEnabled       = "TOTP_ALREADY_ENABLED"
+	reasonInvalidMFACode    = "INVALID_MFA_CODE"
+	reasonInvalidCreds      = "INVALID_CREDENTIALS"
+)
+
+// * toStatusError переводит ошибку хранилища в gRPC статус с кодом и errdetails.
+// * Ошибки, которые уже являются статусом, возвращаются как есть,
+// * нераспознанные логируются и превращаются в codes.Internal без подробностей
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	resource := "record"
+
+	var se *storer.Error
+	if errors.As(err, &se) && se.Resource != "" {
+		resource = se.Resource
+	}
+
+	var stockErr *storer.InsufficientStockError
+
+	switch {
+	case errors.Is(err, storer.ErrNotFound):
+		return withDetails(codes.NotFound, resource+" not found", errorInfo(reasonNotFound, resource),
+			&errdetails.ResourceInfo{ResourceType: resource, Description: err.Error()})
+	case errors.Is(err, storer.ErrDuplicateEmail):
+		return withDetails(codes.AlreadyExists, "email is already registered", errorInfo(reasonDuplicateEmail, resource),
+			&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "email", Description: "email is already registered"}}})
+	case errors.Is(err, storer.ErrForeignKey):
+		return withDetails(codes.FailedPrecondition, resource+" references or is referenced by another record", errorInfo(reasonForeignKey, resource),
+			&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{{Type: reasonForeignKey, Subject: resource, Description: err.Error()}}})
+	case errors.Is(err, storer.ErrConflict):
+		return withDetails(codes.Aborted, resource+" was modified concurrently, retry the request", errorInfo(reasonConflict, resource))
+	case errors.As(err, &stockErr):
+		return withDetails(codes.FailedPrecondition, stockErr.Error(), errorInfo(reasonInsufficientStock, "product"),
+			&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{{Type: reasonInsufficientStock, Subject: "product", Description: stockErr.Error()}}})
+	case errors.Is(err, storer.ErrInvalidStatusTransition):
+		return withDetails(codes.FailedPrecondition, err.Error(), errorInfo(reasonInvalidTransition, "order"))
+	case errors.Is(err, storer.ErrInvalidCursor), errors.Is(err, storer.ErrEmptySearchQuery):
+		return withDetails(codes.InvalidArgument, err.Error(), errorInfo(reasonInvalidArgument, ""))
+	case errors.Is(err, storer.ErrRefreshTokenReused):
+		return withDetails(codes.Unauthenticated, "refresh token was already used, all sessions of this login are revoked", errorInfo(reasonRefreshReused, "session"))
+	case errors.Is(err, storer.ErrSessionRevoked):
+		return withDetails(codes.Unauthenticated, "session revoked", errorInfo(reasonSessionRevoked, "session"))
+	case errors.Is(err, storer.ErrEmailAlreadyVerified):
+		return withDetails(codes.FailedPrecondition, "email is already verified", errorInfo(reasonEmailVerified, "user"))
+	case errors.Is(err, storer.ErrTOTPAlreadyEnabled):
+		return withDetails(codes.FailedPrecondition, "two-factor authentication is already enabled", errorInfo(reasonTOTPEnabled, "totp"))
+	case errors.Is(err, storer.ErrInvalidMFACode):
+		return withDetails(codes.InvalidArgument, "invalid or already used code", errorInfo(reasonInvalidMFACode, "totp"))
+	case errors.Is(err, storer.ErrInvalidCredentials):
+		return withDetails(codes.Unauthenticated, "invalid email or password", errorInfo(reasonInvalidCreds, "user"))
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	log.Printf("internal storer error: %v", err)
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+func errorInfo(reason, resource string) *errdetails.ErrorInfo {
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+
+	if resource != "" {
+		info.Metadata = map[string]string{"resource": resource}
+	}
+
+	return info
+}
+
+func withDetails(code codes.Code, msg string, details ...protoadapt.MessageV1) error {
+	st, err := status.New(code, msg).WithDetails(details...)
+
+	if err != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/rpcmeta"
+	"davidHwang/ecomm/validate"
+	"log"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// * RequestInfoInterceptor кладет в контекст request ID и пользователя из metadata
+// * (см. rpcmeta.FromContext) и логирует каждый вызов с его результатом и длительностью
+func RequestInfoInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ri := rpcmeta.FromIncomingContext(ctx)
+		start := time.Now()
+
+		res, err := handler(rpcmeta.NewContext(ctx, ri), req)
+
+		log.Printf("%s request_id=%q user_id=%d code=%s duration=%s",
+			info.FullMethod, ri.RequestID, ri.UserID, status.Code(err), time.Since(start))
+
+		return res, err
+	}
+}
+
+// * правила валидации запросов по методам pb.EcommServer (те же, что и в ecomm-api)
+var methodRules = map[string][]validate.Rules{
+	pb.Ecomm_CreateProduct_FullMethodName:       {validate.ProductCreate},
+	pb.Ecomm_UpdateProduct_FullMethodName:       {validate.ProductUpdate},
+	pb.Ecomm_CreateOrder_FullMethodName:         {validate.Order},
+	pb.Ecomm_CreateUser_FullMethodName:          {validate.User, validate.HashablePassword},
+	pb.Ecomm_UpdateUser_FullMethodName:          {validate.UserUpdate},
+	pb.Ecomm_CreatePasswordReset_FullMethodName: {validate.PasswordResetCreate},
+	pb.Ecomm_ResetPassword_FullMethodName:       {validate.PasswordReset},
+	pb.Ecomm_VerifyCredentials_FullMethodName:   {validate.Login},
+	pb.Ecomm_ChangePassword_FullMethodName:      {validate.PasswordChange},
+	pb.Ecomm_EnrollTOTP_FullMethodName:          {validate.TOTPEnrollment},
+	pb.Ecomm_GetLoginAttempt_FullMethodName:     {validate.LoginAttempt},
+	pb.Ecomm_AddLoginFailure_FullMethodName:     {validate.LoginAttemptFailure},
+	pb.Ecomm_RemoveLoginFailure_FullMethodName:  {validate.LoginAttemptRemoval},
+	pb.Ecomm_ResetLoginAttempts_FullMethodName:  {validate.LoginAttempt},
+	pb.Ecomm_AssignRole_FullMethodName:          {validate.RoleAssignment},
+	pb.Ecomm_UnassignRole_FullMethodName:        {validate.RoleAssignment},
+}
+
+// * ValidationInterceptor отклоняет запросы, не прошедшие валидацию,
+// * с codes.InvalidArgument и списком полей в errdetails.BadRequest
+func ValidationInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		rules, ok := methodRules[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		m, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		if errs := validate.Message(m, rules...); len(errs) > 0 {
+			return nil, validationError(errs)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func validationError(errs validate.Errors) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(errs))
+	for _, fe := range errs {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+	}
+
+	return withDetails(codes.InvalidArgument, "request validation failed: "+errs.Error(),
+		errorInfo(reasonValidationFailed, ""),
+		&errdetails.BadRequest{FieldViolations: violations})
+}
@@ -3,7 +3,7 @@ package server
 import (
 	"davidHwang/ecomm/ecomm-grpc/pb"
 	"davidHwang/ecomm/ecomm-grpc/storer"
-	"davidHwang/ecomm/util"
+	"davidHwang/ecomm/rbac"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -44,6 +44,34 @@ func toPBProductRes(p *storer.Product) *pb.ProductRes {
 
 }
 
+func toPBProductSearchHit(h *storer.ProductSearchHit) *pb.ProductSearchHit {
+	return &pb.ProductSearchHit{
+		Product:   toPBProductRes(&h.Product),
+		Relevance: h.Relevance,
+		Snippet:   h.Snippet,
+	}
+}
+
+var productSortFields = map[pb.ProductSortField]storer.ProductSortField{
+	pb.ProductSortField_SORT_BY_ID:         storer.ProductSortID,
+	pb.ProductSortField_SORT_BY_PRICE:      storer.ProductSortPrice,
+	pb.ProductSortField_SORT_BY_RATING:     storer.ProductSortRating,
+	pb.ProductSortField_SORT_BY_CREATED_AT: storer.ProductSortCreatedAt,
+}
+
+func toStorerListProductsParams(p *pb.ListProductsReq) storer.ListProductsParams {
+	return storer.ListProductsParams{
+		PageSize:    int(p.GetPageSize()),
+		Cursor:      p.GetCursor(),
+		Category:    p.GetCategory(),
+		MinPrice:    roundCents(float64(p.GetMinPrice())),
+		MaxPrice:    roundCents(float64(p.GetMaxPrice())),
+		InStockOnly: p.GetInStockOnly(),
+		SortBy:      productSortFields[p.GetSortBy()],
+		SortDesc:    p.GetSortDesc(),
+	}
+}
+
 func patchProductReq(product *storer.Product, p *pb.ProductReq) {
 	if p.Name != "" {
 		product.Name = p.Name
@@ -83,48 +111,63 @@ func toTimePtr(t time.Time) *time.Time {
 	return &t
 }
 
-func toStorerOrder(o *pb.OrderReq) *storer.Order {
-	return &storer.Order{
+func toPBOrderRes(o *storer.Order) *pb.OrderRes {
+	res := &pb.OrderRes{
+		Id:            o.ID,
+		Items:         toPBOrderItems(o.Items),
 		PaymentMethod: o.PaymentMethod,
 		TaxPrice:      o.TaxPrice,
 		ShippingPrice: o.ShippingPrice,
 		TotalPrice:    o.TotalPrice,
-		Items:         toStorerOrderItems(o.Items),
+		UserId:        o.UserID,
+		Status:        toPBOrderStatus(o.Status),
+		Breakdown:     toPBPriceBreakdown(o),
+		CreatedAt:     timestamppb.New(o.CreatedAt),
 	}
-}
 
-func toStorerOrderItems(items []*pb.OrderItem) []storer.OrderItem {
-	var res []storer.OrderItem
-
-	for _, i := range items {
-		res = append(res, storer.OrderItem{
-			Name:      i.Name,
-			Quantity:  i.Quantity,
-			Image:     i.Image,
-			Price:     i.Price,
-			ProductID: i.ProductId,
-		})
+	if o.UpdatedAt != nil {
+		res.UpdatedAt = timestamppb.New(*o.UpdatedAt)
 	}
+
 	return res
 }
 
+func toPBPriceBreakdown(o *storer.Order) *pb.PriceBreakdown {
+	var itemsPrice float64
 
-func toPBOrderRes(o *storer.Order) *pb.OrderRes {
-	res := &pb.OrderRes{
-		Id:            o.ID,
-		Items:         toPBOrderItems(o.Items),
-		PaymentMethod: o.PaymentMethod,
+	for _, i := range o.Items {
+		itemsPrice += float64(i.Price) * float64(i.Quantity)
+	}
+
+	return &pb.PriceBreakdown{
+		ItemsPrice:    float32(roundCents(itemsPrice)),
 		TaxPrice:      o.TaxPrice,
 		ShippingPrice: o.ShippingPrice,
 		TotalPrice:    o.TotalPrice,
-		CreatedAt:     timestamppb.New(o.CreatedAt),
 	}
+}
 
-	if o.UpdatedAt != nil {
-		res.UpdatedAt = timestamppb.New(*o.UpdatedAt)
+var orderStatuses = map[storer.OrderStatus]pb.OrderStatus{
+	storer.OrderStatusPending:   pb.OrderStatus_PENDING,
+	storer.OrderStatusPaid:      pb.OrderStatus_PAID,
+	storer.OrderStatusShipped:   pb.OrderStatus_SHIPPED,
+	storer.OrderStatusDelivered: pb.OrderStatus_DELIVERED,
+	storer.OrderStatusCancelled: pb.OrderStatus_CANCELLED,
+	storer.OrderStatusRefunded:  pb.OrderStatus_REFUNDED,
+}
+
+func toPBOrderStatus(s storer.OrderStatus) pb.OrderStatus {
+	return orderStatuses[s]
+}
+
+func toStorerOrderStatus(s pb.OrderStatus) storer.OrderStatus {
+	for ss, ps := range orderStatuses {
+		if ps == s {
+			return ss
+		}
 	}
 
-	return res
+	return ""
 }
 
 func toPBOrderItems(o []storer.OrderItem) []*pb.OrderItem {
@@ -155,35 +198,68 @@ func toStorerUser(u *pb.UserReq) *storer.User {
 
 func toPBUserRes(u *storer.User) *pb.UserRes {
 	res := &pb.UserRes{
-		Id:           u.ID,
-		Name:         u.Name,
-		Email:        u.Email,
-		Password:     u.Password,
-		IsAdmin:      u.IsAdmin,
+		Id:      u.ID,
+		Name:    u.Name,
+		Email:   u.Email,
+		IsAdmin: u.IsAdmin,
+	}
+
+	if u.EmailVerifiedAt != nil {
+		res.EmailVerifiedAt = timestamppb.New(*u.EmailVerifiedAt)
+	}
+
+	return res
+}
+
+func toPBLoginAttemptRes(a *storer.LoginAttempt) *pb.LoginAttemptRes {
+	return &pb.LoginAttemptRes{
+		Key:           a.Key,
+		Failures:      a.Failures,
+		LastFailureAt: timestamppb.New(a.LastFailureAt),
 	}
+}
 
+func toPBRoleRes(r *storer.Role) *pb.RoleRes {
+	return &pb.RoleRes{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Permissions,
+	}
+}
+
+func toPBListRoleRes(roles []*storer.Role) *pb.ListRoleRes {
+	res := &pb.ListRoleRes{}
+	for _, r := range roles {
+		res.Roles = append(res.Roles, toPBRoleRes(r))
+	}
 
 	return res
 }
 
+// * имена ролей и объединенные права пользователя
+func rolesAndPermissions(roles []*storer.Role) ([]string, []string) {
+	names := make([]string, 0, len(roles))
+	sets := make([][]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.Name)
+		sets = append(sets, r.Permissions)
+	}
+
+	return names, rbac.Merge(sets...)
+}
+
 func patchUserReq(user *storer.User, u *pb.UserReq) {
 	if u.Name != "" {
 		user.Name = u.Name
 	}
 
-	if u.Email != "" {
+	//* новый email нужно подтвердить заново
+	if u.Email != "" && u.Email != user.Email {
 		user.Email = u.Email
+		user.EmailVerifiedAt = nil
 	}
 
-	if u.Password != "" {
-		hashed, err := util.HashPassword(u.Password)
-		if err != nil {
-			panic(err)
-		}
-
-		user.Password = hashed
-	}
-
+	//* пароль меняется только через ChangePassword с проверкой текущего пароля
 	if u.IsAdmin {
 		user.IsAdmin = u.IsAdmin
 	}
@@ -191,5 +267,42 @@ func patchUserReq(user *storer.User, u *pb.UserReq) {
 	user.UpdatedAt = toTimePtr(time.Now())
 }
 
+func toStorerSession(sr *pb.SessionReq) *storer.Session {
+	return &storer.Session{
+		ID:           sr.GetId(),
+		UserEmail:    sr.GetUserEmail(),
+		RefreshToken: sr.GetRefreshToken(),
+		IsRevoked:    sr.GetIsRevoked(),
+		ExpiresAt:    toTimePtr(sr.GetExpiresAt().AsTime()),
+		UserAgent:    sr.GetUserAgent(),
+		IPAddress:    sr.GetIpAddress(),
+	}
+}
+
+func toPBSessionRes(s *storer.Session) *pb.SessionRes {
+	res := &pb.SessionRes{
+		Id:           s.ID,
+		UserEmail:    s.UserEmail,
+		RefreshToken: s.RefreshToken,
+		IsRevoked:    s.IsRevoked,
+		FamilyId:     s.FamilyID,
+		ParentId:     s.ParentID,
+		UserAgent:    s.UserAgent,
+		IpAddress:    s.IPAddress,
+		CreatedAt:    timestamppb.New(s.CreatedAt),
+	}
+
+	//* у сессий, созданных до появления expires_at, срока нет
+	if s.ExpiresAt != nil {
+		res.ExpiresAt = timestamppb.New(*s.ExpiresAt)
+	}
+
+	if s.RotatedAt != nil {
+		res.RotatedAt = timestamppb.New(*s.RotatedAt)
+	}
+
+	return res
+}
+
 
 //* EP 7 =  20 : 00
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/storer"
+	"davidHwang/ecomm/util"
+	"log"
+)
+
+// * с хэшем этого пароля сравнивается пароль несуществующего пользователя,
+// * чтобы время ответа не выдавало, зарегистрирован ли email
+const dummyPassword = "dummy password for unknown users"
+
+// * после успешной проверки пароля хэш пересчитывается, если он создан другим алгоритмом
+// * или с устаревшими параметрами. Так стоимость хэширования повышается без сброса паролей,
+// * ошибка пересчета вход не прерывает
+func (s *Server) rehashPassword(ctx context.Context, usr *storer.User, password string) {
+	if !util.NeedsRehash(usr.Password) {
+		return
+	}
+
+	hashed, err := util.HashPassword(password)
+
+	if err != nil {
+		log.Printf("error rehashing password of user %d: %v", usr.ID, err)
+		return
+	}
+
+	if err := s.storer.RehashPassword(ctx, usr.ID, usr.Password, hashed); err != nil {
+		log.Printf("error saving rehashed password of user %d: %v", usr.ID, err)
+	}
+}
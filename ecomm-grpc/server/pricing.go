@@ -0,0 +1,87 @@
+package server
+
+import (
+	"davidHwang/ecomm/ecomm-grpc/storer"
+	"math"
+)
+
+// * TaxCalculator - расчет налога по стоимости товаров заказа
+type TaxCalculator interface {
+	Tax(itemsPrice float64, items []storer.OrderItem) float64
+}
+
+// * ShippingCalculator - расчет стоимости доставки заказа
+type ShippingCalculator interface {
+	Shipping(itemsPrice float64, items []storer.OrderItem) float64
+}
+
+// * налог как процент от стоимости товаров, Rate = 0.15 означает 15%
+type FlatRateTax struct {
+	Rate float64
+}
+
+func (t FlatRateTax) Tax(itemsPrice float64, _ []storer.OrderItem) float64 {
+	return itemsPrice * t.Rate
+}
+
+// * фиксированная доставка, бесплатная начиная с FreeOver (0 - всегда платная)
+type FlatRateShipping struct {
+	Price    float64
+	FreeOver float64
+}
+
+func (s FlatRateShipping) Shipping(itemsPrice float64, _ []storer.OrderItem) float64 {
+	if s.FreeOver > 0 && itemsPrice >= s.FreeOver {
+		return 0
+	}
+
+	return s.Price
+}
+
+type PriceBreakdown struct {
+	ItemsPrice    float64
+	TaxPrice      float64
+	ShippingPrice float64
+	TotalPrice    float64
+}
+
+// * Pricer считает итоговую стоимость заказа на стороне сервера
+type Pricer struct {
+	tax      TaxCalculator
+	shipping ShippingCalculator
+}
+
+func NewPricer(tax TaxCalculator, shipping ShippingCalculator) *Pricer {
+	return &Pricer{
+		tax:      tax,
+		shipping: shipping,
+	}
+}
+
+func (p *Pricer) Price(items []storer.OrderItem) PriceBreakdown {
+	var itemsPrice float64
+
+	for _, i := range items {
+		itemsPrice += roundCents(float64(i.Price)) * float64(i.Quantity)
+	}
+
+	itemsPrice = roundCents(itemsPrice)
+	tax := roundCents(p.tax.Tax(itemsPrice, items))
+	shipping := roundCents(p.shipping.Shipping(itemsPrice, items))
+
+	return PriceBreakdown{
+		ItemsPrice:    itemsPrice,
+		TaxPrice:      tax,
+		ShippingPrice: shipping,
+		TotalPrice:    roundCents(itemsPrice + tax + shipping),
+	}
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// * сравнение цены от клиента с рассчитанной (float32 в proto теряет точность)
+func samePrice(client float32, computed float64) bool {
+	return math.Abs(float64(client)-computed) < 0.005
+}
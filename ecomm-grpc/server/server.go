@@ -4,17 +4,37 @@ import (
 	"context"
 	"davidHwang/ecomm/ecomm-grpc/pb"
 	"davidHwang/ecomm/ecomm-grpc/storer"
-
-	"google.golang.org/protobuf/types/known/timestamppb"
+	"davidHwang/ecomm/rbac"
+	"davidHwang/ecomm/totp"
+	"davidHwang/ecomm/util"
+	"davidHwang/ecomm/validate"
+	"errors"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
-	storer *storer.MySQLStorer
+	storer     storer.Storer
+	pricer     *Pricer
+	totpCipher *totp.SecretCipher
+	// * хэш dummyPassword, см. VerifyCredentials
+	dummyPasswordHash string
 	pb.UnimplementedEcommServer
 }
 
-func NewServer(storer *storer.MySQLStorer) *Server {
-	return &Server{storer: storer}
+// * totpCipher шифрует секреты TOTP в хранилище, nil - секреты хранятся открытым текстом.
+// * Пароли хэширует хэшер util.SetPasswordHasher, поэтому он задается до NewServer
+func NewServer(storer storer.Storer, pricer *Pricer, totpCipher *totp.SecretCipher) (*Server, error) {
+	dummyHash, err := util.HashPassword(dummyPassword)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{storer: storer, pricer: pricer, totpCipher: totpCipher, dummyPasswordHash: dummyHash}, nil
 }
 
 // * PRODUCTS
@@ -22,7 +42,7 @@ func (s *Server) CreateProduct(ctx context.Context, req *pb.ProductReq) (*pb.Pro
 
 	pr, err := s.storer.CreateProduct(ctx, toStorerProduct(req))
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return toPBProductRes(pr), nil
@@ -32,17 +52,17 @@ func (s *Server) GetProduct(ctx context.Context, p *pb.ProductReq) (*pb.ProductR
 	pr, err := s.storer.GetProduct(ctx, p.GetId())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return toPBProductRes(pr), nil
 }
 
-func (s *Server) ListProducts(ctx context.Context, p *pb.ProductReq) (*pb.ListProductRes, error) {
-	prs, err := s.storer.ListProducts(ctx)
+func (s *Server) ListProducts(ctx context.Context, p *pb.ListProductsReq) (*pb.ListProductRes, error) {
+	prs, next, err := s.storer.ListProducts(ctx, toStorerListProductsParams(p))
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	var lpr []*pb.ProductRes
 
@@ -50,7 +70,26 @@ func (s *Server) ListProducts(ctx context.Context, p *pb.ProductReq) (*pb.ListPr
 		lpr = append(lpr, toPBProductRes(lp))
 	}
 
-	return &pb.ListProductRes{Products: lpr}, nil
+	return &pb.ListProductRes{Products: lpr, NextCursor: next}, nil
+}
+
+func (s *Server) SearchProducts(ctx context.Context, req *pb.SearchProductsReq) (*pb.SearchProductsRes, error) {
+	hits, err := s.storer.SearchProducts(ctx, storer.SearchProductsParams{
+		Query: req.GetQuery(),
+		Limit: int(req.GetLimit()),
+	})
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	res := &pb.SearchProductsRes{}
+
+	for _, h := range hits {
+		res.Hits = append(res.Hits, toPBProductSearchHit(h))
+	}
+
+	return res, nil
 }
 
 func (s *Server) UpdateProduct(ctx context.Context, p *pb.ProductReq) (*pb.ProductRes, error) {
@@ -58,7 +97,7 @@ func (s *Server) UpdateProduct(ctx context.Context, p *pb.ProductReq) (*pb.Produ
 	product, err := s.storer.GetProduct(ctx, p.GetId())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	patchProductReq(product, p)
@@ -66,7 +105,7 @@ func (s *Server) UpdateProduct(ctx context.Context, p *pb.ProductReq) (*pb.Produ
 	pr, err := s.storer.UpdateProduct(ctx, product)
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return toPBProductRes(pr), nil
@@ -76,7 +115,7 @@ func (s *Server) DeleteProduct(ctx context.Context, p *pb.ProductReq) (*pb.Produ
 	err := s.storer.DeleteProduct(ctx, p.GetId())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &pb.ProductRes{}, nil
@@ -84,20 +123,100 @@ func (s *Server) DeleteProduct(ctx context.Context, p *pb.ProductReq) (*pb.Produ
 
 // * ORDERS
 func (s *Server) CreateOrder(ctx context.Context, o *pb.OrderReq) (*pb.OrderRes, error) {
-	or, err := s.storer.CreateOrder(ctx, toStorerOrder(o))
+	if err := authorizeUser(ctx, o.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	order, err := s.priceOrder(ctx, o)
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
+	}
+
+	or, err := s.storer.CreateOrder(ctx, order)
+
+	if err != nil {
+		return nil, toStatusError(err)
 	}
 
 	return toPBOrderRes(or), nil
 }
 
+// * собираем заказ по текущему каталогу: название, картинка и цена товара берутся из базы,
+// * налог и доставка считаются на сервере. Суммы от клиента только сверяются.
+// * Хранилище повторно сверяет цены с заблокированными строками товаров и возвращает ErrConflict, если они изменились
+func (s *Server) priceOrder(ctx context.Context, o *pb.OrderReq) (*storer.Order, error) {
+	if len(o.GetItems()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "order has no items")
+	}
+
+	items := make([]storer.OrderItem, 0, len(o.GetItems()))
+
+	for _, i := range o.GetItems() {
+		if i.GetQuantity() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid quantity %d for product %d", i.GetQuantity(), i.GetProductId())
+		}
+
+		p, err := s.storer.GetProduct(ctx, i.GetProductId())
+
+		if err != nil {
+			if errors.Is(err, storer.ErrNotFound) {
+				return nil, status.Errorf(codes.NotFound, "product %d not found", i.GetProductId())
+			}
+
+			return nil, toStatusError(err)
+		}
+
+		if i.GetPrice() != 0 && !samePrice(i.GetPrice(), roundCents(float64(p.Price))) {
+			return nil, status.Errorf(codes.InvalidArgument, "price mismatch for product %d: expected %.2f, got %.2f", p.ID, p.Price, i.GetPrice())
+		}
+
+		items = append(items, storer.OrderItem{
+			Name:      p.Name,
+			Quantity:  i.GetQuantity(),
+			Image:     p.Image,
+			Price:     p.Price,
+			ProductID: p.ID,
+		})
+	}
+
+	b := s.pricer.Price(items)
+
+	totals := []struct {
+		name     string
+		client   float32
+		computed float64
+	}{
+		{"tax_price", o.GetTaxPrice(), b.TaxPrice},
+		{"shipping_price", o.GetShippingPrice(), b.ShippingPrice},
+		{"total_price", o.GetTotalPrice(), b.TotalPrice},
+	}
+
+	for _, t := range totals {
+		if t.client != 0 && !samePrice(t.client, t.computed) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s mismatch: expected %.2f, got %.2f", t.name, t.computed, t.client)
+		}
+	}
+
+	return &storer.Order{
+		PaymentMethod: o.GetPaymentMethod(),
+		TaxPrice:      float32(b.TaxPrice),
+		ShippingPrice: float32(b.ShippingPrice),
+		TotalPrice:    float32(b.TotalPrice),
+		UserID:        o.GetUserId(),
+		Items:         items,
+	}, nil
+}
+
 func (s *Server) GetOrder(ctx context.Context, o *pb.OrderReq) (*pb.OrderRes, error) {
+	if err := authorizeUser(ctx, o.GetUserId()); err != nil {
+		return nil, err
+	}
+
 	or, err := s.storer.GetOrder(ctx, o.GetUserId())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return toPBOrderRes(or), nil
@@ -107,7 +226,7 @@ func (s *Server) ListOrders(ctx context.Context, o *pb.OrderReq) (*pb.ListOrderR
 	orders, err := s.storer.ListOrders(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	var lor []*pb.OrderRes
@@ -119,42 +238,156 @@ func (s *Server) ListOrders(ctx context.Context, o *pb.OrderReq) (*pb.ListOrderR
 	return &pb.ListOrderRes{Orders: lor}, nil
 }
 
+func (s *Server) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusReq) (*pb.OrderRes, error) {
+	next := toStorerOrderStatus(req.GetStatus())
+
+	if next == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown order status %q", req.GetStatus())
+	}
+
+	or, err := s.storer.UpdateOrderStatus(ctx, req.GetId(), next)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBOrderRes(or), nil
+}
+
 func (s *Server) DeleteOrder(ctx context.Context, o *pb.OrderReq) (*pb.OrderRes, error) {
-	err := s.storer.DeleteOrder(ctx, o.GetId())
+	order, err := s.storer.GetOrderByID(ctx, o.GetId())
 
 	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if err := authorizeUser(ctx, order.UserID); err != nil {
 		return nil, err
 	}
 
+	err = s.storer.DeleteOrder(ctx, o.GetId())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
 	return &pb.OrderRes{}, nil
 }
 
 // * USERS
+// * без ролей создается покупатель (роль customer). Другие роли и права администратора
+// * может выдать только пользователь с правом roles:write, у которого есть все права этих ролей
 func (s *Server) CreateUser(ctx context.Context, u *pb.UserReq) (*pb.UserRes, error) {
-	usr, err := s.storer.CreateUser(ctx, toStorerUser(u))
+	if err := authorizeAdminFlag(ctx, u.GetIsAdmin()); err != nil {
+		return nil, err
+	}
+
+	roles := u.GetRoles()
+	if len(roles) == 0 {
+		roles = []string{rbac.RoleCustomer}
+	}
+
+	for _, role := range roles {
+		if role == rbac.RoleCustomer {
+			continue
+		}
+
+		if err := authorizePermissions(ctx, []string{rbac.PermRolesWrite}); err != nil {
+			return nil, err
+		}
+
+		if err := s.authorizeRoleGrant(ctx, role); err != nil {
+			return nil, err
+		}
+	}
+
+	hashed, err := util.HashPassword(u.GetPassword())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	return toPBUserRes(usr), nil
+	user := toStorerUser(u)
+	user.Password = hashed
+
+	usr, err := s.storer.CreateUser(ctx, user, roles...)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	res := toPBUserRes(usr)
+	res.Roles = roles
+
+	return res, nil
 }
 
+// * вместе с пользователем возвращаются его роли и права для claims токена доступа
 func (s *Server) GetUser(ctx context.Context, u *pb.UserReq) (*pb.UserRes, error) {
 	usr, err := s.storer.GetUser(ctx, u.GetEmail())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	return toPBUserRes(usr), nil
+	return s.userWithRoles(ctx, usr)
+}
+
+// * роль superadmin, права которой получают администраторы (is_admin)
+func (s *Server) superadminRole(ctx context.Context) (*storer.Role, error) {
+	roles, err := s.storer.ListRoles(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	for _, r := range roles {
+		if r.Name == rbac.RoleSuperadmin {
+			return r, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.Internal, "role %q not found", rbac.RoleSuperadmin)
+}
+
+// * пользователь с ролями, правами и признаком двухфакторной аутентификации
+func (s *Server) userWithRoles(ctx context.Context, usr *storer.User) (*pb.UserRes, error) {
+	roles, err := s.storer.GetUserRoles(ctx, usr.ID)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	res := toPBUserRes(usr)
+	res.Roles, res.Permissions = rolesAndPermissions(roles)
+
+	//* is_admin сам прав не дает: администратор получает права роли superadmin, даже если она не назначена
+	if usr.IsAdmin && !slices.Contains(res.Roles, rbac.RoleSuperadmin) {
+		superadmin, err := s.superadminRole(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Permissions = rbac.Merge(res.Permissions, superadmin.Permissions)
+	}
+
+	//* ecomm-api по этому флагу решает, нужен ли второй шаг входа
+	t, err := s.storer.GetTOTP(ctx, usr.ID)
+
+	switch {
+	case err == nil:
+		res.MfaEnabled = t.EnabledAt != nil
+	case !errors.Is(err, storer.ErrNotFound):
+		return nil, toStatusError(err)
+	}
+
+	return res, nil
 }
 
 func (s *Server) ListUsers(ctx context.Context, u *pb.UserReq) (*pb.ListUserRes, error) {
 	users, err := s.storer.ListUsers(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	var lu []*pb.UserRes
 
@@ -166,10 +399,23 @@ func (s *Server) ListUsers(ctx context.Context, u *pb.UserReq) (*pb.ListUserRes,
 }
 
 func (s *Server) UpdateUser(ctx context.Context, u *pb.UserReq) (*pb.UserRes, error) {
+	if err := authorizeEmail(ctx, u.GetEmail()); err != nil {
+		return nil, err
+	}
+
+	if err := authorizeAdminFlag(ctx, u.GetIsAdmin()); err != nil {
+		return nil, err
+	}
+
+	//* пароль не игнорируется молча: клиент должен узнать, что он не изменился
+	if u.GetPassword() != "" {
+		return nil, validationError(validate.Errors{{Field: "password", Message: "can only be changed with ChangePassword"}})
+	}
+
 	user, err := s.storer.GetUser(ctx, u.GetEmail())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	patchUserReq(user, u)
@@ -177,7 +423,7 @@ func (s *Server) UpdateUser(ctx context.Context, u *pb.UserReq) (*pb.UserRes, er
 	usr, err := s.storer.UpdateUser(ctx, user)
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return toPBUserRes(usr), nil
@@ -187,72 +433,462 @@ func (s *Server) DeleteUser(ctx context.Context, u *pb.UserReq) (*pb.UserRes, er
 	err := s.storer.DeleteUser(ctx, u.GetId())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &pb.UserRes{}, nil
 }
 
-//* SESSIONS
+func (s *Server) VerifyEmail(ctx context.Context, u *pb.UserReq) (*pb.UserRes, error) {
+	usr, err := s.storer.VerifyEmail(ctx, u.GetId(), u.GetEmail())
 
-func (s *Server) CreateSession(ctx context.Context, sr *pb.SessionReq) (*pb.SessionRes, error) {
-	session, err := s.storer.CreateSession(ctx, &storer.Session{
-		ID:           sr.GetId(),
-		UserEmail:    sr.GetUserEmail(),
-		RefreshToken: sr.GetRefreshToken(),
-		IsRevoked:    sr.GetIsRevoked(),
-		ExpiresAt:    toTimePtr(sr.GetExpiresAt().AsTime()),
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUserRes(usr), nil
+}
+
+// * сохраняет хэш токена сброса пароля для пользователя с email.
+// * Неизвестный email - NotFound, ecomm-api не сообщает об этом клиенту
+func (s *Server) CreatePasswordReset(ctx context.Context, req *pb.PasswordResetReq) (*pb.UserRes, error) {
+	usr, err := s.storer.GetUser(ctx, req.GetEmail())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	_, err = s.storer.CreatePasswordReset(ctx, &storer.PasswordReset{
+		TokenHash: req.GetTokenHash(),
+		UserID:    usr.ID,
+		ExpiresAt: req.GetExpiresAt().AsTime(),
 	})
 
 	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUserRes(usr), nil
+}
+
+// * устанавливает новый пароль по токену сброса и отзывает все сессии пользователя
+func (s *Server) ResetPassword(ctx context.Context, req *pb.PasswordResetReq) (*pb.UserRes, error) {
+	hashed, err := util.HashPassword(req.GetPassword())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	usr, err := s.storer.ResetPassword(ctx, req.GetTokenHash(), hashed)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUserRes(usr), nil
+}
+
+// * проверка пароля внутри ecomm-grpc, хэш не покидает сервис. Неизвестный email и неверный
+// * пароль - одинаковая ошибка; хэш с устаревшими параметрами пересчитывается текущим хэшером
+func (s *Server) VerifyCredentials(ctx context.Context, u *pb.UserReq) (*pb.UserRes, error) {
+	usr, err := s.storer.GetUser(ctx, u.GetEmail())
+
+	if errors.Is(err, storer.ErrNotFound) {
+		//* пароль все равно сравнивается, чтобы время ответа не выдавало существование email
+		util.CheckPassword(u.GetPassword(), s.dummyPasswordHash)
+		return nil, toStatusError(storer.ErrInvalidCredentials)
+	}
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if err := util.CheckPassword(u.GetPassword(), usr.Password); err != nil {
+		return nil, toStatusError(storer.ErrInvalidCredentials)
+	}
+
+	s.rehashPassword(ctx, usr, u.GetPassword())
+
+	return s.userWithRoles(ctx, usr)
+}
+
+// * смена пароля после проверки текущего. Все сессии пользователя, кроме текущей
+// * (keep_session_id), отзываются
+func (s *Server) ChangePassword(ctx context.Context, req *pb.PasswordChangeReq) (*pb.UserRes, error) {
+	usr, err := s.storer.GetUser(ctx, req.GetEmail())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if err := util.CheckPassword(req.GetCurrentPassword(), usr.Password); err != nil {
+		return nil, toStatusError(storer.ErrInvalidCredentials)
+	}
+
+	hashed, err := util.HashPassword(req.GetNewPassword())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	usr, err = s.storer.ChangePassword(ctx, usr.ID, usr.Password, hashed, req.GetKeepSessionId())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUserRes(usr), nil
+}
+
+//* TOTP
+
+// * сохраняет новый секрет TOTP пользователя, до подтверждения кодом он не действует
+func (s *Server) EnrollTOTP(ctx context.Context, req *pb.TOTPReq) (*pb.TOTPRes, error) {
+	if err := authorizeUser(ctx, req.GetUserId()); err != nil {
 		return nil, err
 	}
 
-	return &pb.SessionRes{
-		Id:           session.ID,
-		UserEmail:    session.UserEmail,
-		RefreshToken: session.RefreshToken,
-		IsRevoked:    session.IsRevoked,
-		ExpiresAt:    timestamppb.New(*session.ExpiresAt),
-	}, nil
+	secret, err := s.totpCipher.Seal(req.GetUserId(), req.GetSecret())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if err := s.storer.SetTOTPSecret(ctx, req.GetUserId(), secret); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.TOTPRes{}, nil
+}
+
+// * включает TOTP первым кодом из приложения и сохраняет хэши кодов восстановления
+func (s *Server) ConfirmTOTP(ctx context.Context, req *pb.TOTPReq) (*pb.TOTPRes, error) {
+	if err := authorizeUser(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	t, err := s.storer.GetTOTP(ctx, req.GetUserId())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if t.EnabledAt != nil {
+		return nil, toStatusError(storer.ErrTOTPAlreadyEnabled)
+	}
+
+	secret, err := s.totpCipher.Open(req.GetUserId(), t.Secret)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	step, ok := totp.Validate(secret, req.GetCode(), time.Now())
+	if !ok {
+		return nil, toStatusError(storer.ErrInvalidMFACode)
+	}
+
+	if err := s.storer.EnableTOTP(ctx, req.GetUserId(), step, req.GetRecoveryCodeHashes()); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.TOTPRes{Enabled: true, RecoveryCodesLeft: int64(len(req.GetRecoveryCodeHashes()))}, nil
+}
+
+// * второй шаг входа: код TOTP или хэш кода восстановления, каждый принимается один раз
+func (s *Server) VerifyTOTP(ctx context.Context, req *pb.TOTPReq) (*pb.TOTPRes, error) {
+	if err := s.checkMFACode(ctx, req); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	left, err := s.storer.CountRecoveryCodes(ctx, req.GetUserId())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.TOTPRes{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// * отключение TOTP тоже подтверждается кодом
+func (s *Server) DisableTOTP(ctx context.Context, req *pb.TOTPReq) (*pb.TOTPRes, error) {
+	if err := authorizeUser(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMFACode(ctx, req); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if err := s.storer.DeleteTOTP(ctx, req.GetUserId()); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.TOTPRes{}, nil
+}
+
+// * без включенного TOTP любой код считается неверным
+func (s *Server) checkMFACode(ctx context.Context, req *pb.TOTPReq) error {
+	t, err := s.storer.GetTOTP(ctx, req.GetUserId())
+
+	switch {
+	case errors.Is(err, storer.ErrNotFound):
+		return storer.ErrInvalidMFACode
+	case err != nil:
+		return err
+	case t.EnabledAt == nil:
+		return storer.ErrInvalidMFACode
+	}
+
+	if hash := req.GetRecoveryCodeHash(); hash != "" {
+		return s.storer.UseRecoveryCode(ctx, req.GetUserId(), hash)
+	}
+
+	secret, err := s.totpCipher.Open(req.GetUserId(), t.Secret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret, req.GetCode(), time.Now())
+	if !ok {
+		return storer.ErrInvalidMFACode
+	}
+
+	return s.storer.UseTOTPStep(ctx, req.GetUserId(), step)
+}
+
+//* LOGIN ATTEMPTS
+
+// * счетчики неудачных попыток входа, общие для всех реплик ecomm-api.
+// * Ключа без неудач нет в хранилище - это ноль попыток, а не ошибка
+func (s *Server) GetLoginAttempt(ctx context.Context, req *pb.LoginAttemptReq) (*pb.LoginAttemptRes, error) {
+	a, err := s.storer.GetLoginAttempt(ctx, req.GetKey())
+
+	if errors.Is(err, storer.ErrNotFound) {
+		return &pb.LoginAttemptRes{Key: req.GetKey()}, nil
+	}
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBLoginAttemptRes(a), nil
+}
+
+func (s *Server) AddLoginFailure(ctx context.Context, req *pb.LoginAttemptReq) (*pb.LoginAttemptRes, error) {
+	a, err := s.storer.AddLoginFailure(ctx, req.GetKey(), req.GetWindow().AsDuration())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBLoginAttemptRes(a), nil
+}
+
+// * отмена неудачи, которую ecomm-api учел до проверки пароля, если пароль оказался верным
+func (s *Server) RemoveLoginFailure(ctx context.Context, req *pb.LoginAttemptReq) (*pb.LoginAttemptRes, error) {
+	var previousAt time.Time
+	if req.GetPreviousFailureAt() != nil {
+		previousAt = req.GetPreviousFailureAt().AsTime()
+	}
+
+	if err := s.storer.RemoveLoginFailure(ctx, req.GetKey(), req.GetFailureAt().AsTime(), previousAt); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.LoginAttemptRes{Key: req.GetKey()}, nil
+}
+
+func (s *Server) ResetLoginAttempts(ctx context.Context, req *pb.LoginAttemptReq) (*pb.LoginAttemptRes, error) {
+	if err := s.storer.ResetLoginAttempts(ctx, req.GetKey()); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.LoginAttemptRes{Key: req.GetKey()}, nil
+}
+
+//* ROLES
+
+func (s *Server) ListRoles(ctx context.Context, _ *pb.RoleReq) (*pb.ListRoleRes, error) {
+	roles, err := s.storer.ListRoles(ctx)
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBListRoleRes(roles), nil
+}
+
+func (s *Server) GetUserRoles(ctx context.Context, req *pb.RoleReq) (*pb.ListRoleRes, error) {
+	roles, err := s.storer.GetUserRoles(ctx, req.GetUserId())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBListRoleRes(roles), nil
+}
+
+// * возвращает роли пользователя после назначения
+func (s *Server) AssignRole(ctx context.Context, req *pb.RoleReq) (*pb.ListRoleRes, error) {
+	if err := s.authorizeRoleGrant(ctx, req.GetName()); err != nil {
+		return nil, err
+	}
+
+	if err := s.storer.AssignRole(ctx, req.GetUserId(), req.GetName()); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return s.GetUserRoles(ctx, req)
+}
+
+func (s *Server) UnassignRole(ctx context.Context, req *pb.RoleReq) (*pb.ListRoleRes, error) {
+	if err := s.authorizeRoleGrant(ctx, req.GetName()); err != nil {
+		return nil, err
+	}
+
+	if err := s.storer.UnassignRole(ctx, req.GetUserId(), req.GetName()); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return s.GetUserRoles(ctx, req)
+}
+
+// * назначить или снять роль можно, только если у вызывающего есть все ее права.
+// * Неизвестная роль пропускается, ее отклонит хранилище
+func (s *Server) authorizeRoleGrant(ctx context.Context, name string) error {
+	roles, err := s.storer.ListRoles(ctx)
+
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	for _, r := range roles {
+		if r.Name == name {
+			return authorizePermissions(ctx, r.Permissions)
+		}
+	}
+
+	return nil
+}
+
+//* SESSIONS
+
+func (s *Server) CreateSession(ctx context.Context, sr *pb.SessionReq) (*pb.SessionRes, error) {
+	session, err := s.storer.CreateSession(ctx, toStorerSession(sr))
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBSessionRes(session), nil
 }
 
 func (s *Server) GetSession(ctx context.Context, sr *pb.SessionReq) (*pb.SessionRes, error) {
 	session, err := s.storer.GetSession(ctx, sr.GetId())
 
 	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBSessionRes(session), nil
+}
+
+// * ротация токена обновления: сессия req.id заменяется новой сессией req.session
+func (s *Server) RotateSession(ctx context.Context, req *pb.RotateSessionReq) (*pb.SessionRes, error) {
+	if req.GetSession() == nil {
+		return nil, status.Error(codes.InvalidArgument, "new session is required")
+	}
+
+	session, err := s.storer.RotateSession(ctx, req.GetId(), toStorerSession(req.GetSession()))
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBSessionRes(session), nil
+}
+
+// * активные сессии пользователя (устройства, на которых выполнен вход)
+func (s *Server) ListSessions(ctx context.Context, sr *pb.SessionReq) (*pb.ListSessionRes, error) {
+	if err := authorizeEmail(ctx, sr.GetUserEmail()); err != nil {
 		return nil, err
 	}
 
-	return &pb.SessionRes{
-		Id:           session.ID,
-		UserEmail:    session.UserEmail,
-		RefreshToken: session.RefreshToken,
-		IsRevoked:    session.IsRevoked,
-		ExpiresAt:    timestamppb.New(*session.ExpiresAt),
-	}, nil
+	sessions, err := s.storer.ListSessions(ctx, sr.GetUserEmail())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	var ls []*pb.SessionRes
+
+	//* токен обновления дает право на новые токены доступа, в списке сессий он не возвращается
+	for _, session := range sessions {
+		sr := toPBSessionRes(session)
+		sr.RefreshToken = ""
+		ls = append(ls, sr)
+	}
+
+	return &pb.ListSessionRes{Sessions: ls}, nil
 }
 
 func (s *Server) RevokeSession(ctx context.Context, sr *pb.SessionReq) (*pb.SessionRes, error) {
+	if err := s.authorizeSession(ctx, sr.GetId()); err != nil {
+		return nil, err
+	}
+
 	err := s.storer.RevokeSession(ctx, sr.GetId())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &pb.SessionRes{}, nil
 }
 
+// * выход на всех устройствах
+func (s *Server) RevokeUserSessions(ctx context.Context, sr *pb.SessionReq) (*pb.SessionRes, error) {
+	if err := authorizeEmail(ctx, sr.GetUserEmail()); err != nil {
+		return nil, err
+	}
+
+	err := s.storer.RevokeUserSessions(ctx, sr.GetUserEmail())
+
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.SessionRes{}, nil
+}
 
 func (s *Server) DeleteSession(ctx context.Context, sr *pb.SessionReq) (*pb.SessionRes, error) {
+	if err := s.authorizeSession(ctx, sr.GetId()); err != nil {
+		return nil, err
+	}
+
 	err := s.storer.DeleteSession(ctx, sr.GetId())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &pb.SessionRes{}, nil
 }
 
+// * пользователь может отозвать или удалить только свою сессию.
+// * Для несуществующей сессии ответ остается за storer
+func (s *Server) authorizeSession(ctx context.Context, id string) error {
+	session, err := s.storer.GetSession(ctx, id)
+
+	if errors.Is(err, storer.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	return authorizeEmail(ctx, session.UserEmail)
+}
+
 //* 21 : 42
 //* https://www.youtube.com/watch?v=D1a7ny_imUw
@@ -0,0 +1,732 @@
+package server
+
+import (
+	"context"
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"davidHwang/ecomm/ecomm-grpc/storer"
+	"davidHwang/ecomm/rbac"
+	"davidHwang/ecomm/token"
+	"davidHwang/ecomm/totp"
+	"davidHwang/ecomm/util"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newTestServer(t *testing.T) (*Server, *storer.User, *storer.Product) {
+	st := storer.NewMemoryStorer()
+	ctx := context.Background()
+
+	u, err := st.CreateUser(ctx, &storer.User{Name: "user", Email: "user@example.com", Password: "hashed"})
+	require.NoError(t, err)
+
+	p, err := st.CreateProduct(ctx, &storer.Product{
+		Name:         "Product 1",
+		Image:        "image1",
+		Category:     "category1",
+		Price:        20,
+		CountInStock: 10,
+	})
+	require.NoError(t, err)
+
+	pricer := NewPricer(FlatRateTax{Rate: 0.1}, FlatRateShipping{Price: 5, FreeOver: 100})
+
+	totpCipher, err := totp.NewSecretCipher("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	require.NoError(t, err)
+
+	srv, err := NewServer(st, pricer, totpCipher)
+	require.NoError(t, err)
+
+	return srv, u, p
+}
+
+// * контекст вызова от имени пользователя, как после AuthInterceptor
+// * администратор получает права роли superadmin, как при выдаче токена
+func userContext(u *storer.User) context.Context {
+	claims := &token.UserClaims{ID: u.ID, Email: u.Email, IsAdmin: u.IsAdmin}
+	if u.IsAdmin {
+		claims.Permissions = []string{rbac.PermAll}
+	}
+
+	return contextWithClaims(context.Background(), claims)
+}
+
+func TestCreateOrderPricing(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *Server, *storer.User, *storer.Product)
+	}{
+		{
+			name: "computes totals from catalog",
+			test: func(t *testing.T, srv *Server, u *storer.User, p *storer.Product) {
+				res, err := srv.CreateOrder(userContext(u), &pb.OrderReq{
+					PaymentMethod: "card",
+					UserId:        u.ID,
+					Items:         []*pb.OrderItem{{ProductId: p.ID, Quantity: 2, Name: "cheap", Image: "fake", Price: 0}},
+				})
+				require.NoError(t, err)
+
+				require.Len(t, res.Items, 1)
+				require.Equal(t, p.Name, res.Items[0].Name)
+				require.Equal(t, p.Image, res.Items[0].Image)
+				require.Equal(t, p.Price, res.Items[0].Price)
+
+				require.InDelta(t, 40, res.Breakdown.ItemsPrice, 0.001)
+				require.InDelta(t, 4, res.TaxPrice, 0.001)
+				require.InDelta(t, 5, res.ShippingPrice, 0.001)
+				require.InDelta(t, 49, res.TotalPrice, 0.001)
+			},
+		},
+		{
+			name: "free shipping threshold",
+			test: func(t *testing.T, srv *Server, u *storer.User, p *storer.Product) {
+				res, err := srv.CreateOrder(userContext(u), &pb.OrderReq{
+					UserId:     u.ID,
+					Items:      []*pb.OrderItem{{ProductId: p.ID, Quantity: 5}},
+					TotalPrice: 110,
+				})
+				require.NoError(t, err)
+				require.Zero(t, res.ShippingPrice)
+				require.InDelta(t, 110, res.TotalPrice, 0.001)
+			},
+		},
+		{
+			name: "rejects mismatched total",
+			test: func(t *testing.T, srv *Server, u *storer.User, p *storer.Product) {
+				_, err := srv.CreateOrder(userContext(u), &pb.OrderReq{
+					UserId:     u.ID,
+					Items:      []*pb.OrderItem{{ProductId: p.ID, Quantity: 1}},
+					TotalPrice: 0.01,
+				})
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "rejects mismatched item price",
+			test: func(t *testing.T, srv *Server, u *storer.User, p *storer.Product) {
+				_, err := srv.CreateOrder(userContext(u), &pb.OrderReq{
+					UserId: u.ID,
+					Items:  []*pb.OrderItem{{ProductId: p.ID, Quantity: 1, Price: 1}},
+				})
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "unknown product",
+			test: func(t *testing.T, srv *Server, u *storer.User, _ *storer.Product) {
+				_, err := srv.CreateOrder(userContext(u), &pb.OrderReq{
+					UserId: u.ID,
+					Items:  []*pb.OrderItem{{ProductId: 12345, Quantity: 1}},
+				})
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "insufficient stock",
+			test: func(t *testing.T, srv *Server, u *storer.User, p *storer.Product) {
+				_, err := srv.CreateOrder(userContext(u), &pb.OrderReq{
+					UserId: u.ID,
+					Items:  []*pb.OrderItem{{ProductId: p.ID, Quantity: 11}},
+				})
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, u, p := newTestServer(t)
+			tc.test(t, srv, u, p)
+		})
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	tcs := []struct {
+		name   string
+		code   codes.Code
+		reason string
+		call   func(*Server, *storer.User, *storer.Product) error
+	}{
+		{
+			name:   "product not found",
+			code:   codes.NotFound,
+			reason: reasonNotFound,
+			call: func(srv *Server, _ *storer.User, _ *storer.Product) error {
+				_, err := srv.GetProduct(context.Background(), &pb.ProductReq{Id: 12345})
+				return err
+			},
+		},
+		{
+			name:   "duplicate email",
+			code:   codes.AlreadyExists,
+			reason: reasonDuplicateEmail,
+			call: func(srv *Server, u *storer.User, _ *storer.Product) error {
+				_, err := srv.CreateUser(context.Background(), &pb.UserReq{Name: "other", Email: u.Email, Password: "password"})
+				return err
+			},
+		},
+		{
+			name:   "product referenced by order",
+			code:   codes.FailedPrecondition,
+			reason: reasonForeignKey,
+			call: func(srv *Server, u *storer.User, p *storer.Product) error {
+				_, err := srv.CreateOrder(userContext(u), &pb.OrderReq{UserId: u.ID, Items: []*pb.OrderItem{{ProductId: p.ID, Quantity: 1}}})
+				require.NoError(t, err)
+
+				_, err = srv.DeleteProduct(context.Background(), &pb.ProductReq{Id: p.ID})
+				return err
+			},
+		},
+		{
+			name:   "refresh token reuse",
+			code:   codes.Unauthenticated,
+			reason: reasonRefreshReused,
+			call: func(srv *Server, u *storer.User, _ *storer.Product) error {
+				expiresAt := timestamppb.New(time.Now().Add(time.Hour))
+
+				_, err := srv.CreateSession(context.Background(), &pb.SessionReq{Id: "s1", UserEmail: u.Email, RefreshToken: "r1", ExpiresAt: expiresAt})
+				require.NoError(t, err)
+
+				_, err = srv.RotateSession(context.Background(), &pb.RotateSessionReq{Id: "s1", Session: &pb.SessionReq{Id: "s2", UserEmail: u.Email, RefreshToken: "r2", ExpiresAt: expiresAt}})
+				require.NoError(t, err)
+
+				_, err = srv.RotateSession(context.Background(), &pb.RotateSessionReq{Id: "s1", Session: &pb.SessionReq{Id: "s3", UserEmail: u.Email, RefreshToken: "r3", ExpiresAt: expiresAt}})
+				return err
+			},
+		},
+		{
+			name:   "invalid cursor",
+			code:   codes.InvalidArgument,
+			reason: reasonInvalidArgument,
+			call: func(srv *Server, _ *storer.User, _ *storer.Product) error {
+				_, err := srv.ListProducts(context.Background(), &pb.ListProductsReq{Cursor: "not a cursor"})
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, u, p := newTestServer(t)
+
+			st := status.Convert(tc.call(srv, u, p))
+			require.Equal(t, tc.code, st.Code())
+
+			var info *errdetails.ErrorInfo
+			for _, d := range st.Details() {
+				if ei, ok := d.(*errdetails.ErrorInfo); ok {
+					info = ei
+				}
+			}
+
+			require.NotNil(t, info)
+			require.Equal(t, tc.reason, info.GetReason())
+		})
+	}
+}
+
+func TestStatusErrorHidesInternalErrors(t *testing.T) {
+	err := toStatusError(errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, "internal error", status.Convert(err).Message())
+}
+
+func TestValidationInterceptor(t *testing.T) {
+	interceptor := ValidationInterceptor()
+	called := false
+	handler := func(ctx context.Context, req any) (any, error) {
+		called = true
+		return &pb.ProductRes{}, nil
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: pb.Ecomm_CreateProduct_FullMethodName}
+
+	_, err := interceptor(context.Background(), &pb.ProductReq{Name: "Product", Price: -1, CountInStock: -2}, info, handler)
+	require.False(t, called)
+
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	var fields []string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	require.Equal(t, []string{"image", "category", "price", "count_in_stock"}, fields)
+
+	_, err = interceptor(context.Background(), &pb.ProductReq{Name: "Product", Image: "image", Category: "category", Price: 10}, info, handler)
+	require.NoError(t, err)
+	require.True(t, called)
+
+	// * методы без правил не проверяются
+	called = false
+	_, err = interceptor(context.Background(), &pb.ProductReq{}, &grpc.UnaryServerInfo{FullMethod: pb.Ecomm_GetProduct_FullMethodName}, handler)
+	require.NoError(t, err)
+	require.True(t, called)
+}
+
+type fakeSessionChecker map[string]bool
+
+func (f fakeSessionChecker) IsRevoked(_ context.Context, id string) (bool, error) {
+	return f[id], nil
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	maker := token.NewJWTMaker("test-secret-key-with-enough-length")
+	interceptor := AuthInterceptor(maker, fakeSessionChecker{"revoked": true})
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		claims, _ := ClaimsFromContext(ctx)
+		return claims, nil
+	}
+
+	bearer := func(tok string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationKey, "Bearer "+tok))
+	}
+
+	withSession := func(sessionID string, isAdmin bool) context.Context {
+		tok, _, err := maker.CreateToken(1, "user@example.com", isAdmin, nil, sessionID, time.Minute)
+		require.NoError(t, err)
+
+		return bearer(tok)
+	}
+
+	withToken := func(id int64, isAdmin bool, perms ...string) context.Context {
+		tok, _, err := maker.CreateToken(id, "user@example.com", isAdmin, perms, "session", time.Minute)
+		require.NoError(t, err)
+
+		return bearer(tok)
+	}
+
+	serviceTok, _, err := maker.CreateServiceToken("ecomm-api", time.Minute)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		code   codes.Code
+	}{
+		{"public without token", context.Background(), pb.Ecomm_GetProduct_FullMethodName, codes.OK},
+		{"authenticated without token", context.Background(), pb.Ecomm_GetOrder_FullMethodName, codes.Unauthenticated},
+		{"authenticated with token", withToken(1, false), pb.Ecomm_GetOrder_FullMethodName, codes.OK},
+		{"admin as user", withToken(1, false), pb.Ecomm_ListUsers_FullMethodName, codes.PermissionDenied},
+		{"admin as admin", withToken(1, true, rbac.PermAll), pb.Ecomm_ListUsers_FullMethodName, codes.OK},
+		{"admin flag without permissions", withToken(1, true), pb.Ecomm_ListUsers_FullMethodName, codes.PermissionDenied},
+		{"admin with permission", withToken(1, false, rbac.PermProductsWrite), pb.Ecomm_CreateProduct_FullMethodName, codes.OK},
+		{"admin with other permission", withToken(1, false, rbac.PermProductsWrite), pb.Ecomm_ListOrders_FullMethodName, codes.PermissionDenied},
+		{"admin as superadmin role", withToken(1, false, rbac.PermAll), pb.Ecomm_ListUsers_FullMethodName, codes.OK},
+		{"session method with permission", withToken(1, false, rbac.PermUsersWrite), pb.Ecomm_CreateSession_FullMethodName, codes.PermissionDenied},
+		{"password reset with permission", withToken(1, false, rbac.PermUsersWrite), pb.Ecomm_ResetPassword_FullMethodName, codes.PermissionDenied},
+		{"mfa login step as user", withToken(1, false), pb.Ecomm_VerifyTOTP_FullMethodName, codes.PermissionDenied},
+		{"service method as admin", withToken(1, true), pb.Ecomm_VerifyCredentials_FullMethodName, codes.PermissionDenied},
+		{"service method as service", bearer(serviceTok), pb.Ecomm_VerifyCredentials_FullMethodName, codes.OK},
+		{"admin method as service", bearer(serviceTok), pb.Ecomm_ListUsers_FullMethodName, codes.OK},
+		{"refresh token", withSession("", false), pb.Ecomm_GetOrder_FullMethodName, codes.Unauthenticated},
+		{"refresh token of admin", withSession("", true), pb.Ecomm_ListUsers_FullMethodName, codes.Unauthenticated},
+		{"revoked session", withSession("revoked", false), pb.Ecomm_GetOrder_FullMethodName, codes.Unauthenticated},
+		{"unknown method requires admin", withToken(1, false), "/pb.ecomm/Unknown", codes.PermissionDenied},
+		{
+			"invalid token on public method",
+			metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationKey, "Bearer invalid")),
+			pb.Ecomm_GetProduct_FullMethodName,
+			codes.Unauthenticated,
+		},
+		{
+			"malformed authorization",
+			metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationKey, "Basic abc")),
+			pb.Ecomm_GetOrder_FullMethodName,
+			codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := interceptor(tc.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			require.Equal(t, tc.code, status.Code(err))
+
+			if claims, _ := res.(*token.UserClaims); claims != nil && !claims.IsService() {
+				require.Equal(t, int64(1), claims.ID)
+			}
+		})
+	}
+}
+
+func TestSessionChecker(t *testing.T) {
+	st := storer.NewMemoryStorer()
+	ctx := context.Background()
+
+	_, err := st.CreateSession(ctx, &storer.Session{ID: "session", UserEmail: "user@example.com", RefreshToken: "refresh"})
+	require.NoError(t, err)
+
+	sessions := NewSessionChecker(st, 0)
+
+	revoked, err := sessions.IsRevoked(ctx, "session")
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, st.RevokeSession(ctx, "session"))
+
+	revoked, err = sessions.IsRevoked(ctx, "session")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// * удаленная сессия (выход из системы) считается отозванной
+	revoked, err = sessions.IsRevoked(ctx, "missing")
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestOwnerChecks(t *testing.T) {
+	srv, u, p := newTestServer(t)
+
+	other := userContext(&storer.User{ID: u.ID + 1, Email: "other@example.com"})
+	admin := userContext(&storer.User{ID: u.ID + 2, Email: "admin@example.com", IsAdmin: true})
+
+	_, err := srv.CreateOrder(other, &pb.OrderReq{UserId: u.ID, Items: []*pb.OrderItem{{ProductId: p.ID, Quantity: 1}}})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	order, err := srv.CreateOrder(userContext(u), &pb.OrderReq{UserId: u.ID, Items: []*pb.OrderItem{{ProductId: p.ID, Quantity: 1}}})
+	require.NoError(t, err)
+
+	_, err = srv.GetOrder(other, &pb.OrderReq{UserId: u.ID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.DeleteOrder(other, &pb.OrderReq{Id: order.Id})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.UpdateUser(other, &pb.UserReq{Email: u.Email, Name: "renamed"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.UpdateUser(userContext(u), &pb.UserReq{Email: u.Email, IsAdmin: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.CreateUser(context.Background(), &pb.UserReq{Name: "new", Email: "new@example.com", Password: "password", IsAdmin: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.ListSessions(other, &pb.SessionReq{UserEmail: u.Email})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.CreateSession(admin, &pb.SessionReq{Id: "listed", UserEmail: u.Email, RefreshToken: "refresh", ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))})
+	require.NoError(t, err)
+
+	sessions, err := srv.ListSessions(userContext(u), &pb.SessionReq{UserEmail: u.Email})
+	require.NoError(t, err)
+	require.Len(t, sessions.GetSessions(), 1)
+	require.Equal(t, "listed", sessions.GetSessions()[0].GetId())
+	require.Empty(t, sessions.GetSessions()[0].GetRefreshToken())
+
+	//* сессия без срока (expires_at IS NULL) не роняет ListSessions
+	_, err = srv.storer.CreateSession(context.Background(), &storer.Session{ID: "legacy", UserEmail: u.Email, RefreshToken: "legacy"})
+	require.NoError(t, err)
+
+	sessions, err = srv.ListSessions(userContext(u), &pb.SessionReq{UserEmail: u.Email})
+	require.NoError(t, err)
+	require.Len(t, sessions.GetSessions(), 2)
+
+	for _, s := range sessions.GetSessions() {
+		if s.GetId() == "legacy" {
+			require.Nil(t, s.GetExpiresAt())
+		}
+	}
+
+	_, err = srv.RevokeUserSessions(other, &pb.SessionReq{UserEmail: u.Email})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.GetOrder(admin, &pb.OrderReq{UserId: u.ID})
+	require.NoError(t, err)
+
+	_, err = srv.DeleteOrder(userContext(u), &pb.OrderReq{Id: order.Id})
+	require.NoError(t, err)
+}
+
+func TestRoles(t *testing.T) {
+	srv, u, _ := newTestServer(t)
+	ctx := context.Background()
+
+	superadmin := contextWithClaims(ctx, &token.UserClaims{ID: u.ID + 1, Permissions: []string{rbac.PermAll}})
+	//* может назначать роли, но не имеет права products:write
+	support := contextWithClaims(ctx, &token.UserClaims{ID: u.ID + 2, Permissions: []string{rbac.PermRolesWrite, rbac.PermOrdersRead, rbac.PermOrdersWrite, rbac.PermUsersRead}})
+
+	roles, err := srv.AssignRole(superadmin, &pb.RoleReq{UserId: u.ID, Name: rbac.RoleCatalogManager})
+	require.NoError(t, err)
+	require.Len(t, roles.GetRoles(), 1)
+
+	_, err = srv.AssignRole(support, &pb.RoleReq{UserId: u.ID, Name: rbac.RoleOrderSupport})
+	require.NoError(t, err)
+
+	_, err = srv.AssignRole(support, &pb.RoleReq{UserId: u.ID, Name: rbac.RoleSuperadmin})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.UnassignRole(support, &pb.RoleReq{UserId: u.ID, Name: rbac.RoleCatalogManager})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.AssignRole(superadmin, &pb.RoleReq{UserId: u.ID, Name: "unknown"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	//* GetUser возвращает роли и объединенные права для токена доступа
+	gu, err := srv.GetUser(ctx, &pb.UserReq{Email: u.Email})
+	require.NoError(t, err)
+	require.Equal(t, []string{rbac.RoleCatalogManager, rbac.RoleOrderSupport}, gu.GetRoles())
+	require.Equal(t, []string{rbac.PermProductsWrite, rbac.PermOrdersRead, rbac.PermOrdersWrite, rbac.PermUsersRead}, gu.GetPermissions())
+
+	//* is_admin без роли superadmin получает ее права, но не саму роль
+	admin, err := srv.storer.CreateUser(ctx, &storer.User{Name: "admin", Email: "admin@example.com", Password: "hashed", IsAdmin: true}, rbac.RoleCatalogManager)
+	require.NoError(t, err)
+
+	gu, err = srv.GetUser(ctx, &pb.UserReq{Email: admin.Email})
+	require.NoError(t, err)
+	require.Equal(t, []string{rbac.RoleCatalogManager}, gu.GetRoles())
+	require.Equal(t, []string{rbac.PermProductsWrite, rbac.PermAll}, gu.GetPermissions())
+
+	//* регистрация без ролей создает покупателя
+	created, err := srv.CreateUser(ctx, &pb.UserReq{Name: "new", Email: "new@example.com", Password: "password"})
+	require.NoError(t, err)
+	require.Equal(t, []string{rbac.RoleCustomer}, created.GetRoles())
+
+	//* пароль хэширует ecomm-grpc
+	stored, err := srv.storer.GetUser(ctx, created.GetEmail())
+	require.NoError(t, err)
+	require.NoError(t, util.CheckPassword("password", stored.Password))
+
+	_, err = srv.CreateUser(ctx, &pb.UserReq{Name: "new", Email: "manager@example.com", Password: "password", Roles: []string{rbac.RoleCatalogManager}})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = srv.CreateUser(support, &pb.UserReq{Name: "new", Email: "manager@example.com", Password: "password", Roles: []string{rbac.RoleCatalogManager}})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	created, err = srv.CreateUser(superadmin, &pb.UserReq{Name: "new", Email: "manager@example.com", Password: "password", Roles: []string{rbac.RoleCatalogManager}})
+	require.NoError(t, err)
+	require.Equal(t, []string{rbac.RoleCatalogManager}, created.GetRoles())
+
+	//* роль superadmin дает доступ к данным других пользователей
+	_, err = srv.GetOrder(superadmin, &pb.OrderReq{UserId: u.ID})
+	require.NotEqual(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestVerifyEmail(t *testing.T) {
+	srv, u, _ := newTestServer(t)
+
+	res, err := srv.VerifyEmail(context.Background(), &pb.UserReq{Id: u.ID, Email: u.Email})
+	require.NoError(t, err)
+	require.NotNil(t, res.GetEmailVerifiedAt())
+
+	_, err = srv.VerifyEmail(context.Background(), &pb.UserReq{Id: u.ID, Email: u.Email})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	//* изменение профиля без смены email не сбрасывает подтверждение
+	res, err = srv.UpdateUser(userContext(u), &pb.UserReq{Email: u.Email, Name: "renamed"})
+	require.NoError(t, err)
+	require.NotNil(t, res.GetEmailVerifiedAt())
+
+	user := &storer.User{Email: u.Email, EmailVerifiedAt: toTimePtr(time.Now())}
+	patchUserReq(user, &pb.UserReq{Email: "new@example.com"})
+	require.Nil(t, user.EmailVerifiedAt)
+}
+
+func TestPasswordReset(t *testing.T) {
+	srv, u, _ := newTestServer(t)
+	ctx := context.Background()
+
+	_, err := srv.CreateSession(ctx, &pb.SessionReq{Id: "session", UserEmail: u.Email, RefreshToken: "refresh", ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))})
+	require.NoError(t, err)
+
+	_, err = srv.CreatePasswordReset(ctx, &pb.PasswordResetReq{Email: "unknown@example.com", TokenHash: "hash", ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err := srv.CreatePasswordReset(ctx, &pb.PasswordResetReq{Email: u.Email, TokenHash: "hash", ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))})
+	require.NoError(t, err)
+	require.Equal(t, u.ID, res.GetId())
+
+	res, err = srv.ResetPassword(ctx, &pb.PasswordResetReq{TokenHash: "hash", Password: "new password"})
+	require.NoError(t, err)
+	require.Equal(t, u.Email, res.GetEmail())
+
+	stored, err := srv.storer.GetUser(ctx, u.Email)
+	require.NoError(t, err)
+	require.NoError(t, util.CheckPassword("new password", stored.Password))
+
+	session, err := srv.GetSession(ctx, &pb.SessionReq{Id: "session"})
+	require.NoError(t, err)
+	require.True(t, session.GetIsRevoked())
+
+	_, err = srv.ResetPassword(ctx, &pb.PasswordResetReq{TokenHash: "hash", Password: "other password"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestVerifyCredentials(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	ctx := context.Background()
+
+	//* хэш с параметрами ниже текущих (по умолчанию bcrypt с DefaultCost)
+	weak, err := util.BcryptHasher{Cost: bcrypt.MinCost}.Hash("password")
+	require.NoError(t, err)
+
+	u, err := srv.storer.CreateUser(ctx, &storer.User{Name: "login", Email: "login@example.com", Password: weak})
+	require.NoError(t, err)
+
+	for _, req := range []*pb.UserReq{
+		{Email: u.Email, Password: "wrong-password"},
+		{Email: "unknown@example.com", Password: "password"},
+	} {
+		_, err = srv.VerifyCredentials(ctx, req)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Equal(t, "invalid email or password", status.Convert(err).Message())
+	}
+
+	res, err := srv.VerifyCredentials(ctx, &pb.UserReq{Email: u.Email, Password: "password"})
+	require.NoError(t, err)
+	require.Equal(t, u.ID, res.GetId())
+
+	//* устаревший хэш пересчитан текущим хэшером
+	stored, err := srv.storer.GetUser(ctx, u.Email)
+	require.NoError(t, err)
+	require.NotEqual(t, weak, stored.Password)
+	require.False(t, util.NeedsRehash(stored.Password))
+	require.NoError(t, util.CheckPassword("password", stored.Password))
+}
+
+func TestChangePassword(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	ctx := context.Background()
+
+	hashed, err := util.HashPassword("password")
+	require.NoError(t, err)
+
+	u, err := srv.storer.CreateUser(ctx, &storer.User{Name: "change", Email: "change@example.com", Password: hashed})
+	require.NoError(t, err)
+
+	//* изменение профиля с паролем отклоняется, пароль не меняется
+	_, err = srv.UpdateUser(userContext(u), &pb.UserReq{Email: u.Email, Password: "plain"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stored, err := srv.storer.GetUser(ctx, u.Email)
+	require.NoError(t, err)
+	require.Equal(t, hashed, stored.Password)
+
+	_, err = srv.ChangePassword(ctx, &pb.PasswordChangeReq{Email: u.Email, CurrentPassword: "wrong-password", NewPassword: "new password"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	res, err := srv.ChangePassword(ctx, &pb.PasswordChangeReq{Email: u.Email, CurrentPassword: "password", NewPassword: "new password"})
+	require.NoError(t, err)
+	require.Equal(t, u.Email, res.GetEmail())
+
+	//* новый пароль хэширует ecomm-grpc
+	stored, err = srv.storer.GetUser(ctx, u.Email)
+	require.NoError(t, err)
+	require.NotEqual(t, "new password", stored.Password)
+	require.NoError(t, util.CheckPassword("new password", stored.Password))
+}
+
+func TestTOTP(t *testing.T) {
+	srv, u, _ := newTestServer(t)
+	ctx := userContext(u)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	code := func(offset int64) string {
+		c, err := totp.Code(secret, totp.Step(time.Now())+offset)
+		require.NoError(t, err)
+		return c
+	}
+
+	_, err = srv.EnrollTOTP(ctx, &pb.TOTPReq{UserId: u.ID + 1, Secret: secret})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.EnrollTOTP(ctx, &pb.TOTPReq{UserId: u.ID, Secret: secret})
+	require.NoError(t, err)
+
+	//* в хранилище секрет зашифрован
+	stored, err := srv.storer.GetTOTP(ctx, u.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, stored.Secret)
+
+	//* неподтвержденный TOTP не требует второго шага входа
+	_, err = srv.VerifyTOTP(context.Background(), &pb.TOTPReq{UserId: u.ID, Code: code(0)})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = srv.ConfirmTOTP(ctx, &pb.TOTPReq{UserId: u.ID, Code: "000000"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := srv.ConfirmTOTP(ctx, &pb.TOTPReq{UserId: u.ID, Code: code(0), RecoveryCodeHashes: []string{"hash1", "hash2"}})
+	require.NoError(t, err)
+	require.True(t, res.GetEnabled())
+	require.Equal(t, int64(2), res.GetRecoveryCodesLeft())
+
+	gu, err := srv.GetUser(context.Background(), &pb.UserReq{Email: u.Email})
+	require.NoError(t, err)
+	require.True(t, gu.GetMfaEnabled())
+
+	//* код шага подтверждения повторно не принимается
+	_, err = srv.VerifyTOTP(context.Background(), &pb.TOTPReq{UserId: u.ID, Code: code(0)})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = srv.VerifyTOTP(context.Background(), &pb.TOTPReq{UserId: u.ID, Code: code(1)})
+	require.NoError(t, err)
+
+	res, err = srv.VerifyTOTP(context.Background(), &pb.TOTPReq{UserId: u.ID, RecoveryCodeHash: "hash1"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), res.GetRecoveryCodesLeft())
+
+	_, err = srv.VerifyTOTP(context.Background(), &pb.TOTPReq{UserId: u.ID, RecoveryCodeHash: "hash1"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = srv.EnrollTOTP(ctx, &pb.TOTPReq{UserId: u.ID, Secret: secret})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = srv.DisableTOTP(ctx, &pb.TOTPReq{UserId: u.ID, RecoveryCodeHash: "hash2"})
+	require.NoError(t, err)
+
+	gu, err = srv.GetUser(context.Background(), &pb.UserReq{Email: u.Email})
+	require.NoError(t, err)
+	require.False(t, gu.GetMfaEnabled())
+}
+
+func TestLoginAttempts(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	ctx := context.Background()
+
+	res, err := srv.GetLoginAttempt(ctx, &pb.LoginAttemptReq{Key: "ip:127.0.0.1"})
+	require.NoError(t, err)
+	require.Zero(t, res.GetFailures())
+
+	for range 2 {
+		res, err = srv.AddLoginFailure(ctx, &pb.LoginAttemptReq{Key: "ip:127.0.0.1", Window: durationpb.New(time.Hour)})
+		require.NoError(t, err)
+	}
+	require.Equal(t, int64(2), res.GetFailures())
+
+	res, err = srv.GetLoginAttempt(ctx, &pb.LoginAttemptReq{Key: "ip:127.0.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.GetFailures())
+	require.NotNil(t, res.GetLastFailureAt())
+
+	_, err = srv.RemoveLoginFailure(ctx, &pb.LoginAttemptReq{Key: "ip:127.0.0.1", FailureAt: res.GetLastFailureAt()})
+	require.NoError(t, err)
+
+	res, err = srv.GetLoginAttempt(ctx, &pb.LoginAttemptReq{Key: "ip:127.0.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), res.GetFailures())
+
+	_, err = srv.ResetLoginAttempts(ctx, &pb.LoginAttemptReq{Key: "ip:127.0.0.1"})
+	require.NoError(t, err)
+
+	res, err = srv.GetLoginAttempt(ctx, &pb.LoginAttemptReq{Key: "ip:127.0.0.1"})
+	require.NoError(t, err)
+	require.Zero(t, res.GetFailures())
+}
@@ -0,0 +1,122 @@
+package storer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// * email уже подтвержден, токен подтверждения использовать повторно нельзя
+var ErrEmailAlreadyVerified = errors.New("email already verified")
+
+// * двухфакторная аутентификация уже включена, новый секрет выдать нельзя
+var ErrTOTPAlreadyEnabled = errors.New("totp already enabled")
+
+// * неверный, уже использованный код TOTP или код восстановления
+var ErrInvalidMFACode = errors.New("invalid mfa code")
+
+// * неизвестный email или неверный пароль, причина не раскрывается
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// * InsufficientStockError - на складе не хватает товара для заказа
+type InsufficientStockError struct {
+	ProductID int64
+	Requested int64
+	Available int64
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %d: requested %d, available %d", e.ProductID, e.Requested, e.Available)
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+// * виды ошибок хранилища, проверяются через errors.Is
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrDuplicateEmail = errors.New("duplicate email")
+	ErrForeignKey     = errors.New("foreign key violation")
+	ErrConflict       = errors.New("conflict")
+)
+
+// * коды ошибок MySQL
+const (
+	mysqlErrDupEntry           = 1062
+	mysqlErrLockWaitTimeout    = 1205
+	mysqlErrDeadlock           = 1213
+	mysqlErrRowIsReferenced    = 1451
+	mysqlErrNoReferencedRow    = 1452
+	mysqlErrRowIsReferencedOld = 1217
+	mysqlErrNoReferencedRowOld = 1216
+)
+
+// * Error - типизированная ошибка хранилища.
+// * Kind - один из ErrNotFound, ErrDuplicateEmail, ErrForeignKey, ErrConflict,
+// * Resource - сущность (product, order, user, session), Err - исходная ошибка
+type Error struct {
+	Kind     error
+	Resource string
+	Msg      string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return e.Msg + ": " + e.Err.Error()
+}
+
+// * errors.Is находит и вид ошибки, и исходную ошибку (например sql.ErrNoRows)
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+func newError(kind error, resource, msg string, err error) error {
+	return &Error{Kind: kind, Resource: resource, Msg: msg, Err: err}
+}
+
+// * оборачивает ошибку базы данных, определяя ее вид по sql.ErrNoRows и номеру ошибки MySQL.
+// * Нераспознанные ошибки оборачиваются как обычно через %w
+func dbError(resource, msg string, err error) error {
+	// * уже типизированная ошибка (например из вложенной функции транзакции) сохраняет свою сущность
+	var se *Error
+	if errors.As(err, &se) {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+
+	if kind := errorKind(resource, err); kind != nil {
+		return newError(kind, resource, msg, err)
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func errorKind(resource string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return nil
+	}
+
+	switch me.Number {
+	case mysqlErrDupEntry:
+		if resource == "user" {
+			return ErrDuplicateEmail
+		}
+
+		return ErrConflict
+	case mysqlErrRowIsReferenced, mysqlErrNoReferencedRow, mysqlErrRowIsReferencedOld, mysqlErrNoReferencedRowOld:
+		return ErrForeignKey
+	case mysqlErrDeadlock, mysqlErrLockWaitTimeout:
+		return ErrConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package storer
+
+import (
+	"errors"
+	"fmt"
+)
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+var ErrInvalidStatusTransition = errors.New("invalid order status transition")
+
+// * допустимые переходы статусов заказа:
+// * pending -> paid -> shipped -> delivered
+// * pending -> cancelled, paid -> refunded, delivered -> refunded
+// * cancelled и refunded - конечные состояния
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderStatusTransitions[s]
+	return ok
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// * проверка перехода, ошибка оборачивает ErrInvalidStatusTransition
+func (s OrderStatus) ValidateTransition(next OrderStatus) error {
+	if !next.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidStatusTransition, next)
+	}
+
+	if !s.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, s, next)
+	}
+
+	return nil
+}
+
+// * товар зарезервирован за заказом пока он не отправлен
+func (s OrderStatus) holdsStock() bool {
+	return s == OrderStatusPending || s == OrderStatusPaid
+}
+
+// * при отмене или возврате до отправки товар возвращается на склад
+func (s OrderStatus) releasesStock(next OrderStatus) bool {
+	return s.holdsStock() && (next == OrderStatusCancelled || next == OrderStatusRefunded)
+}
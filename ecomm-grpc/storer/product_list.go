@@ -0,0 +1,189 @@
+package storer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	DefaultProductPageSize = 20
+	MaxProductPageSize     = 100
+)
+
+type ProductSortField string
+
+const (
+	ProductSortID        ProductSortField = "id"
+	ProductSortPrice     ProductSortField = "price"
+	ProductSortRating    ProductSortField = "rating"
+	ProductSortCreatedAt ProductSortField = "created_at"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// * параметры выборки товаров: фильтры, сортировка и курсор страницы
+type ListProductsParams struct {
+	PageSize    int
+	Cursor      string
+	Category    string
+	MinPrice    float64
+	MaxPrice    float64
+	InStockOnly bool
+	SortBy      ProductSortField
+	SortDesc    bool
+}
+
+// * колонки, по которым разрешена сортировка
+var productSortColumns = map[ProductSortField]string{
+	ProductSortID:        "id",
+	ProductSortPrice:     "price",
+	ProductSortRating:    "rating",
+	ProductSortCreatedAt: "created_at",
+}
+
+func (p *ListProductsParams) normalize() error {
+	if p.PageSize <= 0 {
+		p.PageSize = DefaultProductPageSize
+	}
+
+	if p.PageSize > MaxProductPageSize {
+		p.PageSize = MaxProductPageSize
+	}
+
+	if p.SortBy == "" {
+		p.SortBy = ProductSortID
+	}
+
+	if _, ok := productSortColumns[p.SortBy]; !ok {
+		return fmt.Errorf("unknown sort field %q", p.SortBy)
+	}
+
+	return nil
+}
+
+// * productCursor - позиция последнего товара на странице (keyset pagination).
+// * Для клиента это непрозрачная строка base64
+type productCursor struct {
+	SortBy    ProductSortField `json:"s"`
+	SortDesc  bool             `json:"d"`
+	ID        int64            `json:"id"`
+	Price     float64          `json:"p,omitempty"`
+	Rating    int64            `json:"r,omitempty"`
+	CreatedAt time.Time        `json:"c,omitempty"`
+}
+
+func newProductCursor(p *Product, params *ListProductsParams) productCursor {
+	return productCursor{
+		SortBy:    params.SortBy,
+		SortDesc:  params.SortDesc,
+		ID:        p.ID,
+		Price:     priceKey(p.Price),
+		Rating:    p.Rating,
+		CreatedAt: p.CreatedAt,
+	}
+}
+
+// * значение колонки сортировки для условия WHERE
+func (c productCursor) value() interface{} {
+	switch c.SortBy {
+	case ProductSortPrice:
+		return c.Price
+	case ProductSortRating:
+		return c.Rating
+	case ProductSortCreatedAt:
+		return c.CreatedAt
+	}
+
+	return c.ID
+}
+
+func (c productCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// * курсор должен быть выдан для той же сортировки
+func decodeProductCursor(s string, params *ListProductsParams) (*productCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var c productCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	if c.SortBy != params.SortBy || c.SortDesc != params.SortDesc {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	return &c, nil
+}
+
+// * цена хранится как decimal(10,2), сравниваем с округлением до центов
+func priceKey(price float32) float64 {
+	return math.Round(float64(price)*100) / 100
+}
+
+// * сравнение товара с курсором в порядке сортировки: -1 раньше, 0 тот же, 1 позже
+func compareProducts(p *Product, c *productCursor, sortBy ProductSortField) int {
+	var primary int
+
+	switch sortBy {
+	case ProductSortPrice:
+		primary = compareFloat(priceKey(p.Price), c.Price)
+	case ProductSortRating:
+		primary = compareInt(p.Rating, c.Rating)
+	case ProductSortCreatedAt:
+		primary = p.CreatedAt.Compare(c.CreatedAt)
+	}
+
+	if primary != 0 {
+		return primary
+	}
+
+	return compareInt(p.ID, c.ID)
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// * выбирается на один товар больше размера страницы, чтобы узнать есть ли следующая
+func nextProductPage(products []*Product, params *ListProductsParams) ([]*Product, string) {
+	if len(products) <= params.PageSize {
+		return products, ""
+	}
+
+	products = products[:params.PageSize]
+
+	return products, newProductCursor(products[len(products)-1], params).encode()
+}
@@ -0,0 +1,225 @@
+package storer
+
+import (
+	"errors"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	// * сколько слов вокруг первого совпадения попадает в сниппет
+	snippetWordsBefore = 8
+	snippetWordsAfter  = 16
+
+	snippetMarkOpen  = "<mark>"
+	snippetMarkClose = "</mark>"
+)
+
+var ErrEmptySearchQuery = errors.New("empty search query")
+
+type SearchProductsParams struct {
+	Query string
+	Limit int
+}
+
+// * ProductSearchHit - найденный товар с релевантностью и сниппетом.
+// * В сниппете совпавшие слова обернуты в <mark>, остальной текст экранирован для HTML
+type ProductSearchHit struct {
+	Product
+	Relevance float64 `db:"relevance"`
+	Snippet   string  `db:"-"`
+}
+
+// * возвращает уникальные слова запроса в нижнем регистре
+func (p *SearchProductsParams) normalize() ([]string, error) {
+	if p.Limit <= 0 {
+		p.Limit = DefaultSearchLimit
+	}
+
+	if p.Limit > MaxSearchLimit {
+		p.Limit = MaxSearchLimit
+	}
+
+	terms := uniqueTerms(tokenize(p.Query))
+
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	return terms, nil
+}
+
+// * веса полей для поиска в памяти, название важнее описания
+var searchFieldWeights = []struct {
+	field  func(*Product) string
+	weight float64
+}{
+	{func(p *Product) string { return p.Name }, 3},
+	{func(p *Product) string { return p.Category }, 2},
+	{func(p *Product) string { return p.Description }, 1},
+}
+
+// * релевантность товара для поиска без MySQL: число совпавших слов с учетом веса поля
+func searchRelevance(p *Product, terms []string) float64 {
+	set := termSet(terms)
+
+	var relevance float64
+	for _, f := range searchFieldWeights {
+		for _, t := range tokenize(f.field(p)) {
+			if set[t] {
+				relevance += f.weight
+			}
+		}
+	}
+
+	return relevance
+}
+
+// * сортировка по убыванию релевантности, при равенстве по id
+func sortSearchHits(hits []*ProductSearchHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Relevance != hits[j].Relevance {
+			return hits[i].Relevance > hits[j].Relevance
+		}
+
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+// * сниппет строится из описания, если совпадений в нем нет - из названия
+func productSnippet(p *Product, terms []string) string {
+	if s := highlightSnippet(p.Description, terms); s != "" {
+		return s
+	}
+
+	return highlightSnippet(p.Name, terms)
+}
+
+type wordSpan struct {
+	start, end int
+}
+
+// * границы слов в байтах, слово - последовательность букв и цифр
+func wordSpans(s string) []wordSpan {
+	var (
+		spans []wordSpan
+		start = -1
+	)
+
+	for i, r := range s {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, wordSpan{start, i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, wordSpan{start, len(s)})
+	}
+
+	return spans
+}
+
+func tokenize(s string) []string {
+	spans := wordSpans(s)
+	tokens := make([]string, 0, len(spans))
+
+	for _, sp := range spans {
+		tokens = append(tokens, strings.ToLower(s[sp.start:sp.end]))
+	}
+
+	return tokens
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	terms := make([]string, 0, len(tokens))
+
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+
+	return terms
+}
+
+func termSet(terms []string) map[string]bool {
+	set := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		set[t] = true
+	}
+
+	return set
+}
+
+// * фрагмент текста вокруг первого совпадения с подсвеченными словами,
+// * пустая строка если совпадений нет
+func highlightSnippet(text string, terms []string) string {
+	spans := wordSpans(text)
+	set := termSet(terms)
+
+	first := -1
+	for i, sp := range spans {
+		if set[strings.ToLower(text[sp.start:sp.end])] {
+			first = i
+			break
+		}
+	}
+
+	if first < 0 {
+		return ""
+	}
+
+	lo := max(first-snippetWordsBefore, 0)
+	hi := min(first+snippetWordsAfter, len(spans)-1)
+
+	start, end := spans[lo].start, spans[hi].end
+	if lo == 0 {
+		start = 0
+	}
+
+	if hi == len(spans)-1 {
+		end = len(text)
+	}
+
+	var b strings.Builder
+
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	pos := start
+	for _, sp := range spans[lo : hi+1] {
+		word := text[sp.start:sp.end]
+
+		if !set[strings.ToLower(word)] {
+			continue
+		}
+
+		b.WriteString(html.EscapeString(text[pos:sp.start]))
+		b.WriteString(snippetMarkOpen)
+		b.WriteString(html.EscapeString(word))
+		b.WriteString(snippetMarkClose)
+		pos = sp.end
+	}
+
+	b.WriteString(html.EscapeString(text[pos:end]))
+
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package storer
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// * Permissions - права роли, в MySQL хранятся в json колонке roles.permissions
+type Permissions []string
+
+func (p Permissions) Value() (driver.Value, error) {
+	if p == nil {
+		p = Permissions{}
+	}
+
+	data, err := json.Marshal([]string(p))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (p *Permissions) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*p = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported permissions type %T", src)
+	}
+
+	return json.Unmarshal(data, (*[]string)(p))
+}
@@ -0,0 +1,43 @@
+package storer
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSessionRevoked     = errors.New("session revoked")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// * ротация токена обновления (OAuth 2.0 Security BCP): каждое обновление заменяет сессию новой
+// * в том же семействе. Повторное предъявление уже замененного токена означает, что он был украден,
+// * поэтому отзывается все семейство
+
+// * ротировать можно только действующую, еще не замененную сессию того же пользователя
+func checkRotation(old, next *Session) error {
+	switch {
+	case old.RotatedAt != nil:
+		return ErrRefreshTokenReused
+	case old.IsRevoked:
+		return ErrSessionRevoked
+	case old.UserEmail != next.UserEmail:
+		return ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// * семейство сессии, для сессий без семейства (созданных до ротации) - сама сессия
+func sessionFamily(s *Session) string {
+	if s.FamilyID == "" {
+		return s.ID
+	}
+
+	return s.FamilyID
+}
+
+// * активная сессия - не отозвана, не заменена ротацией и не истекла
+func (s *Session) IsActive(now time.Time) bool {
+	return !s.IsRevoked && s.RotatedAt == nil && (s.ExpiresAt == nil || s.ExpiresAt.After(now))
+}
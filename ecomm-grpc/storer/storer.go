@@ -0,0 +1,111 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// * Storer - общий интерфейс хранилища, его реализуют MySQLStorer и MemoryStorer
+type Storer interface {
+	CreateProduct(ctx context.Context, p *Product) (*Product, error)
+	GetProduct(ctx context.Context, id int64) (*Product, error)
+	ListProducts(ctx context.Context, params ListProductsParams) ([]*Product, string, error)
+	SearchProducts(ctx context.Context, params SearchProductsParams) ([]*ProductSearchHit, error)
+	UpdateProduct(ctx context.Context, p *Product) (*Product, error)
+	DeleteProduct(ctx context.Context, id int64) error
+
+	CreateOrder(ctx context.Context, o *Order) (*Order, error)
+	GetOrder(ctx context.Context, userID int64) (*Order, error)
+	GetOrderByID(ctx context.Context, id int64) (*Order, error)
+	ListOrders(ctx context.Context) ([]*Order, error)
+	UpdateOrderStatus(ctx context.Context, id int64, status OrderStatus) (*Order, error)
+	DeleteOrder(ctx context.Context, id int64) error
+
+	CreateUser(ctx context.Context, u *User, roles ...string) (*User, error)
+	GetUser(ctx context.Context, email string) (*User, error)
+	ListUsers(ctx context.Context) ([]*User, error)
+	UpdateUser(ctx context.Context, u *User) (*User, error)
+	DeleteUser(ctx context.Context, id int64) error
+	VerifyEmail(ctx context.Context, id int64, email string) (*User, error)
+	CreatePasswordReset(ctx context.Context, r *PasswordReset) (*PasswordReset, error)
+	ResetPassword(ctx context.Context, tokenHash, password string) (*User, error)
+	RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) error
+	ChangePassword(ctx context.Context, userID int64, oldHash, newHash, keepSessionID string) (*User, error)
+
+	GetTOTP(ctx context.Context, userID int64) (*TOTP, error)
+	SetTOTPSecret(ctx context.Context, userID int64, secret string) error
+	EnableTOTP(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+	CountRecoveryCodes(ctx context.Context, userID int64) (int64, error)
+	DeleteTOTP(ctx context.Context, userID int64) error
+
+	GetLoginAttempt(ctx context.Context, key string) (*LoginAttempt, error)
+	AddLoginFailure(ctx context.Context, key string, window time.Duration) (*LoginAttempt, error)
+	RemoveLoginFailure(ctx context.Context, key string, failureAt, previousAt time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+
+	ListRoles(ctx context.Context) ([]*Role, error)
+	GetUserRoles(ctx context.Context, userID int64) ([]*Role, error)
+	AssignRole(ctx context.Context, userID int64, role string) error
+	UnassignRole(ctx context.Context, userID int64, role string) error
+
+	CreateSession(ctx context.Context, s *Session) (*Session, error)
+	GetSession(ctx context.Context, id string) (*Session, error)
+	RotateSession(ctx context.Context, id string, next *Session) (*Session, error)
+	ListSessions(ctx context.Context, email string) ([]*Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeUserSessions(ctx context.Context, email string) error
+	DeleteSession(ctx context.Context, id string) error
+}
+
+var (
+	_ Storer = (*MySQLStorer)(nil)
+	_ Storer = (*MemoryStorer)(nil)
+)
+
+type productQuantity struct {
+	productID int64
+	quantity  int64
+}
+
+// * цена каждого товара из заказа. Сервер считает суммы заказа по цене, прочитанной до транзакции
+func orderedPrices(items []OrderItem) map[int64]float32 {
+	prices := make(map[int64]float32, len(items))
+	for _, oi := range items {
+		prices[oi.ProductID] = oi.Price
+	}
+
+	return prices
+}
+
+// * цена товара изменилась после расчета заказа - заказ не создается, клиент повторяет запрос
+func checkPrice(productID int64, ordered, current float32) error {
+	if math.Round(float64(ordered)*100) != math.Round(float64(current)*100) {
+		return newError(ErrConflict, "product", "error creating order",
+			fmt.Errorf("price of product %d changed from %.2f to %.2f", productID, ordered, current))
+	}
+
+	return nil
+}
+
+// * суммарное количество по каждому товару, отсортированное по id товара,
+// * чтобы строки блокировались всегда в одном порядке
+func groupQuantities(items []OrderItem) []productQuantity {
+	totals := make(map[int64]int64)
+	for _, oi := range items {
+		totals[oi.ProductID] += oi.Quantity
+	}
+
+	res := make([]productQuantity, 0, len(totals))
+	for id, q := range totals {
+		res = append(res, productQuantity{productID: id, quantity: q})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].productID < res[j].productID })
+
+	return res
+}
@@ -0,0 +1,992 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"davidHwang/ecomm/rbac"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// * MemoryStorer - потокобезопасное хранилище в памяти.
+// * Ведет себя так же как MySQLStorer (включая ошибки внешних ключей и уникальности email),
+// * поэтому подходит для тестов и локального запуска без MySQL
+type MemoryStorer struct {
+	mu sync.RWMutex
+
+	products   map[int64]*Product
+	orders     map[int64]*Order
+	orderItems map[int64][]OrderItem
+	users      map[int64]*User
+	roles      map[string]*Role
+	userRoles  map[int64]map[string]bool
+	sessions   map[string]*Session
+	resets     map[string]*PasswordReset
+	totp       map[int64]*TOTP
+	//* хэш кода восстановления -> использован ли он
+	recoveryCodes map[int64]map[string]bool
+	loginAttempts map[string]*LoginAttempt
+
+	lastProductID   int64
+	lastOrderID     int64
+	lastOrderItemID int64
+	lastUserID      int64
+}
+
+func NewMemoryStorer() *MemoryStorer {
+	ms := &MemoryStorer{
+		products:   make(map[int64]*Product),
+		orders:     make(map[int64]*Order),
+		orderItems: make(map[int64][]OrderItem),
+		users:      make(map[int64]*User),
+		roles:      make(map[string]*Role),
+		userRoles:  make(map[int64]map[string]bool),
+		sessions:   make(map[string]*Session),
+		resets:     make(map[string]*PasswordReset),
+		totp:       make(map[int64]*TOTP),
+
+		recoveryCodes: make(map[int64]map[string]bool),
+		loginAttempts: make(map[string]*LoginAttempt),
+	}
+
+	// * те же роли, что создает миграция add_roles
+	for _, r := range rbac.DefaultRoles {
+		ms.roles[r.Name] = &Role{Name: r.Name, Description: r.Description, Permissions: Permissions(r.Permissions), CreatedAt: time.Now()}
+	}
+
+	return ms
+}
+
+// *PRODUCT
+func (ms *MemoryStorer) CreateProduct(_ context.Context, p *Product) (*Product, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.lastProductID++
+	p.ID = ms.lastProductID
+	p.CreatedAt = time.Now()
+
+	cp := *p
+	ms.products[p.ID] = &cp
+
+	return p, nil
+}
+
+func (ms *MemoryStorer) GetProduct(_ context.Context, id int64) (*Product, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	p, ok := ms.products[id]
+	if !ok {
+		return nil, dbError("product", "error getting product", sql.ErrNoRows)
+	}
+
+	cp := *p
+	return &cp, nil
+}
+
+func (ms *MemoryStorer) ListProducts(_ context.Context, params ListProductsParams) ([]*Product, string, error) {
+	if err := params.normalize(); err != nil {
+		return nil, "", err
+	}
+
+	cursor, err := decodeProductCursor(params.Cursor, &params)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	// * >0 - товар идет после b в выбранном порядке сортировки
+	order := func(a *Product, b *productCursor) int {
+		c := compareProducts(a, b, params.SortBy)
+		if params.SortDesc {
+			return -c
+		}
+
+		return c
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var products []*Product
+	for _, p := range ms.products {
+		if params.Category != "" && p.Category != params.Category {
+			continue
+		}
+
+		if params.MinPrice > 0 && priceKey(p.Price) < params.MinPrice {
+			continue
+		}
+
+		if params.MaxPrice > 0 && priceKey(p.Price) > params.MaxPrice {
+			continue
+		}
+
+		if params.InStockOnly && p.CountInStock <= 0 {
+			continue
+		}
+
+		if cursor != nil && order(p, cursor) <= 0 {
+			continue
+		}
+
+		cp := *p
+		products = append(products, &cp)
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		c := newProductCursor(products[j], &params)
+		return order(products[i], &c) < 0
+	})
+
+	if len(products) > params.PageSize+1 {
+		products = products[:params.PageSize+1]
+	}
+
+	products, next := nextProductPage(products, &params)
+
+	return products, next, nil
+}
+
+// * поиск без MySQL: совпадение по словам в названии, категории и описании
+func (ms *MemoryStorer) SearchProducts(_ context.Context, params SearchProductsParams) ([]*ProductSearchHit, error) {
+	terms, err := params.normalize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var hits []*ProductSearchHit
+	for _, p := range ms.products {
+		relevance := searchRelevance(p, terms)
+		if relevance == 0 {
+			continue
+		}
+
+		hits = append(hits, &ProductSearchHit{
+			Product:   *p,
+			Relevance: relevance,
+			Snippet:   productSnippet(p, terms),
+		})
+	}
+
+	sortSearchHits(hits)
+
+	if len(hits) > params.Limit {
+		hits = hits[:params.Limit]
+	}
+
+	return hits, nil
+}
+
+func (ms *MemoryStorer) UpdateProduct(_ context.Context, p *Product) (*Product, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	old, ok := ms.products[p.ID]
+	if !ok {
+		// * как и UPDATE в MySQL - отсутствие строки не ошибка
+		return p, nil
+	}
+
+	cp := *p
+	cp.CreatedAt = old.CreatedAt
+	ms.products[p.ID] = &cp
+
+	return p, nil
+}
+
+func (ms *MemoryStorer) DeleteProduct(_ context.Context, id int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, items := range ms.orderItems {
+		for _, oi := range items {
+			if oi.ProductID == id {
+				return newError(ErrForeignKey, "product", "error deleting product", fmt.Errorf("product %d is referenced by order %d", id, oi.OrderID))
+			}
+		}
+	}
+
+	delete(ms.products, id)
+
+	return nil
+}
+
+//*ORDER
+
+func (ms *MemoryStorer) CreateOrder(_ context.Context, o *Order) (*Order, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.users[o.UserID]; !ok {
+		return nil, newError(ErrForeignKey, "order", "error creating order", fmt.Errorf("user %d does not exist", o.UserID))
+	}
+
+	prices := orderedPrices(o.Items)
+	quantities := groupQuantities(o.Items)
+	for _, q := range quantities {
+		p, ok := ms.products[q.productID]
+		if !ok {
+			return nil, dbError("product", fmt.Sprintf("error locking product %d", q.productID), sql.ErrNoRows)
+		}
+
+		if err := checkPrice(q.productID, prices[q.productID], p.Price); err != nil {
+			return nil, err
+		}
+
+		if p.CountInStock < q.quantity {
+			return nil, fmt.Errorf("error creating order: %w", &InsufficientStockError{ProductID: q.productID, Requested: q.quantity, Available: p.CountInStock})
+		}
+	}
+
+	for _, q := range quantities {
+		ms.products[q.productID].CountInStock -= q.quantity
+	}
+
+	if o.Status == "" {
+		o.Status = OrderStatusPending
+	}
+
+	ms.lastOrderID++
+	o.ID = ms.lastOrderID
+	o.CreatedAt = time.Now()
+
+	items := make([]OrderItem, 0, len(o.Items))
+	for _, oi := range o.Items {
+		ms.lastOrderItemID++
+		oi.ID = ms.lastOrderItemID
+		oi.OrderID = o.ID
+		items = append(items, oi)
+	}
+
+	co := *o
+	co.Items = nil
+	ms.orders[o.ID] = &co
+	ms.orderItems[o.ID] = items
+
+	return o, nil
+}
+
+func (ms *MemoryStorer) GetOrder(_ context.Context, userID int64) (*Order, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var found *Order
+	for _, o := range ms.orders {
+		if o.UserID == userID && (found == nil || o.ID < found.ID) {
+			found = o
+		}
+	}
+
+	if found == nil {
+		return nil, dbError("order", "error getting order", sql.ErrNoRows)
+	}
+
+	return ms.copyOrder(found), nil
+}
+
+func (ms *MemoryStorer) GetOrderByID(_ context.Context, id int64) (*Order, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	o, ok := ms.orders[id]
+	if !ok {
+		return nil, dbError("order", "error getting order", sql.ErrNoRows)
+	}
+
+	return ms.copyOrder(o), nil
+}
+
+func (ms *MemoryStorer) ListOrders(_ context.Context) ([]*Order, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	orders := make([]*Order, 0, len(ms.orders))
+	for _, o := range ms.orders {
+		orders = append(orders, ms.copyOrder(o))
+	}
+
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+
+	return orders, nil
+}
+
+func (ms *MemoryStorer) UpdateOrderStatus(_ context.Context, id int64, status OrderStatus) (*Order, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	o, ok := ms.orders[id]
+	if !ok {
+		return nil, dbError("order", "error getting order status", sql.ErrNoRows)
+	}
+
+	if err := o.Status.ValidateTransition(status); err != nil {
+		return nil, fmt.Errorf("error updating order status: %w", err)
+	}
+
+	if o.Status.releasesStock(status) {
+		ms.restock(id)
+	}
+
+	o.Status = status
+	o.UpdatedAt = toTimePtr(time.Now())
+
+	return ms.copyOrder(o), nil
+}
+
+func (ms *MemoryStorer) DeleteOrder(_ context.Context, id int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	o, ok := ms.orders[id]
+	if !ok {
+		return dbError("order", "error deleting order", sql.ErrNoRows)
+	}
+
+	if o.Status.holdsStock() {
+		ms.restock(id)
+	}
+
+	delete(ms.orderItems, id)
+	delete(ms.orders, id)
+
+	return nil
+}
+
+// * возврат товаров заказа на склад, вызывать под блокировкой
+func (ms *MemoryStorer) restock(orderID int64) {
+	for _, q := range groupQuantities(ms.orderItems[orderID]) {
+		if p, ok := ms.products[q.productID]; ok {
+			p.CountInStock += q.quantity
+		}
+	}
+}
+
+func toTimePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// * копия заказа вместе с его элементами, вызывать под блокировкой
+func (ms *MemoryStorer) copyOrder(o *Order) *Order {
+	co := *o
+	co.Items = append([]OrderItem(nil), ms.orderItems[o.ID]...)
+
+	return &co
+}
+
+//* USERS
+
+func (ms *MemoryStorer) CreateUser(_ context.Context, u *User, roles ...string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.findUserByEmail(u.Email) != nil {
+		return nil, newError(ErrDuplicateEmail, "user", "error inserting user", fmt.Errorf("duplicate email %q", u.Email))
+	}
+
+	for _, role := range roles {
+		if _, ok := ms.roles[role]; !ok {
+			return nil, newError(ErrForeignKey, "role", "error assigning role", fmt.Errorf("role %q does not exist", role))
+		}
+	}
+
+	ms.lastUserID++
+	u.ID = ms.lastUserID
+	u.CreatedAt = time.Now()
+
+	cu := *u
+	ms.users[u.ID] = &cu
+
+	if len(roles) > 0 {
+		ms.userRoles[u.ID] = make(map[string]bool)
+		for _, role := range roles {
+			ms.userRoles[u.ID][role] = true
+		}
+	}
+
+	return u, nil
+}
+
+func (ms *MemoryStorer) GetUser(_ context.Context, email string) (*User, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	u := ms.findUserByEmail(email)
+	if u == nil {
+		return nil, dbError("user", "error getting user", sql.ErrNoRows)
+	}
+
+	cu := *u
+	return &cu, nil
+}
+
+func (ms *MemoryStorer) ListUsers(_ context.Context) ([]*User, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	users := make([]*User, 0, len(ms.users))
+	for _, u := range ms.users {
+		cu := *u
+		users = append(users, &cu)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users, nil
+}
+
+func (ms *MemoryStorer) UpdateUser(_ context.Context, u *User) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	old, ok := ms.users[u.ID]
+	if !ok {
+		return u, nil
+	}
+
+	if other := ms.findUserByEmail(u.Email); other != nil && other.ID != u.ID {
+		return nil, newError(ErrDuplicateEmail, "user", "error updating user", fmt.Errorf("duplicate email %q", u.Email))
+	}
+
+	cu := *u
+	cu.CreatedAt = old.CreatedAt
+	ms.users[u.ID] = &cu
+
+	return u, nil
+}
+
+func (ms *MemoryStorer) DeleteUser(_ context.Context, id int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, o := range ms.orders {
+		if o.UserID == id {
+			return newError(ErrForeignKey, "user", "error deleting user", fmt.Errorf("user %d is referenced by order %d", id, o.ID))
+		}
+	}
+
+	delete(ms.users, id)
+	delete(ms.userRoles, id)
+
+	for hash, r := range ms.resets {
+		if r.UserID == id {
+			delete(ms.resets, hash)
+		}
+	}
+
+	delete(ms.totp, id)
+	delete(ms.recoveryCodes, id)
+
+	return nil
+}
+
+func (ms *MemoryStorer) VerifyEmail(_ context.Context, id int64, email string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	u, ok := ms.users[id]
+	if !ok || u.Email != email {
+		return nil, dbError("user", "error getting user", sql.ErrNoRows)
+	}
+
+	if u.EmailVerifiedAt != nil {
+		return nil, ErrEmailAlreadyVerified
+	}
+
+	u.EmailVerifiedAt = toTimePtr(time.Now())
+
+	cu := *u
+	return &cu, nil
+}
+
+//* PASSWORD RESET
+
+func (ms *MemoryStorer) CreatePasswordReset(_ context.Context, r *PasswordReset) (*PasswordReset, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.users[r.UserID]; !ok {
+		return nil, newError(ErrForeignKey, "password reset", "error inserting password reset", fmt.Errorf("user %d does not exist", r.UserID))
+	}
+
+	if _, ok := ms.resets[r.TokenHash]; ok {
+		return nil, newError(ErrConflict, "password reset", "error inserting password reset", errors.New("duplicate token hash"))
+	}
+
+	r.CreatedAt = time.Now()
+
+	cr := *r
+	ms.resets[r.TokenHash] = &cr
+
+	return r, nil
+}
+
+func (ms *MemoryStorer) ResetPassword(_ context.Context, tokenHash, password string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+
+	r, ok := ms.resets[tokenHash]
+	if !ok || r.UsedAt != nil || !r.ExpiresAt.After(now) {
+		return nil, dbError("password reset", "error getting password reset", sql.ErrNoRows)
+	}
+
+	u, ok := ms.users[r.UserID]
+	if !ok {
+		return nil, dbError("user", "error getting user", sql.ErrNoRows)
+	}
+
+	u.Password = password
+	u.UpdatedAt = toTimePtr(now)
+
+	for _, other := range ms.resets {
+		if other.UserID == u.ID && other.UsedAt == nil {
+			other.UsedAt = toTimePtr(now)
+		}
+	}
+
+	for _, s := range ms.sessions {
+		if s.UserEmail == u.Email {
+			s.IsRevoked = true
+		}
+	}
+
+	cu := *u
+	return &cu, nil
+}
+
+func (ms *MemoryStorer) RehashPassword(_ context.Context, userID int64, oldHash, newHash string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if u, ok := ms.users[userID]; ok && u.Password == oldHash {
+		u.Password = newHash
+	}
+
+	return nil
+}
+
+func (ms *MemoryStorer) ChangePassword(_ context.Context, userID int64, oldHash, newHash, keepSessionID string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	u, ok := ms.users[userID]
+	if !ok {
+		return nil, dbError("user", "error getting user", sql.ErrNoRows)
+	}
+
+	if u.Password != oldHash {
+		return nil, newError(ErrConflict, "user", "error changing password", errors.New("password was changed concurrently"))
+	}
+
+	now := time.Now()
+	u.Password = newHash
+	u.UpdatedAt = toTimePtr(now)
+
+	for _, r := range ms.resets {
+		if r.UserID == u.ID && r.UsedAt == nil {
+			r.UsedAt = toTimePtr(now)
+		}
+	}
+
+	for _, s := range ms.sessions {
+		if s.UserEmail == u.Email && s.ID != keepSessionID {
+			s.IsRevoked = true
+		}
+	}
+
+	cu := *u
+	return &cu, nil
+}
+
+//* TOTP
+
+func (ms *MemoryStorer) GetTOTP(_ context.Context, userID int64) (*TOTP, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	t, ok := ms.totp[userID]
+	if !ok {
+		return nil, dbError("totp", "error getting totp", sql.ErrNoRows)
+	}
+
+	ct := *t
+	return &ct, nil
+}
+
+func (ms *MemoryStorer) SetTOTPSecret(_ context.Context, userID int64, secret string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.users[userID]; !ok {
+		return newError(ErrForeignKey, "totp", "error saving totp secret", fmt.Errorf("user %d does not exist", userID))
+	}
+
+	if t, ok := ms.totp[userID]; ok && t.EnabledAt != nil {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	ms.totp[userID] = &TOTP{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+
+	return nil
+}
+
+func (ms *MemoryStorer) EnableTOTP(_ context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	t, ok := ms.totp[userID]
+	if !ok || t.EnabledAt != nil {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	t.EnabledAt = toTimePtr(time.Now())
+	t.LastUsedStep = step
+
+	codes := make(map[string]bool, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		codes[hash] = false
+	}
+	ms.recoveryCodes[userID] = codes
+
+	return nil
+}
+
+func (ms *MemoryStorer) UseTOTPStep(_ context.Context, userID int64, step int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	t, ok := ms.totp[userID]
+	if !ok || t.EnabledAt == nil || t.LastUsedStep >= step {
+		return ErrInvalidMFACode
+	}
+
+	t.LastUsedStep = step
+
+	return nil
+}
+
+func (ms *MemoryStorer) UseRecoveryCode(_ context.Context, userID int64, codeHash string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	used, ok := ms.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return ErrInvalidMFACode
+	}
+
+	ms.recoveryCodes[userID][codeHash] = true
+
+	return nil
+}
+
+func (ms *MemoryStorer) CountRecoveryCodes(_ context.Context, userID int64) (int64, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var n int64
+	for _, used := range ms.recoveryCodes[userID] {
+		if !used {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (ms *MemoryStorer) DeleteTOTP(_ context.Context, userID int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.totp, userID)
+	delete(ms.recoveryCodes, userID)
+
+	return nil
+}
+
+//* LOGIN ATTEMPTS
+
+func (ms *MemoryStorer) GetLoginAttempt(_ context.Context, key string) (*LoginAttempt, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	a, ok := ms.loginAttempts[key]
+	if !ok {
+		return nil, dbError("login attempt", "error getting login attempt", sql.ErrNoRows)
+	}
+
+	ca := *a
+	return &ca, nil
+}
+
+func (ms *MemoryStorer) AddLoginFailure(_ context.Context, key string, window time.Duration) (*LoginAttempt, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+
+	a, ok := ms.loginAttempts[key]
+	if !ok || a.LastFailureAt.Before(now.Add(-window)) {
+		a = &LoginAttempt{Key: key}
+		ms.loginAttempts[key] = a
+	}
+
+	a.Failures++
+	a.LastFailureAt = now
+
+	ca := *a
+	return &ca, nil
+}
+
+func (ms *MemoryStorer) RemoveLoginFailure(_ context.Context, key string, failureAt, previousAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	a, ok := ms.loginAttempts[key]
+	if !ok {
+		return nil
+	}
+
+	a.Failures--
+	if a.Failures <= 0 {
+		delete(ms.loginAttempts, key)
+		return nil
+	}
+
+	if a.LastFailureAt.Equal(failureAt) && !previousAt.IsZero() {
+		a.LastFailureAt = previousAt
+	}
+
+	return nil
+}
+
+func (ms *MemoryStorer) ResetLoginAttempts(_ context.Context, key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.loginAttempts, key)
+
+	return nil
+}
+
+//* ROLES
+
+func (ms *MemoryStorer) ListRoles(_ context.Context) ([]*Role, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	roles := make([]*Role, 0, len(ms.roles))
+	for _, r := range ms.roles {
+		roles = append(roles, copyRole(r))
+	}
+
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	return roles, nil
+}
+
+func (ms *MemoryStorer) GetUserRoles(_ context.Context, userID int64) ([]*Role, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	roles := make([]*Role, 0, len(ms.userRoles[userID]))
+	for name := range ms.userRoles[userID] {
+		roles = append(roles, copyRole(ms.roles[name]))
+	}
+
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	return roles, nil
+}
+
+func (ms *MemoryStorer) AssignRole(_ context.Context, userID int64, role string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.users[userID]; !ok {
+		return newError(ErrForeignKey, "role", "error assigning role", fmt.Errorf("user %d does not exist", userID))
+	}
+
+	if _, ok := ms.roles[role]; !ok {
+		return newError(ErrForeignKey, "role", "error assigning role", fmt.Errorf("role %q does not exist", role))
+	}
+
+	if ms.userRoles[userID] == nil {
+		ms.userRoles[userID] = make(map[string]bool)
+	}
+
+	ms.userRoles[userID][role] = true
+
+	return nil
+}
+
+func (ms *MemoryStorer) UnassignRole(_ context.Context, userID int64, role string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.userRoles[userID], role)
+
+	return nil
+}
+
+func copyRole(r *Role) *Role {
+	cr := *r
+	cr.Permissions = append(Permissions(nil), r.Permissions...)
+
+	return &cr
+}
+
+// * поиск пользователя по email, вызывать под блокировкой
+func (ms *MemoryStorer) findUserByEmail(email string) *User {
+	for _, u := range ms.users {
+		if u.Email == email {
+			return u
+		}
+	}
+
+	return nil
+}
+
+//* SESSIONS
+
+func (ms *MemoryStorer) CreateSession(_ context.Context, s *Session) (*Session, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.sessions[s.ID]; ok {
+		return nil, newError(ErrConflict, "session", "error creating session", fmt.Errorf("duplicate id %q", s.ID))
+	}
+
+	s.CreatedAt = time.Now()
+	s.FamilyID = sessionFamily(s)
+
+	cs := *s
+	ms.sessions[s.ID] = &cs
+
+	return s, nil
+}
+
+func (ms *MemoryStorer) GetSession(_ context.Context, id string) (*Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	s, ok := ms.sessions[id]
+	if !ok {
+		return nil, dbError("session", "error getting session", sql.ErrNoRows)
+	}
+
+	cs := *s
+	return &cs, nil
+}
+
+// * заменяет сессию id новой сессией next в том же семействе.
+// * Если сессия id уже была заменена, отзывает все семейство и возвращает ErrRefreshTokenReused
+func (ms *MemoryStorer) RotateSession(_ context.Context, id string, next *Session) (*Session, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	old, ok := ms.sessions[id]
+	if !ok {
+		return nil, dbError("session", "error rotating session", sql.ErrNoRows)
+	}
+
+	if err := checkRotation(old, next); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			family := sessionFamily(old)
+
+			for _, s := range ms.sessions {
+				if sessionFamily(s) == family {
+					s.IsRevoked = true
+				}
+			}
+		}
+
+		return nil, fmt.Errorf("error rotating session %q: %w", id, err)
+	}
+
+	if _, ok := ms.sessions[next.ID]; ok {
+		return nil, newError(ErrConflict, "session", "error rotating session", fmt.Errorf("duplicate id %q", next.ID))
+	}
+
+	now := time.Now()
+	old.RotatedAt = &now
+
+	next.FamilyID = sessionFamily(old)
+	next.ParentID = old.ID
+	next.CreatedAt = now
+
+	cs := *next
+	ms.sessions[next.ID] = &cs
+
+	return next, nil
+}
+
+// * активные сессии пользователя, новые первыми
+func (ms *MemoryStorer) ListSessions(_ context.Context, email string) ([]*Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	now := time.Now()
+	sessions := make([]*Session, 0)
+
+	for _, s := range ms.sessions {
+		if s.UserEmail == email && s.IsActive(now) {
+			cs := *s
+			sessions = append(sessions, &cs)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+		}
+
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+// * отмена сессии
+func (ms *MemoryStorer) RevokeSession(_ context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if s, ok := ms.sessions[id]; ok {
+		s.IsRevoked = true
+	}
+
+	return nil
+}
+
+// * выход на всех устройствах
+func (ms *MemoryStorer) RevokeUserSessions(_ context.Context, email string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, s := range ms.sessions {
+		if s.UserEmail == email {
+			s.IsRevoked = true
+		}
+	}
+
+	return nil
+}
+
+func (ms *MemoryStorer) DeleteSession(_ context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.sessions, id)
+
+	return nil
+}
@@ -2,7 +2,11 @@ package storer
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -21,13 +25,13 @@ func (ms *MySQLStorer) CreateProduct(ctx context.Context, p *Product) (*Product,
 	res, err := ms.db.NamedExecContext(ctx, `INSERT INTO products (name, image, category, description, rating, num_reviews, price, count_in_stock) VALUES (:name, :image, :category, :description, :rating, :num_reviews, :price, :count_in_stock)`, p)
 
 	if err != nil {
-		return nil, fmt.Errorf("error inserting product: %w", err)
+		return nil, dbError("product", "error inserting product", err)
 	}
 
 	id, err := res.LastInsertId()
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting last inserted id: %w", err)
+		return nil, dbError("product", "error getting last inserted id", err)
 	}
 
 	p.ID = id
@@ -41,29 +45,123 @@ func (ms *MySQLStorer) GetProduct(ctx context.Context, id int64) (*Product, erro
 	err := ms.db.GetContext(ctx, &p, `SELECT * FROM products WHERE id=?`, id)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting product: %w", err)
+		return nil, dbError("product", "error getting product", err)
 	}
 
 	return &p, nil
 }
 
-func (ms *MySQLStorer) ListProducts(ctx context.Context) ([]*Product, error) {
+// * выборка страницы товаров с фильтрами, возвращает товары и курсор следующей страницы
+func (ms *MySQLStorer) ListProducts(ctx context.Context, params ListProductsParams) ([]*Product, string, error) {
+	if err := params.normalize(); err != nil {
+		return nil, "", err
+	}
+
+	cursor, err := decodeProductCursor(params.Cursor, &params)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	col := productSortColumns[params.SortBy]
+	dir, op := "ASC", ">"
+
+	if params.SortDesc {
+		dir, op = "DESC", "<"
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	if params.Category != "" {
+		where = append(where, "category = ?")
+		args = append(args, params.Category)
+	}
+
+	if params.MinPrice > 0 {
+		where = append(where, "price >= ?")
+		args = append(args, params.MinPrice)
+	}
+
+	if params.MaxPrice > 0 {
+		where = append(where, "price <= ?")
+		args = append(args, params.MaxPrice)
+	}
+
+	if params.InStockOnly {
+		where = append(where, "count_in_stock > 0")
+	}
+
+	if cursor != nil {
+		if params.SortBy == ProductSortID {
+			where = append(where, "id "+op+" ?")
+			args = append(args, cursor.ID)
+		} else {
+			where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", col, op, col, op))
+			args = append(args, cursor.value(), cursor.value(), cursor.ID)
+		}
+	}
+
+	query := `SELECT * FROM products`
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	if params.SortBy == ProductSortID {
+		query += " ORDER BY id " + dir
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", col, dir, dir)
+	}
+
+	query += " LIMIT ?"
+	args = append(args, params.PageSize+1)
+
 	var products []*Product
 
-	err := ms.db.SelectContext(ctx, &products, `SELECT * FROM products`)
+	err = ms.db.SelectContext(ctx, &products, query, args...)
 
 	if err != nil {
-		return nil, fmt.Errorf("error listing products: %w", err)
+		return nil, "", dbError("product", "error listing products", err)
 	}
 
-	return products, nil
+	products, next := nextProductPage(products, &params)
+
+	return products, next, nil
+}
+
+// * полнотекстовый поиск по FULLTEXT индексу (name, category, description),
+// * товары упорядочены по релевантности
+func (ms *MySQLStorer) SearchProducts(ctx context.Context, params SearchProductsParams) ([]*ProductSearchHit, error) {
+	terms, err := params.normalize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.Join(terms, " ")
+	var hits []*ProductSearchHit
+
+	err = ms.db.SelectContext(ctx, &hits, `SELECT *, MATCH(name, category, description) AGAINST(? IN NATURAL LANGUAGE MODE) AS relevance FROM products WHERE MATCH(name, category, description) AGAINST(? IN NATURAL LANGUAGE MODE) ORDER BY relevance DESC, id ASC LIMIT ?`, query, query, params.Limit)
+
+	if err != nil {
+		return nil, dbError("product", "error searching products", err)
+	}
+
+	for _, h := range hits {
+		h.Snippet = productSnippet(&h.Product, terms)
+	}
+
+	return hits, nil
 }
 
 func (ms *MySQLStorer) UpdateProduct(ctx context.Context, p *Product) (*Product, error) {
 	_, err := ms.db.NamedExecContext(ctx, `UPDATE products SET name=:name, image=:image, category=:category, description=:description, rating=:rating, num_reviews=:num_reviews, price=:price, count_in_stock=:count_in_stock, updated_at=:updated_at WHERE id=:id`, p)
 
 	if err != nil {
-		return nil, fmt.Errorf("error updating product: %w", err)
+		return nil, dbError("product", "error updating product", err)
 	}
 
 	return p, nil
@@ -73,7 +171,7 @@ func (ms *MySQLStorer) DeleteProduct(ctx context.Context, id int64) error {
 	_, err := ms.db.ExecContext(ctx, `DELETE FROM products WHERE id=?`, id)
 
 	if err != nil {
-		return fmt.Errorf("error deleting product: %w", err)
+		return dbError("product", "error deleting product", err)
 	}
 
 	return nil
@@ -86,10 +184,16 @@ func (ms *MySQLStorer) CreateOrder(ctx context.Context, o *Order) (*Order, error
 	// сделаем транзакцию
 
 	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		err := reserveStock(ctx, tx, o.Items)
+
+		if err != nil {
+			return err
+		}
+
 		order, err := createOrder(ctx, tx, o)
 
 		if err != nil {
-			return fmt.Errorf("MySQLStorer:CreateOrder ## ,error creating order: %w", err)
+			return dbError("order", "MySQLStorer:CreateOrder ## ,error creating order", err)
 		}
 
 		for _, oi := range o.Items {
@@ -98,7 +202,7 @@ func (ms *MySQLStorer) CreateOrder(ctx context.Context, o *Order) (*Order, error
 			err = createOrderItem(ctx, tx, oi)
 
 			if err != nil {
-				return fmt.Errorf("error creating order item: %w", err)
+				return dbError("order", "error creating order item", err)
 			}
 		}
 
@@ -107,7 +211,7 @@ func (ms *MySQLStorer) CreateOrder(ctx context.Context, o *Order) (*Order, error
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("error creating order: %w", err)
+		return nil, dbError("order", "error creating order", err)
 	}
 
 	return o, nil
@@ -115,15 +219,19 @@ func (ms *MySQLStorer) CreateOrder(ctx context.Context, o *Order) (*Order, error
 
 // * создадим приватный метод для создания заказа (order)
 func createOrder(ctx context.Context, tx *sqlx.Tx, o *Order) (*Order, error) {
-	res, err := tx.NamedExecContext(ctx, `INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id) VALUES (:payment_method, :tax_price, :shipping_price, :total_price, :user_id)`, o)
+	if o.Status == "" {
+		o.Status = OrderStatusPending
+	}
+
+	res, err := tx.NamedExecContext(ctx, `INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id, status) VALUES (:payment_method, :tax_price, :shipping_price, :total_price, :user_id, :status)`, o)
 
 	if err != nil {
-		return nil, fmt.Errorf("createOrder: FUNCTION !!! : error inserting order: %w", err)
+		return nil, dbError("order", "createOrder: FUNCTION !!! : error inserting order", err)
 	}
 	id, err := res.LastInsertId()
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting last inserted id: %w", err)
+		return nil, dbError("order", "error getting last inserted id", err)
 	}
 
 	o.ID = id
@@ -138,13 +246,13 @@ func createOrderItem(ctx context.Context, tx *sqlx.Tx, oi OrderItem) error {
 	res, err := tx.NamedExecContext(ctx, `INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (:name, :quantity, :image, :price, :product_id, :order_id)`, oi)
 
 	if err != nil {
-		return fmt.Errorf("error inserting order item: %w", err)
+		return dbError("order", "error inserting order item", err)
 	}
 
 	id, err := res.LastInsertId()
 
 	if err != nil {
-		return fmt.Errorf("error getting last inserted id: %w", err)
+		return dbError("order", "error getting last inserted id", err)
 	}
 
 	oi.ID = id
@@ -152,20 +260,76 @@ func createOrderItem(ctx context.Context, tx *sqlx.Tx, oi OrderItem) error {
 	return nil
 }
 
+// * блокируем строки товаров, сверяем цену заказа с ценой заблокированной строки,
+// * проверяем остаток и списываем количество из заказа
+func reserveStock(ctx context.Context, tx *sqlx.Tx, items []OrderItem) error {
+	prices := orderedPrices(items)
+
+	for _, q := range groupQuantities(items) {
+		var p struct {
+			Price        float32 `db:"price"`
+			CountInStock int64   `db:"count_in_stock"`
+		}
+
+		err := tx.GetContext(ctx, &p, `SELECT price, count_in_stock FROM products WHERE id=? FOR UPDATE`, q.productID)
+
+		if err != nil {
+			return dbError("product", fmt.Sprintf("error locking product %d", q.productID), err)
+		}
+
+		if err := checkPrice(q.productID, prices[q.productID], p.Price); err != nil {
+			return err
+		}
+
+		if p.CountInStock < q.quantity {
+			return &InsufficientStockError{ProductID: q.productID, Requested: q.quantity, Available: p.CountInStock}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE products SET count_in_stock=count_in_stock-? WHERE id=?`, q.quantity, q.productID)
+
+		if err != nil {
+			return dbError("product", fmt.Sprintf("error reserving stock for product %d", q.productID), err)
+		}
+	}
+
+	return nil
+}
+
+// * возврат товаров заказа на склад
+func restockOrderItems(ctx context.Context, tx *sqlx.Tx, orderID int64) error {
+	var items []OrderItem
+
+	err := tx.SelectContext(ctx, &items, `SELECT * FROM order_items WHERE order_id=?`, orderID)
+
+	if err != nil {
+		return dbError("order", "error getting order items", err)
+	}
+
+	for _, q := range groupQuantities(items) {
+		_, err = tx.ExecContext(ctx, `UPDATE products SET count_in_stock=count_in_stock+? WHERE id=?`, q.quantity, q.productID)
+
+		if err != nil {
+			return dbError("product", fmt.Sprintf("error restocking product %d", q.productID), err)
+		}
+	}
+
+	return nil
+}
+
 func (ms *MySQLStorer) GetOrder(ctx context.Context, userID int64) (*Order, error) {
 	var o Order
 
 	err := ms.db.GetContext(ctx, &o, `SELECT * FROM orders WHERE user_id=?`, userID)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting order: %w", err)
+		return nil, dbError("order", "error getting order", err)
 	}
 
 	var items []OrderItem
 	err = ms.db.SelectContext(ctx, &items, `SELECT * FROM order_items WHERE order_id=?`, o.ID)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting order items: %w", err)
+		return nil, dbError("order", "error getting order items", err)
 	}
 
 	o.Items = items
@@ -179,7 +343,7 @@ func (ms *MySQLStorer) ListOrders(ctx context.Context) ([]*Order, error) {
 	err := ms.db.SelectContext(ctx, &orders, `SELECT * FROM orders`)
 
 	if err != nil {
-		return nil, fmt.Errorf("error listing orders: %w", err)
+		return nil, dbError("order", "error listing orders", err)
 	}
 
 	for i := range orders {
@@ -188,7 +352,7 @@ func (ms *MySQLStorer) ListOrders(ctx context.Context) ([]*Order, error) {
 		err := ms.db.SelectContext(ctx, &items, `SELECT * FROM order_items WHERE order_id=?`, orders[i].ID)
 
 		if err != nil {
-			return nil, fmt.Errorf("error getting order items: %w", err)
+			return nil, dbError("order", "error getting order items", err)
 		}
 		orders[i].Items = items
 
@@ -199,20 +363,93 @@ func (ms *MySQLStorer) ListOrders(ctx context.Context) ([]*Order, error) {
 
 //* Update Order
 
+// * смена статуса заказа с проверкой допустимости перехода
+func (ms *MySQLStorer) UpdateOrderStatus(ctx context.Context, id int64, status OrderStatus) (*Order, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		var current OrderStatus
+
+		err := tx.GetContext(ctx, &current, `SELECT status FROM orders WHERE id=? FOR UPDATE`, id)
+
+		if err != nil {
+			return dbError("order", "error getting order status", err)
+		}
+
+		if err := current.ValidateTransition(status); err != nil {
+			return err
+		}
+
+		if current.releasesStock(status) {
+			if err := restockOrderItems(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE orders SET status=?, updated_at=? WHERE id=?`, status, time.Now(), id)
+
+		if err != nil {
+			return dbError("order", "error updating order status", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, dbError("order", "error updating order status", err)
+	}
+
+	return ms.GetOrderByID(ctx, id)
+}
+
+// * заказ по его id (GetOrder ищет по id пользователя)
+func (ms *MySQLStorer) GetOrderByID(ctx context.Context, id int64) (*Order, error) {
+	var o Order
+
+	err := ms.db.GetContext(ctx, &o, `SELECT * FROM orders WHERE id=?`, id)
+
+	if err != nil {
+		return nil, dbError("order", "error getting order", err)
+	}
+
+	var items []OrderItem
+	err = ms.db.SelectContext(ctx, &items, `SELECT * FROM order_items WHERE order_id=?`, o.ID)
+
+	if err != nil {
+		return nil, dbError("order", "error getting order items", err)
+	}
+
+	o.Items = items
+
+	return &o, nil
+}
+
 //* Delete Order
 
 func (ms *MySQLStorer) DeleteOrder(ctx context.Context, id int64) error {
 	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
-		_, err := tx.ExecContext(ctx, `DELETE FROM order_items WHERE order_id=?`, id)
+		var current OrderStatus
+
+		err := tx.GetContext(ctx, &current, `SELECT status FROM orders WHERE id=? FOR UPDATE`, id)
+
+		if err != nil {
+			return dbError("order", "error getting order status", err)
+		}
+
+		if current.holdsStock() {
+			if err := restockOrderItems(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM order_items WHERE order_id=?`, id)
 
 		if err != nil {
-			return fmt.Errorf("error deleting order items: %w", err)
+			return dbError("order", "error deleting order items", err)
 		}
 
 		_, err = tx.ExecContext(ctx, `DELETE FROM orders WHERE id=?`, id)
 
 		if err != nil {
-			return fmt.Errorf("error deleting order: %w", err)
+			return dbError("order", "error deleting order", err)
 		}
 
 		return nil
@@ -220,7 +457,7 @@ func (ms *MySQLStorer) DeleteOrder(ctx context.Context, id int64) error {
 	})
 
 	if err != nil {
-		return fmt.Errorf("error deleting order: %w", err)
+		return dbError("order", "error deleting order", err)
 	}
 
 	return nil
@@ -252,16 +489,46 @@ func (ms *MySQLStorer) execTx(ctx context.Context, fn func(*sqlx.Tx) error) erro
 
 //* USERS
 
-func (ms *MySQLStorer) CreateUser(ctx context.Context, u *User) (*User, error) {
-	res, err := ms.db.NamedExecContext(ctx, `INSERT INTO users (name, email, password, is_admin) VALUES (:name, :email, :password, :is_admin)`, u)
+const insertUserQuery = `INSERT INTO users (name, email, password, is_admin) VALUES (:name, :email, :password, :is_admin)`
+
+// * пользователь и его роли создаются в одной транзакции
+func (ms *MySQLStorer) CreateUser(ctx context.Context, u *User, roles ...string) (*User, error) {
+	if len(roles) > 0 {
+		err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+			res, err := tx.NamedExecContext(ctx, insertUserQuery, u)
+			if err != nil {
+				return dbError("user", "error inserting user", err)
+			}
+
+			if u.ID, err = res.LastInsertId(); err != nil {
+				return dbError("user", "error getting last inserted id", err)
+			}
+
+			for _, role := range roles {
+				if _, err := tx.ExecContext(ctx, `INSERT INTO user_roles (user_id, role_name) VALUES (?, ?)`, u.ID, role); err != nil {
+					return dbError("role", "error assigning role", err)
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return u, nil
+	}
+
+	res, err := ms.db.NamedExecContext(ctx, insertUserQuery, u)
 
 	if err != nil {
-		return nil, fmt.Errorf("error inserting user: %w", err)
+		return nil, dbError("user", "error inserting user", err)
 	}
 
 	id, err := res.LastInsertId()
 	if err != nil {
-		return nil, fmt.Errorf("error getting last inserted id: %w", err)
+		return nil, dbError("user", "error getting last inserted id", err)
 	}
 	u.ID = id
 
@@ -274,7 +541,7 @@ func (ms *MySQLStorer) GetUser(ctx context.Context, email string) (*User, error)
 	err := ms.db.GetContext(ctx, &u, `SELECT * FROM users WHERE email=?`, email)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting user: %w", err)
+		return nil, dbError("user", "error getting user", err)
 	}
 
 	return &u, nil
@@ -285,17 +552,17 @@ func (ms *MySQLStorer) ListUsers(ctx context.Context) ([]*User, error) {
 	err := ms.db.SelectContext(ctx, &users, `SELECT * FROM users`)
 
 	if err != nil {
-		return nil, fmt.Errorf("error listing users: %w", err)
+		return nil, dbError("user", "error listing users", err)
 	}
 
 	return users, nil
 }
 
 func (ms *MySQLStorer) UpdateUser(ctx context.Context, u *User) (*User, error) {
-	_, err := ms.db.NamedExecContext(ctx, `UPDATE users SET name=:name, email=:email, password=:password, is_admin=:is_admin, updated_at=:updated_at WHERE id=:id`, u)
+	_, err := ms.db.NamedExecContext(ctx, `UPDATE users SET name=:name, email=:email, password=:password, is_admin=:is_admin, updated_at=:updated_at, email_verified_at=:email_verified_at WHERE id=:id`, u)
 
 	if err != nil {
-		return nil, fmt.Errorf("error updating user: %w", err)
+		return nil, dbError("user", "error updating user", err)
 	}
 
 	return u, nil
@@ -304,7 +571,387 @@ func (ms *MySQLStorer) UpdateUser(ctx context.Context, u *User) (*User, error) {
 func (ms *MySQLStorer) DeleteUser(ctx context.Context, id int64) error {
 	_, err := ms.db.ExecContext(ctx, `DELETE FROM users WHERE id=?`, id)
 	if err != nil {
-		return fmt.Errorf("error deleting user: %w", err)
+		return dbError("user", "error deleting user", err)
+	}
+
+	return nil
+}
+
+// * подтверждение email пользователя id, если email не изменился с момента выдачи токена.
+// * Повторное подтверждение - ErrEmailAlreadyVerified
+func (ms *MySQLStorer) VerifyEmail(ctx context.Context, id int64, email string) (*User, error) {
+	var u User
+
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &u, `SELECT * FROM users WHERE id=? AND email=? FOR UPDATE`, id, email); err != nil {
+			return dbError("user", "error getting user", err)
+		}
+
+		if u.EmailVerifiedAt != nil {
+			return ErrEmailAlreadyVerified
+		}
+
+		now := time.Now()
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET email_verified_at=? WHERE id=?`, now, id); err != nil {
+			return dbError("user", "error verifying email", err)
+		}
+		u.EmailVerifiedAt = &now
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+//* PASSWORD RESET
+
+func (ms *MySQLStorer) CreatePasswordReset(ctx context.Context, r *PasswordReset) (*PasswordReset, error) {
+	_, err := ms.db.NamedExecContext(ctx, `INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES (:token_hash, :user_id, :expires_at)`, r)
+
+	if err != nil {
+		return nil, dbError("password reset", "error inserting password reset", err)
+	}
+
+	return r, nil
+}
+
+// * смена пароля по токену сброса: токен и все остальные неиспользованные токены пользователя
+// * погашаются, а его сессии отзываются в той же транзакции.
+// * Использованный, истекший или неизвестный токен - ErrNotFound
+func (ms *MySQLStorer) ResetPassword(ctx context.Context, tokenHash, password string) (*User, error) {
+	var u User
+
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		now := time.Now()
+
+		var r PasswordReset
+		err := tx.GetContext(ctx, &r, `SELECT * FROM password_resets WHERE token_hash=? AND used_at IS NULL AND expires_at>? FOR UPDATE`, tokenHash, now)
+		if err != nil {
+			return dbError("password reset", "error getting password reset", err)
+		}
+
+		if err := tx.GetContext(ctx, &u, `SELECT * FROM users WHERE id=? FOR UPDATE`, r.UserID); err != nil {
+			return dbError("user", "error getting user", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET password=?, updated_at=? WHERE id=?`, password, now, u.ID); err != nil {
+			return dbError("user", "error updating password", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE password_resets SET used_at=? WHERE user_id=? AND used_at IS NULL`, now, u.ID); err != nil {
+			return dbError("password reset", "error using password reset", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE sessions SET is_revoked=1 WHERE user_email=?", u.Email); err != nil {
+			return dbError("session", "error revoking sessions", err)
+		}
+
+		u.Password = password
+		u.UpdatedAt = &now
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// * замена хэша того же пароля (новый алгоритм или параметры). Если пароль успели сменить
+// * после проверки, старый хэш не совпадет и ничего не изменится
+func (ms *MySQLStorer) RehashPassword(ctx context.Context, userID int64, oldHash, newHash string) error {
+	_, err := ms.db.ExecContext(ctx, `UPDATE users SET password=? WHERE id=? AND password=?`, newHash, userID, oldHash)
+
+	if err != nil {
+		return dbError("user", "error updating password", err)
+	}
+
+	return nil
+}
+
+// * смена пароля пользователем: текущий пароль проверен по oldHash, поэтому если хэш успели
+// * изменить - ErrConflict. Неиспользованные токены сброса погашаются, все сессии пользователя,
+// * кроме keepSessionID, отзываются в той же транзакции
+func (ms *MySQLStorer) ChangePassword(ctx context.Context, userID int64, oldHash, newHash, keepSessionID string) (*User, error) {
+	var u User
+
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		now := time.Now()
+
+		if err := tx.GetContext(ctx, &u, `SELECT * FROM users WHERE id=? FOR UPDATE`, userID); err != nil {
+			return dbError("user", "error getting user", err)
+		}
+
+		if u.Password != oldHash {
+			return newError(ErrConflict, "user", "error changing password", errors.New("password was changed concurrently"))
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET password=?, updated_at=? WHERE id=?`, newHash, now, u.ID); err != nil {
+			return dbError("user", "error updating password", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE password_resets SET used_at=? WHERE user_id=? AND used_at IS NULL`, now, u.ID); err != nil {
+			return dbError("password reset", "error using password reset", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE sessions SET is_revoked=1 WHERE user_email=? AND id<>?", u.Email, keepSessionID); err != nil {
+			return dbError("session", "error revoking sessions", err)
+		}
+
+		u.Password = newHash
+		u.UpdatedAt = &now
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+//* TOTP
+
+func (ms *MySQLStorer) GetTOTP(ctx context.Context, userID int64) (*TOTP, error) {
+	var t TOTP
+	err := ms.db.GetContext(ctx, &t, `SELECT * FROM user_totp WHERE user_id=?`, userID)
+
+	if err != nil {
+		return nil, dbError("totp", "error getting totp", err)
+	}
+
+	return &t, nil
+}
+
+// * новый секрет заменяет неподтвержденный, включенный TOTP не меняется - ErrTOTPAlreadyEnabled
+func (ms *MySQLStorer) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		var t TOTP
+		err := tx.GetContext(ctx, &t, `SELECT * FROM user_totp WHERE user_id=? FOR UPDATE`, userID)
+
+		switch {
+		case err == nil && t.EnabledAt != nil:
+			return ErrTOTPAlreadyEnabled
+		case err != nil && !errors.Is(err, sql.ErrNoRows):
+			return dbError("totp", "error getting totp", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO user_totp (user_id, secret) VALUES (?, ?) ON DUPLICATE KEY UPDATE secret=VALUES(secret), last_used_step=0`, userID, secret)
+		if err != nil {
+			return dbError("totp", "error saving totp secret", err)
+		}
+
+		return nil
+	})
+}
+
+// * включает TOTP после проверки первого кода (шаг step) и заменяет коды восстановления
+func (ms *MySQLStorer) EnableTOTP(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE user_totp SET enabled_at=?, last_used_step=? WHERE user_id=? AND enabled_at IS NULL`, time.Now(), step, userID)
+		if err != nil {
+			return dbError("totp", "error enabling totp", err)
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return dbError("totp", "error enabling totp", err)
+		} else if n == 0 {
+			return ErrTOTPAlreadyEnabled
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id=?`, userID); err != nil {
+			return dbError("totp", "error deleting recovery codes", err)
+		}
+
+		for _, hash := range recoveryCodeHashes {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hash); err != nil {
+				return dbError("totp", "error inserting recovery code", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// * отмечает шаг принятого кода. Код того же или более раннего шага - ErrInvalidMFACode
+func (ms *MySQLStorer) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	res, err := ms.db.ExecContext(ctx, `UPDATE user_totp SET last_used_step=? WHERE user_id=? AND enabled_at IS NOT NULL AND last_used_step<?`, step, userID, step)
+	if err != nil {
+		return dbError("totp", "error using totp code", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return dbError("totp", "error using totp code", err)
+	}
+
+	if n == 0 {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (ms *MySQLStorer) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	res, err := ms.db.ExecContext(ctx, `UPDATE user_recovery_codes SET used_at=? WHERE user_id=? AND code_hash=? AND used_at IS NULL`, time.Now(), userID, codeHash)
+	if err != nil {
+		return dbError("totp", "error using recovery code", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return dbError("totp", "error using recovery code", err)
+	}
+
+	if n == 0 {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (ms *MySQLStorer) CountRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	var n int64
+	err := ms.db.GetContext(ctx, &n, `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id=? AND used_at IS NULL`, userID)
+
+	if err != nil {
+		return 0, dbError("totp", "error counting recovery codes", err)
+	}
+
+	return n, nil
+}
+
+func (ms *MySQLStorer) DeleteTOTP(ctx context.Context, userID int64) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id=?`, userID); err != nil {
+			return dbError("totp", "error deleting recovery codes", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id=?`, userID); err != nil {
+			return dbError("totp", "error deleting totp", err)
+		}
+
+		return nil
+	})
+}
+
+//* LOGIN ATTEMPTS
+
+func (ms *MySQLStorer) GetLoginAttempt(ctx context.Context, key string) (*LoginAttempt, error) {
+	var a LoginAttempt
+	err := ms.db.GetContext(ctx, &a, `SELECT * FROM login_attempts WHERE attempt_key=?`, key)
+
+	if err != nil {
+		return nil, dbError("login attempt", "error getting login attempt", err)
+	}
+
+	return &a, nil
+}
+
+// * атомарно учитывает неудачную попытку. Счетчик без неудач дольше window начинается заново,
+// * поэтому реплики ecomm-api, работающие с одной базой, видят общее значение
+func (ms *MySQLStorer) AddLoginFailure(ctx context.Context, key string, window time.Duration) (*LoginAttempt, error) {
+	var a LoginAttempt
+
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		now := time.Now()
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO login_attempts (attempt_key, failures, last_failure_at) VALUES (?, 1, ?) ON DUPLICATE KEY UPDATE failures=IF(last_failure_at<?, 1, failures+1), last_failure_at=VALUES(last_failure_at)`, key, now, now.Add(-window))
+		if err != nil {
+			return dbError("login attempt", "error adding login failure", err)
+		}
+
+		if err := tx.GetContext(ctx, &a, `SELECT * FROM login_attempts WHERE attempt_key=?`, key); err != nil {
+			return dbError("login attempt", "error getting login attempt", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// * RemoveLoginFailure отменяет неудачу failureAt, учтенную AddLoginFailure. Если после нее неудач не было,
+// * время последней неудачи возвращается к previousAt (нулевое - не менять)
+func (ms *MySQLStorer) RemoveLoginFailure(ctx context.Context, key string, failureAt, previousAt time.Time) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		lastFailureAt := previousAt
+		if lastFailureAt.IsZero() {
+			lastFailureAt = failureAt
+		}
+
+		_, err := tx.ExecContext(ctx, `UPDATE login_attempts SET failures=failures-1, last_failure_at=IF(last_failure_at=?, ?, last_failure_at) WHERE attempt_key=?`, failureAt, lastFailureAt, key)
+		if err != nil {
+			return dbError("login attempt", "error removing login failure", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM login_attempts WHERE attempt_key=? AND failures<=0`, key); err != nil {
+			return dbError("login attempt", "error removing login failure", err)
+		}
+
+		return nil
+	})
+}
+
+func (ms *MySQLStorer) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := ms.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE attempt_key=?`, key)
+	if err != nil {
+		return dbError("login attempt", "error resetting login attempts", err)
+	}
+
+	return nil
+}
+
+//* ROLES
+
+func (ms *MySQLStorer) ListRoles(ctx context.Context) ([]*Role, error) {
+	var roles []*Role
+	err := ms.db.SelectContext(ctx, &roles, `SELECT * FROM roles ORDER BY name`)
+
+	if err != nil {
+		return nil, dbError("role", "error listing roles", err)
+	}
+
+	return roles, nil
+}
+
+func (ms *MySQLStorer) GetUserRoles(ctx context.Context, userID int64) ([]*Role, error) {
+	var roles []*Role
+	err := ms.db.SelectContext(ctx, &roles, `SELECT r.* FROM roles r JOIN user_roles ur ON ur.role_name=r.name WHERE ur.user_id=? ORDER BY r.name`, userID)
+
+	if err != nil {
+		return nil, dbError("role", "error getting user roles", err)
+	}
+
+	return roles, nil
+}
+
+// * повторное назначение роли ничего не меняет,
+// * неизвестная роль или пользователь - ошибка внешнего ключа
+func (ms *MySQLStorer) AssignRole(ctx context.Context, userID int64, role string) error {
+	_, err := ms.db.ExecContext(ctx, `INSERT INTO user_roles (user_id, role_name) VALUES (?, ?) ON DUPLICATE KEY UPDATE role_name=role_name`, userID, role)
+	if err != nil {
+		return dbError("role", "error assigning role", err)
+	}
+
+	return nil
+}
+
+func (ms *MySQLStorer) UnassignRole(ctx context.Context, userID int64, role string) error {
+	_, err := ms.db.ExecContext(ctx, `DELETE FROM user_roles WHERE user_id=? AND role_name=?`, userID, role)
+	if err != nil {
+		return dbError("role", "error unassigning role", err)
 	}
 
 	return nil
@@ -312,11 +959,15 @@ func (ms *MySQLStorer) DeleteUser(ctx context.Context, id int64) error {
 
 //* SESSIONS
 
+const insertSessionQuery = "INSERT INTO sessions (id, user_email, refresh_token, is_revoked, expires_at, family_id, parent_id, user_agent, ip_address) VALUES (:id, :user_email, :refresh_token, :is_revoked, :expires_at, :family_id, :parent_id, :user_agent, :ip_address)"
+
 func (ms *MySQLStorer) CreateSession(ctx context.Context, s *Session) (*Session, error) {
-	_, err := ms.db.NamedExecContext(ctx, "INSERT INTO sessions (id, user_email, refresh_token, is_revoked) VALUES (:id, :user_email, :refresh_token, :is_revoked)", s)
+	s.FamilyID = sessionFamily(s)
+
+	_, err := ms.db.NamedExecContext(ctx, insertSessionQuery, s)
 
 	if err != nil {
-		return nil, fmt.Errorf("error creating session: %w", err)
+		return nil, dbError("session", "error creating session", err)
 	}
 
 	return s, nil
@@ -327,18 +978,104 @@ func (ms *MySQLStorer) GetSession(ctx context.Context, id string) (*Session, err
 	err := ms.db.GetContext(ctx, &s, "SELECT * FROM sessions WHERE id=?", id)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting session: %w", err)
+		return nil, dbError("session", "error getting session", err)
 	}
 
 	return &s, nil
 }
 
+// * заменяет сессию id новой сессией next в том же семействе.
+// * Если сессия id уже была заменена, отзывает все семейство и возвращает ErrRefreshTokenReused
+func (ms *MySQLStorer) RotateSession(ctx context.Context, id string, next *Session) (*Session, error) {
+	reused := false
+
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		var old Session
+
+		err := tx.GetContext(ctx, &old, "SELECT * FROM sessions WHERE id=? FOR UPDATE", id)
+
+		if err != nil {
+			return dbError("session", "error getting session", err)
+		}
+
+		err = checkRotation(&old, next)
+
+		// * отзыв семейства должен сохраниться, поэтому транзакция фиксируется, а ошибка возвращается после
+		if errors.Is(err, ErrRefreshTokenReused) {
+			reused = true
+
+			_, err = tx.ExecContext(ctx, "UPDATE sessions SET is_revoked=1 WHERE family_id=?", sessionFamily(&old))
+
+			if err != nil {
+				return dbError("session", "error revoking session family", err)
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE sessions SET rotated_at=NOW() WHERE id=?", id)
+
+		if err != nil {
+			return dbError("session", "error updating session", err)
+		}
+
+		next.FamilyID = sessionFamily(&old)
+		next.ParentID = old.ID
+
+		_, err = tx.NamedExecContext(ctx, insertSessionQuery, next)
+
+		if err != nil {
+			return dbError("session", "error creating session", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, dbError("session", "error rotating session", err)
+	}
+
+	if reused {
+		return nil, fmt.Errorf("error rotating session %q: %w", id, ErrRefreshTokenReused)
+	}
+
+	return next, nil
+}
+
+// * активные сессии пользователя, новые первыми
+func (ms *MySQLStorer) ListSessions(ctx context.Context, email string) ([]*Session, error) {
+	var sessions []*Session
+
+	err := ms.db.SelectContext(ctx, &sessions, "SELECT * FROM sessions WHERE user_email=? AND is_revoked=0 AND rotated_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()) ORDER BY created_at DESC, id ASC", email)
+
+	if err != nil {
+		return nil, dbError("session", "error listing sessions", err)
+	}
+
+	return sessions, nil
+}
+
 // * отмена сессии
 func (ms *MySQLStorer) RevokeSession(ctx context.Context, id string) error {
 	_, err := ms.db.NamedExecContext(ctx, "UPDATE sessions SET is_revoked=1 WHERE id=:id", map[string]interface{}{"id": id})
 
 	if err != nil {
-		return fmt.Errorf("error revoking session: %w", err)
+		return dbError("session", "error revoking session", err)
+	}
+
+	return nil
+}
+
+// * выход на всех устройствах
+func (ms *MySQLStorer) RevokeUserSessions(ctx context.Context, email string) error {
+	_, err := ms.db.ExecContext(ctx, "UPDATE sessions SET is_revoked=1 WHERE user_email=?", email)
+
+	if err != nil {
+		return dbError("session", "error revoking user sessions", err)
 	}
 
 	return nil
@@ -348,7 +1085,7 @@ func (ms *MySQLStorer) DeleteSession(ctx context.Context, id string) error {
 	_, err := ms.db.ExecContext(ctx, "DELETE FROM sessions WHERE id=?", id)
 
 	if err != nil {
-		return fmt.Errorf("error deleting session: %w", err)
+		return dbError("session", "error deleting session", err)
 	}
 
 	return nil
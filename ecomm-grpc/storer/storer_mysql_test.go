@@ -2,11 +2,14 @@ package storer
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 )
@@ -26,6 +29,54 @@ func withTestDB(t *testing.T, fn func(*sqlx.DB, sqlmock.Sqlmock)) {
 	fn(db, mock)
 }
 
+// * ожидания резервирования товара на складе для каждого элемента заказа
+func expectReserveStock(mock sqlmock.Sqlmock, items []OrderItem) {
+	for _, oi := range items {
+		mock.ExpectQuery(`SELECT price, count_in_stock FROM products WHERE id=? FOR UPDATE`).WithArgs(oi.ProductID).
+			WillReturnRows(sqlmock.NewRows([]string{"price", "count_in_stock"}).AddRow(oi.Price, 100))
+		mock.ExpectExec(`UPDATE products SET count_in_stock=count_in_stock-? WHERE id=?`).WithArgs(oi.Quantity, oi.ProductID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+func expectOrderStatus(mock sqlmock.Sqlmock, id int64, status OrderStatus) {
+	mock.ExpectQuery(`SELECT status FROM orders WHERE id=? FOR UPDATE`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+}
+
+func TestDBErrorKinds(t *testing.T) {
+	tcs := []struct {
+		name     string
+		resource string
+		err      error
+		kind     error
+	}{
+		{"no rows", "product", sql.ErrNoRows, ErrNotFound},
+		{"duplicate email", "user", &mysql.MySQLError{Number: 1062}, ErrDuplicateEmail},
+		{"duplicate session", "session", &mysql.MySQLError{Number: 1062}, ErrConflict},
+		{"row is referenced", "user", &mysql.MySQLError{Number: 1451}, ErrForeignKey},
+		{"no referenced row", "order", &mysql.MySQLError{Number: 1452}, ErrForeignKey},
+		{"deadlock", "order", &mysql.MySQLError{Number: 1213}, ErrConflict},
+		{"lock wait timeout", "product", &mysql.MySQLError{Number: 1205}, ErrConflict},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := dbError(tc.resource, "error", tc.err)
+			require.ErrorIs(t, err, tc.kind)
+			require.ErrorIs(t, err, tc.err)
+
+			var se *Error
+			require.ErrorAs(t, err, &se)
+			require.Equal(t, tc.resource, se.Resource)
+		})
+	}
+
+	err := dbError("product", "error", &mysql.MySQLError{Number: 1064})
+	var se *Error
+	require.False(t, errors.As(err, &se))
+}
+
 func TestCreateProduct(t *testing.T) {
 	p := &Product{
 		Name:         "Product 1",
@@ -186,11 +237,62 @@ func TestListProducts(t *testing.T) {
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "name", "image", "category", "description", "rating", "num_reviews", "price", "count_in_stock"}).AddRow(1, p.Name, p.Image, p.Category, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock)
 
-				mock.ExpectQuery(`SELECT * FROM products`).WillReturnRows(rows)
+				mock.ExpectQuery(`SELECT * FROM products ORDER BY id ASC LIMIT ?`).WithArgs(DefaultProductPageSize + 1).WillReturnRows(rows)
 
-				products, err := st.ListProducts(context.Background())
+				products, next, err := st.ListProducts(context.Background(), ListProductsParams{})
 				require.NoError(t, err)
 				require.Len(t, products, 1)
+				require.Empty(t, next)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "filters, sort and cursor",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				params := ListProductsParams{
+					PageSize:    1,
+					Category:    "category1",
+					MinPrice:    10,
+					MaxPrice:    200,
+					InStockOnly: true,
+					SortBy:      ProductSortPrice,
+					SortDesc:    true,
+				}
+
+				rows := sqlmock.NewRows([]string{"id", "name", "image", "category", "description", "rating", "num_reviews", "price", "count_in_stock"}).
+					AddRow(2, p.Name, p.Image, p.Category, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock).
+					AddRow(1, p.Name, p.Image, p.Category, p.Description, p.Rating, p.NumReviews, 50.0, p.CountInStock)
+
+				mock.ExpectQuery(`SELECT * FROM products WHERE category = ? AND price >= ? AND price <= ? AND count_in_stock > 0 ORDER BY price DESC, id DESC LIMIT ?`).
+					WithArgs("category1", 10.0, 200.0, 2).WillReturnRows(rows)
+
+				products, next, err := st.ListProducts(context.Background(), params)
+				require.NoError(t, err)
+				require.Len(t, products, 1)
+				require.NotEmpty(t, next)
+
+				params.Cursor = next
+				mock.ExpectQuery(`SELECT * FROM products WHERE category = ? AND price >= ? AND price <= ? AND count_in_stock > 0 AND (price < ? OR (price = ? AND id < ?)) ORDER BY price DESC, id DESC LIMIT ?`).
+					WithArgs("category1", 10.0, 200.0, 99.99, 99.99, 2, 2).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				products, next, err = st.ListProducts(context.Background(), params)
+				require.NoError(t, err)
+				require.Empty(t, products)
+				require.Empty(t, next)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "cursor for another sort order",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				cursor := newProductCursor(&Product{ID: 1}, &ListProductsParams{SortBy: ProductSortRating})
+
+				_, _, err := st.ListProducts(context.Background(), ListProductsParams{Cursor: cursor.encode()})
+				require.ErrorIs(t, err, ErrInvalidCursor)
 
 				err = mock.ExpectationsWereMet()
 				require.NoError(t, err)
@@ -200,9 +302,9 @@ func TestListProducts(t *testing.T) {
 			name: "failed queryng products",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 
-				mock.ExpectQuery(`SELECT * FROM products`).WillReturnError(fmt.Errorf("error querying products"))
+				mock.ExpectQuery(`SELECT * FROM products ORDER BY id ASC LIMIT ?`).WillReturnError(fmt.Errorf("error querying products"))
 
-				_, err := st.ListProducts(context.Background())
+				_, _, err := st.ListProducts(context.Background(), ListProductsParams{})
 				require.Error(t, err)
 
 				err = mock.ExpectationsWereMet()
@@ -223,6 +325,66 @@ func TestListProducts(t *testing.T) {
 
 }
 
+func TestSearchProducts(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "name", "image", "category", "description", "rating", "num_reviews", "price", "count_in_stock", "relevance"}).
+					AddRow(1, "Wool Sweater", "image1", "clothes", "Warm sweater", 5, 10, 99.99, 10, 0.9)
+
+				mock.ExpectQuery(`SELECT *, MATCH(name, category, description) AGAINST(? IN NATURAL LANGUAGE MODE) AS relevance FROM products WHERE MATCH(name, category, description) AGAINST(? IN NATURAL LANGUAGE MODE) ORDER BY relevance DESC, id ASC LIMIT ?`).
+					WithArgs("warm sweater", "warm sweater", DefaultSearchLimit).WillReturnRows(rows)
+
+				hits, err := st.SearchProducts(context.Background(), SearchProductsParams{Query: "Warm, sweater!"})
+				require.NoError(t, err)
+				require.Len(t, hits, 1)
+				require.Equal(t, "Wool Sweater", hits[0].Name)
+				require.Equal(t, 0.9, hits[0].Relevance)
+				require.Equal(t, "<mark>Warm</mark> <mark>sweater</mark>", hits[0].Snippet)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "empty query",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				_, err := st.SearchProducts(context.Background(), SearchProductsParams{Query: " "})
+				require.ErrorIs(t, err, ErrEmptySearchQuery)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "failed searching products",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT *, MATCH(name, category, description) AGAINST(? IN NATURAL LANGUAGE MODE) AS relevance FROM products WHERE MATCH(name, category, description) AGAINST(? IN NATURAL LANGUAGE MODE) ORDER BY relevance DESC, id ASC LIMIT ?`).
+					WithArgs("sweater", "sweater", 5).WillReturnError(fmt.Errorf("error searching products"))
+
+				_, err := st.SearchProducts(context.Background(), SearchProductsParams{Query: "sweater", Limit: 5})
+				require.Error(t, err)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
 func TestUpdateProduct(t *testing.T) {
 	p := &Product{
 		ID:           1,
@@ -317,6 +479,18 @@ func TestDeleteProduct(t *testing.T) {
 				require.NoError(t, err)
 			},
 		},
+		{
+			name: "product referenced by order",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`DELETE FROM products WHERE id=?`).WithArgs(1).WillReturnError(&mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row"})
+
+				err := st.DeleteProduct(context.Background(), 1)
+				require.ErrorIs(t, err, ErrForeignKey)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "failed deleting product",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
@@ -379,12 +553,13 @@ func TestCreateOrder(t *testing.T) {
 			name: "success",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).
+				expectReserveStock(mock, ois)
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id, status) VALUES (?, ?, ?, ?, ?, ?)`).
 					WillReturnResult(sqlmock.NewResult(1, 1))
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
@@ -401,7 +576,8 @@ func TestCreateOrder(t *testing.T) {
 			name: "failed creating order",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order"))
+				expectReserveStock(mock, ois)
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id, status) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order"))
 				mock.ExpectRollback()
 
 				_, err := st.CreateOrder(context.Background(), o)
@@ -412,14 +588,53 @@ func TestCreateOrder(t *testing.T) {
 
 			},
 		},
+		{
+			name: "insufficient stock",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(`SELECT price, count_in_stock FROM products WHERE id=? FOR UPDATE`).WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"price", "count_in_stock"}).AddRow(99.99, 0))
+
+				mock.ExpectRollback()
+
+				_, err := st.CreateOrder(context.Background(), o)
+				require.ErrorIs(t, err, ErrInsufficientStock)
+
+				var stockErr *InsufficientStockError
+				require.ErrorAs(t, err, &stockErr)
+				require.Equal(t, int64(1), stockErr.ProductID)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "price changed",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(`SELECT price, count_in_stock FROM products WHERE id=? FOR UPDATE`).WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"price", "count_in_stock"}).AddRow(109.99, 100))
+
+				mock.ExpectRollback()
+
+				_, err := st.CreateOrder(context.Background(), o)
+				require.ErrorIs(t, err, ErrConflict)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "failed creating order item",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
+				expectReserveStock(mock, ois)
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id, status) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order item"))
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnError(fmt.Errorf("error creating order item"))
 
 				mock.ExpectRollback()
 
@@ -479,7 +694,7 @@ func TestGetOrder(t *testing.T) {
 
 				orows := sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price"}).AddRow(1, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice)
 
-				mock.ExpectQuery(`SELECT * FROM orders WHERE id=?`).WithArgs(1).WillReturnRows(orows)
+				mock.ExpectQuery(`SELECT * FROM orders WHERE user_id=?`).WithArgs(1).WillReturnRows(orows)
 
 				oirows := sqlmock.NewRows([]string{"id", "name", "quantity", "image", "price", "product_id"}).AddRow(1, ois[0].Name, ois[0].Quantity, ois[0].Image, ois[0].Price, ois[0].ProductID).AddRow(2, ois[1].Name, ois[1].Quantity, ois[1].Image, ois[1].Price, ois[1].ProductID)
 
@@ -506,7 +721,7 @@ func TestGetOrder(t *testing.T) {
 			name: "failed getting order",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 
-				mock.ExpectQuery(`SELECT * FROM orders WHERE id=?`).WithArgs(1).WillReturnError(fmt.Errorf("error getting order"))
+				mock.ExpectQuery(`SELECT * FROM orders WHERE user_id=?`).WithArgs(1).WillReturnError(fmt.Errorf("error getting order"))
 
 				_, err := st.GetOrder(context.Background(), 1)
 				require.Error(t, err)
@@ -519,7 +734,7 @@ func TestGetOrder(t *testing.T) {
 		{
 			name: "failed getting order items",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT * FROM orders WHERE id=?`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price"}).AddRow(1, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice))
+				mock.ExpectQuery(`SELECT * FROM orders WHERE user_id=?`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price"}).AddRow(1, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice))
 
 				mock.ExpectQuery(`SELECT * FROM order_items WHERE order_id=?`).WithArgs(1).WillReturnError(fmt.Errorf("error getting order items"))
 
@@ -535,11 +750,12 @@ func TestGetOrder(t *testing.T) {
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 
 				mock.ExpectBegin()
-				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
+				expectReserveStock(mock, ois)
+				mock.ExpectExec(`INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id, status) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1)) // Успешное создание order, чтобы дойти до items
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price) VALUES (?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO order_items (name, quantity, image, price, product_id, order_id) VALUES (?, ?, ?, ?, ?, ?)`).WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectCommit().WillReturnError(fmt.Errorf("error commiting transaction"))
 
@@ -575,6 +791,8 @@ func TestDeleteOrder(t *testing.T) {
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 
+				expectOrderStatus(mock, 1, OrderStatusDelivered)
+
 				mock.ExpectExec(`DELETE FROM order_items WHERE order_id=?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectExec(`DELETE FROM orders WHERE id=?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -589,11 +807,54 @@ func TestDeleteOrder(t *testing.T) {
 
 			},
 		},
+		{
+			name: "restocks pending order",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				expectOrderStatus(mock, 1, OrderStatusPending)
+
+				mock.ExpectQuery(`SELECT * FROM order_items WHERE order_id=?`).WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "quantity", "image", "price", "product_id", "order_id"}).AddRow(1, "item 1", 3, "image1.jpg", 99.99, 7, 1))
+
+				mock.ExpectExec(`UPDATE products SET count_in_stock=count_in_stock+? WHERE id=?`).WithArgs(3, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(`DELETE FROM order_items WHERE order_id=?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectExec(`DELETE FROM orders WHERE id=?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectCommit()
+
+				err := st.DeleteOrder(context.Background(), 1)
+				require.NoError(t, err)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "not found",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(`SELECT status FROM orders WHERE id=? FOR UPDATE`).WithArgs(1).WillReturnError(sql.ErrNoRows)
+
+				mock.ExpectRollback()
+
+				err := st.DeleteOrder(context.Background(), 1)
+				require.ErrorIs(t, err, ErrNotFound)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "failed deleting order item",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 
+				expectOrderStatus(mock, 1, OrderStatusDelivered)
+
 				mock.ExpectExec(`DELETE FROM order_items WHERE order_id=?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectRollback()
@@ -611,6 +872,8 @@ func TestDeleteOrder(t *testing.T) {
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 
+				expectOrderStatus(mock, 1, OrderStatusDelivered)
+
 				mock.ExpectExec(`DELETE FROM order_items WHERE order_id=?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectExec(`DELETE FROM orders WHERE id=?`).WithArgs(1).WillReturnError(fmt.Errorf("error deleting order"))
@@ -637,6 +900,177 @@ func TestDeleteOrder(t *testing.T) {
 	}
 }
 
+func TestRotateSession(t *testing.T) {
+	sessionColumns := []string{"id", "user_email", "refresh_token", "is_revoked", "created_at", "expires_at", "family_id", "parent_id", "rotated_at", "user_agent", "ip_address"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(`SELECT * FROM sessions WHERE id=? FOR UPDATE`).WithArgs("s1").
+					WillReturnRows(sqlmock.NewRows(sessionColumns).AddRow("s1", "user@example.com", "r1", false, time.Now(), expiresAt, "s1", "", nil, "", ""))
+
+				mock.ExpectExec(`UPDATE sessions SET rotated_at=NOW() WHERE id=?`).WithArgs("s1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(`INSERT INTO sessions (id, user_email, refresh_token, is_revoked, expires_at, family_id, parent_id, user_agent, ip_address) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`).
+					WithArgs("s2", "user@example.com", "r2", false, &expiresAt, "s1", "s1", "", "").WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectCommit()
+
+				s, err := st.RotateSession(context.Background(), "s1", &Session{ID: "s2", UserEmail: "user@example.com", RefreshToken: "r2", ExpiresAt: &expiresAt})
+				require.NoError(t, err)
+				require.Equal(t, "s1", s.FamilyID)
+				require.Equal(t, "s1", s.ParentID)
+
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "reuse revokes family",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(`SELECT * FROM sessions WHERE id=? FOR UPDATE`).WithArgs("s1").
+					WillReturnRows(sqlmock.NewRows(sessionColumns).AddRow("s1", "user@example.com", "r1", false, time.Now(), expiresAt, "s1", "", time.Now(), "", ""))
+
+				mock.ExpectExec(`UPDATE sessions SET is_revoked=1 WHERE family_id=?`).WithArgs("s1").WillReturnResult(sqlmock.NewResult(0, 2))
+
+				mock.ExpectCommit()
+
+				_, err := st.RotateSession(context.Background(), "s1", &Session{ID: "s3", UserEmail: "user@example.com", RefreshToken: "r3", ExpiresAt: &expiresAt})
+				require.ErrorIs(t, err, ErrRefreshTokenReused)
+
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "revoked session",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery(`SELECT * FROM sessions WHERE id=? FOR UPDATE`).WithArgs("s1").
+					WillReturnRows(sqlmock.NewRows(sessionColumns).AddRow("s1", "user@example.com", "r1", true, time.Now(), expiresAt, "s1", "", nil, "", ""))
+
+				mock.ExpectRollback()
+
+				_, err := st.RotateSession(context.Background(), "s1", &Session{ID: "s2", UserEmail: "user@example.com", RefreshToken: "r2", ExpiresAt: &expiresAt})
+				require.ErrorIs(t, err, ErrSessionRevoked)
+
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+			st := NewMySQLStorer(db)
+			tc.test(t, st, mock)
+		})
+	}
+}
+
 //* запуск всех тестов
 //* cd ecomm-api/storer
 //* go test -v -cover
+
+func TestUserRoles(t *testing.T) {
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+
+		mock.ExpectQuery(`SELECT r.* FROM roles r JOIN user_roles ur ON ur.role_name=r.name WHERE ur.user_id=? ORDER BY r.name`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "description", "permissions", "created_at"}).
+				AddRow("order-support", "handles customer orders", []byte(`["orders:read","orders:write"]`), time.Now()))
+
+		roles, err := st.GetUserRoles(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Equal(t, Permissions{"orders:read", "orders:write"}, roles[0].Permissions)
+
+		//* неизвестная роль - ошибка внешнего ключа
+		mock.ExpectExec(`INSERT INTO user_roles (user_id, role_name) VALUES (?, ?) ON DUPLICATE KEY UPDATE role_name=role_name`).WithArgs(1, "unknown").
+			WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+
+		err = st.AssignRole(context.Background(), 1, "unknown")
+		require.ErrorIs(t, err, ErrForeignKey)
+
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(`SELECT * FROM password_resets WHERE token_hash=? AND used_at IS NULL AND expires_at>? FOR UPDATE`).WithArgs("hash", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"token_hash", "user_id", "expires_at", "used_at", "created_at"}).AddRow("hash", 1, time.Now().Add(time.Hour), nil, time.Now()))
+
+		mock.ExpectQuery(`SELECT * FROM users WHERE id=? FOR UPDATE`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "password", "is_admin", "created_at", "updated_at", "email_verified_at"}).
+				AddRow(1, "user", "user@example.com", "old-hash", false, time.Now(), nil, nil))
+
+		mock.ExpectExec(`UPDATE users SET password=?, updated_at=? WHERE id=?`).WithArgs("new-hash", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE password_resets SET used_at=? WHERE user_id=? AND used_at IS NULL`).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE sessions SET is_revoked=1 WHERE user_email=?`).WithArgs("user@example.com").WillReturnResult(sqlmock.NewResult(0, 3))
+
+		mock.ExpectCommit()
+
+		u, err := st.ResetPassword(context.Background(), "hash", "new-hash")
+		require.NoError(t, err)
+		require.Equal(t, "new-hash", u.Password)
+		require.NotNil(t, u.UpdatedAt)
+
+		//* использованный или истекший токен не находится
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(`SELECT * FROM password_resets WHERE token_hash=? AND used_at IS NULL AND expires_at>? FOR UPDATE`).WithArgs("hash", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		mock.ExpectRollback()
+
+		_, err = st.ResetPassword(context.Background(), "hash", "other-hash")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		userRows := func() *sqlmock.Rows {
+			return sqlmock.NewRows([]string{"id", "name", "email", "password", "is_admin", "created_at", "updated_at", "email_verified_at"}).
+				AddRow(1, "user", "user@example.com", "old-hash", false, time.Now(), nil, nil)
+		}
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(`SELECT * FROM users WHERE id=? FOR UPDATE`).WithArgs(1).WillReturnRows(userRows())
+		mock.ExpectExec(`UPDATE users SET password=?, updated_at=? WHERE id=?`).WithArgs("new-hash", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE password_resets SET used_at=? WHERE user_id=? AND used_at IS NULL`).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE sessions SET is_revoked=1 WHERE user_email=? AND id<>?`).WithArgs("user@example.com", "current").WillReturnResult(sqlmock.NewResult(0, 2))
+
+		mock.ExpectCommit()
+
+		u, err := st.ChangePassword(context.Background(), 1, "old-hash", "new-hash", "current")
+		require.NoError(t, err)
+		require.Equal(t, "new-hash", u.Password)
+
+		//* хэш изменился после проверки текущего пароля
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT * FROM users WHERE id=? FOR UPDATE`).WithArgs(1).WillReturnRows(userRows())
+		mock.ExpectRollback()
+
+		_, err = st.ChangePassword(context.Background(), 1, "stale-hash", "new-hash", "current")
+		require.ErrorIs(t, err, ErrConflict)
+
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,871 @@
+package storer
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"davidHwang/ecomm/token"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+// * поведенческие тесты, общие для всех реализаций Storer
+// * MySQL запускается только если задан ECOMM_TEST_MYSQL_DSN (база с примененными миграциями)
+func TestMemoryStorer(t *testing.T) {
+	runStorerSuite(t, func(t *testing.T) Storer {
+		return NewMemoryStorer()
+	})
+}
+
+func TestMySQLStorerSuite(t *testing.T) {
+	dsn := os.Getenv("ECOMM_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("ECOMM_TEST_MYSQL_DSN is not set")
+	}
+
+	db, err := sqlx.Open("mysql", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	runStorerSuite(t, func(t *testing.T) Storer {
+		for _, table := range []string{"login_attempts", "sessions", "user_recovery_codes", "user_totp", "password_resets", "user_roles", "order_items", "orders", "products", "users"} {
+			_, err := db.Exec("DELETE FROM " + table)
+			require.NoError(t, err)
+		}
+
+		return NewMySQLStorer(db)
+	})
+}
+
+// * токен обновления, подписанный RS256 ключом, как при TOKEN_KEYS_DIR
+func newRS256RefreshToken(t *testing.T) string {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	key, err := token.NewKey("session-test", rsaKey)
+	require.NoError(t, err)
+
+	keys, err := token.NewKeySet(key.ID, key)
+	require.NoError(t, err)
+
+	refreshToken, _, err := token.NewKeySetJWTMaker(keys).CreateToken(1, "session@example.com", true, []string{"orders:read", "orders:write", "products:write", "users:read", "users:write"}, "", time.Hour)
+	require.NoError(t, err)
+
+	return refreshToken
+}
+
+func runStorerSuite(t *testing.T, newStorer func(*testing.T) Storer) {
+	ctx := context.Background()
+
+	newProduct := func() *Product {
+		return &Product{
+			Name:         "Product 1",
+			Image:        "image1",
+			Category:     "category1",
+			Description:  "description1",
+			Rating:       5,
+			NumReviews:   10,
+			Price:        100.0,
+			CountInStock: 100,
+		}
+	}
+
+	newUser := func(email string) *User {
+		return &User{
+			Name:     "user",
+			Email:    email,
+			Password: "hashed",
+		}
+	}
+
+	tcs := []struct {
+		name string
+		test func(*testing.T, Storer)
+	}{
+		{
+			name: "product crud",
+			test: func(t *testing.T, st Storer) {
+				cp, err := st.CreateProduct(ctx, newProduct())
+				require.NoError(t, err)
+				require.NotZero(t, cp.ID)
+
+				gp, err := st.GetProduct(ctx, cp.ID)
+				require.NoError(t, err)
+				require.Equal(t, cp.Name, gp.Name)
+				require.Equal(t, cp.CountInStock, gp.CountInStock)
+
+				now := time.Now()
+				gp.Name = "updated"
+				gp.UpdatedAt = &now
+				_, err = st.UpdateProduct(ctx, gp)
+				require.NoError(t, err)
+
+				gp, err = st.GetProduct(ctx, cp.ID)
+				require.NoError(t, err)
+				require.Equal(t, "updated", gp.Name)
+				require.NotNil(t, gp.UpdatedAt)
+
+				products, _, err := st.ListProducts(ctx, ListProductsParams{})
+				require.NoError(t, err)
+				require.Len(t, products, 1)
+
+				require.NoError(t, st.DeleteProduct(ctx, cp.ID))
+
+				_, err = st.GetProduct(ctx, cp.ID)
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "product pagination",
+			test: func(t *testing.T, st Storer) {
+				prices := []float32{30, 10, 20, 10, 40}
+				for i, price := range prices {
+					p := newProduct()
+					p.Price = price
+					p.Rating = int64(i)
+					if i == 4 {
+						p.Category = "other"
+					}
+					if i == 1 {
+						p.CountInStock = 0
+					}
+					_, err := st.CreateProduct(ctx, p)
+					require.NoError(t, err)
+				}
+
+				collect := func(params ListProductsParams) []float32 {
+					var res []float32
+					for {
+						products, next, err := st.ListProducts(ctx, params)
+						require.NoError(t, err)
+						require.LessOrEqual(t, len(products), params.PageSize)
+
+						for _, p := range products {
+							res = append(res, p.Price)
+						}
+
+						if next == "" {
+							return res
+						}
+						params.Cursor = next
+					}
+				}
+
+				require.Equal(t, []float32{10, 10, 20, 30, 40}, collect(ListProductsParams{PageSize: 2, SortBy: ProductSortPrice}))
+				require.Equal(t, []float32{40, 30, 20, 10, 10}, collect(ListProductsParams{PageSize: 2, SortBy: ProductSortPrice, SortDesc: true}))
+				require.Equal(t, []float32{30, 20, 10}, collect(ListProductsParams{PageSize: 1, Category: "category1", InStockOnly: true}))
+				require.Equal(t, []float32{30, 20}, collect(ListProductsParams{PageSize: 10, MinPrice: 15, MaxPrice: 35}))
+				require.Equal(t, []float32{40, 10, 20, 10, 30}, collect(ListProductsParams{PageSize: 3, SortBy: ProductSortRating, SortDesc: true}))
+
+				_, _, err := st.ListProducts(ctx, ListProductsParams{Cursor: "not a cursor"})
+				require.ErrorIs(t, err, ErrInvalidCursor)
+			},
+		},
+		{
+			name: "product search",
+			test: func(t *testing.T, st Storer) {
+				for _, p := range []*Product{
+					{Name: "Red Wool Sweater", Category: "clothes", Description: "Warm sweater for the winter"},
+					{Name: "Blue Jeans", Category: "clothes", Description: "Denim jeans that go with any sweater & shirt"},
+					{Name: "Coffee Mug", Category: "kitchen", Description: "Ceramic mug"},
+				} {
+					p.Image = "image"
+					_, err := st.CreateProduct(ctx, p)
+					require.NoError(t, err)
+				}
+
+				hits, err := st.SearchProducts(ctx, SearchProductsParams{Query: "Sweater"})
+				require.NoError(t, err)
+				require.Len(t, hits, 2)
+				require.Equal(t, "Red Wool Sweater", hits[0].Name)
+				require.Equal(t, "Blue Jeans", hits[1].Name)
+				require.Greater(t, hits[0].Relevance, hits[1].Relevance)
+				require.Equal(t, "Warm <mark>sweater</mark> for the winter", hits[0].Snippet)
+				require.Equal(t, "Denim jeans that go with any <mark>sweater</mark> &amp; shirt", hits[1].Snippet)
+
+				hits, err = st.SearchProducts(ctx, SearchProductsParams{Query: "sweater mug", Limit: 1})
+				require.NoError(t, err)
+				require.Len(t, hits, 1)
+
+				hits, err = st.SearchProducts(ctx, SearchProductsParams{Query: "bicycle"})
+				require.NoError(t, err)
+				require.Empty(t, hits)
+
+				_, err = st.SearchProducts(ctx, SearchProductsParams{Query: "  ,. "})
+				require.ErrorIs(t, err, ErrEmptySearchQuery)
+			},
+		},
+		{
+			name: "product not found",
+			test: func(t *testing.T, st Storer) {
+				_, err := st.GetProduct(ctx, 12345)
+				require.ErrorIs(t, err, ErrNotFound)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "order lifecycle",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("order@example.com"))
+				require.NoError(t, err)
+
+				p, err := st.CreateProduct(ctx, newProduct())
+				require.NoError(t, err)
+
+				o, err := st.CreateOrder(ctx, &Order{
+					PaymentMethod: "card",
+					TaxPrice:      10,
+					ShippingPrice: 20,
+					TotalPrice:    130,
+					UserID:        u.ID,
+					Items: []OrderItem{
+						{Name: p.Name, Quantity: 1, Image: p.Image, Price: p.Price, ProductID: p.ID},
+					},
+				})
+				require.NoError(t, err)
+				require.NotZero(t, o.ID)
+
+				got, err := st.GetOrder(ctx, u.ID)
+				require.NoError(t, err)
+				require.Equal(t, o.ID, got.ID)
+				require.Len(t, got.Items, 1)
+				require.Equal(t, p.ID, got.Items[0].ProductID)
+
+				got, err = st.GetOrderByID(ctx, o.ID)
+				require.NoError(t, err)
+				require.Equal(t, u.ID, got.UserID)
+				require.Len(t, got.Items, 1)
+
+				orders, err := st.ListOrders(ctx)
+				require.NoError(t, err)
+				require.Len(t, orders, 1)
+				require.Len(t, orders[0].Items, 1)
+
+				// * товар из заказа нельзя удалить (внешний ключ)
+				require.Error(t, st.DeleteProduct(ctx, p.ID))
+
+				require.NoError(t, st.DeleteOrder(ctx, o.ID))
+
+				_, err = st.GetOrder(ctx, u.ID)
+				require.ErrorIs(t, err, ErrNotFound)
+
+				_, err = st.GetOrderByID(ctx, o.ID)
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "order status transitions",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("status@example.com"))
+				require.NoError(t, err)
+
+				p, err := st.CreateProduct(ctx, newProduct())
+				require.NoError(t, err)
+
+				o, err := st.CreateOrder(ctx, &Order{
+					PaymentMethod: "card",
+					UserID:        u.ID,
+					Items:         []OrderItem{{Name: p.Name, Quantity: 1, Image: p.Image, Price: p.Price, ProductID: p.ID}},
+				})
+				require.NoError(t, err)
+				require.Equal(t, OrderStatusPending, o.Status)
+
+				_, err = st.UpdateOrderStatus(ctx, o.ID, OrderStatusShipped)
+				require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+				for _, next := range []OrderStatus{OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusRefunded} {
+					uo, err := st.UpdateOrderStatus(ctx, o.ID, next)
+					require.NoError(t, err)
+					require.Equal(t, next, uo.Status)
+					require.Len(t, uo.Items, 1)
+				}
+
+				_, err = st.UpdateOrderStatus(ctx, o.ID, OrderStatusCancelled)
+				require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+				_, err = st.UpdateOrderStatus(ctx, 12345, OrderStatusPaid)
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "stock reservation",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("stock@example.com"))
+				require.NoError(t, err)
+
+				p, err := st.CreateProduct(ctx, newProduct())
+				require.NoError(t, err)
+
+				newOrder := func(quantity int64) *Order {
+					return &Order{
+						PaymentMethod: "card",
+						UserID:        u.ID,
+						Items:         []OrderItem{{Name: p.Name, Quantity: quantity, Image: p.Image, Price: p.Price, ProductID: p.ID}},
+					}
+				}
+
+				requireStock := func(expected int64) {
+					gp, err := st.GetProduct(ctx, p.ID)
+					require.NoError(t, err)
+					require.Equal(t, expected, gp.CountInStock)
+				}
+
+				_, err = st.CreateOrder(ctx, newOrder(p.CountInStock+1))
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				requireStock(100)
+
+				o, err := st.CreateOrder(ctx, newOrder(40))
+				require.NoError(t, err)
+				requireStock(60)
+
+				_, err = st.UpdateOrderStatus(ctx, o.ID, OrderStatusCancelled)
+				require.NoError(t, err)
+				requireStock(100)
+
+				// * цена товара изменилась после расчета заказа - остаток не списывается
+				stale := newOrder(1)
+				stale.Items[0].Price = p.Price + 1
+				_, err = st.CreateOrder(ctx, stale)
+				require.ErrorIs(t, err, ErrConflict)
+				requireStock(100)
+
+				// * отмененный заказ уже вернул товар, удаление не должно вернуть его второй раз
+				require.NoError(t, st.DeleteOrder(ctx, o.ID))
+				requireStock(100)
+
+				o, err = st.CreateOrder(ctx, newOrder(25))
+				require.NoError(t, err)
+				requireStock(75)
+
+				require.NoError(t, st.DeleteOrder(ctx, o.ID))
+				requireStock(100)
+
+				require.ErrorIs(t, st.DeleteOrder(ctx, o.ID), ErrNotFound)
+			},
+		},
+		{
+			name: "order with unknown product",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("unknown@example.com"))
+				require.NoError(t, err)
+
+				_, err = st.CreateOrder(ctx, &Order{
+					PaymentMethod: "card",
+					UserID:        u.ID,
+					Items:         []OrderItem{{Name: "ghost", Quantity: 1, ProductID: 12345}},
+				})
+				require.ErrorIs(t, err, ErrNotFound)
+
+				orders, err := st.ListOrders(ctx)
+				require.NoError(t, err)
+				require.Empty(t, orders)
+			},
+		},
+		{
+			name: "foreign keys",
+			test: func(t *testing.T, st Storer) {
+				_, err := st.CreateOrder(ctx, &Order{PaymentMethod: "card", UserID: 12345})
+				require.ErrorIs(t, err, ErrForeignKey)
+
+				u, err := st.CreateUser(ctx, newUser("fk@example.com"))
+				require.NoError(t, err)
+
+				p, err := st.CreateProduct(ctx, newProduct())
+				require.NoError(t, err)
+
+				_, err = st.CreateOrder(ctx, &Order{
+					PaymentMethod: "card",
+					UserID:        u.ID,
+					Items:         []OrderItem{{Name: p.Name, Quantity: 1, Price: p.Price, ProductID: p.ID}},
+				})
+				require.NoError(t, err)
+
+				require.ErrorIs(t, st.DeleteProduct(ctx, p.ID), ErrForeignKey)
+				require.ErrorIs(t, st.DeleteUser(ctx, u.ID), ErrForeignKey)
+			},
+		},
+		{
+			name: "user crud",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("user@example.com"))
+				require.NoError(t, err)
+				require.NotZero(t, u.ID)
+
+				_, err = st.CreateUser(ctx, newUser("user@example.com"))
+				require.ErrorIs(t, err, ErrDuplicateEmail)
+
+				gu, err := st.GetUser(ctx, "user@example.com")
+				require.NoError(t, err)
+				require.Equal(t, u.ID, gu.ID)
+
+				now := time.Now()
+				gu.Name = "renamed"
+				gu.UpdatedAt = &now
+				_, err = st.UpdateUser(ctx, gu)
+				require.NoError(t, err)
+
+				gu, err = st.GetUser(ctx, "user@example.com")
+				require.NoError(t, err)
+				require.Equal(t, "renamed", gu.Name)
+
+				users, err := st.ListUsers(ctx)
+				require.NoError(t, err)
+				require.Len(t, users, 1)
+
+				require.NoError(t, st.DeleteUser(ctx, u.ID))
+
+				_, err = st.GetUser(ctx, "user@example.com")
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "session lifecycle",
+			test: func(t *testing.T, st Storer) {
+				expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+				_, err := st.CreateSession(ctx, &Session{
+					ID:           "session-1",
+					UserEmail:    "session@example.com",
+					RefreshToken: "refresh",
+					ExpiresAt:    &expiresAt,
+				})
+				require.NoError(t, err)
+
+				s, err := st.GetSession(ctx, "session-1")
+				require.NoError(t, err)
+				require.False(t, s.IsRevoked)
+				require.NotNil(t, s.ExpiresAt)
+
+				require.NoError(t, st.RevokeSession(ctx, "session-1"))
+
+				s, err = st.GetSession(ctx, "session-1")
+				require.NoError(t, err)
+				require.True(t, s.IsRevoked)
+
+				require.NoError(t, st.DeleteSession(ctx, "session-1"))
+
+				_, err = st.GetSession(ctx, "session-1")
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "session with rs256 refresh token",
+			test: func(t *testing.T, st Storer) {
+				refreshToken := newRS256RefreshToken(t)
+				require.Greater(t, len(refreshToken), 512)
+
+				expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+				_, err := st.CreateSession(ctx, &Session{ID: "rs256", UserEmail: "session@example.com", RefreshToken: refreshToken, ExpiresAt: &expiresAt})
+				require.NoError(t, err)
+
+				s, err := st.GetSession(ctx, "rs256")
+				require.NoError(t, err)
+				require.Equal(t, refreshToken, s.RefreshToken)
+			},
+		},
+		{
+			name: "session rotation",
+			test: func(t *testing.T, st Storer) {
+				expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+				newSession := func(id string) *Session {
+					return &Session{ID: id, UserEmail: "session@example.com", RefreshToken: "refresh-" + id, ExpiresAt: &expiresAt}
+				}
+
+				_, err := st.CreateSession(ctx, newSession("session-1"))
+				require.NoError(t, err)
+
+				s, err := st.GetSession(ctx, "session-1")
+				require.NoError(t, err)
+				require.Equal(t, "session-1", s.FamilyID)
+
+				_, err = st.RotateSession(ctx, "session-1", newSession("session-2"))
+				require.NoError(t, err)
+
+				s, err = st.GetSession(ctx, "session-2")
+				require.NoError(t, err)
+				require.Equal(t, "session-1", s.FamilyID)
+				require.Equal(t, "session-1", s.ParentID)
+
+				s, err = st.GetSession(ctx, "session-1")
+				require.NoError(t, err)
+				require.NotNil(t, s.RotatedAt)
+
+				other := newSession("session-x")
+				other.UserEmail = "other@example.com"
+				_, err = st.RotateSession(ctx, "session-2", other)
+				require.ErrorIs(t, err, ErrSessionRevoked)
+
+				// * повторное использование замененного токена отзывает все семейство
+				_, err = st.RotateSession(ctx, "session-1", newSession("session-3"))
+				require.ErrorIs(t, err, ErrRefreshTokenReused)
+
+				s, err = st.GetSession(ctx, "session-2")
+				require.NoError(t, err)
+				require.True(t, s.IsRevoked)
+
+				_, err = st.RotateSession(ctx, "session-2", newSession("session-3"))
+				require.ErrorIs(t, err, ErrSessionRevoked)
+
+				_, err = st.RotateSession(ctx, "missing", newSession("session-3"))
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "user sessions",
+			test: func(t *testing.T, st Storer) {
+				expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+				expiredAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+				for _, s := range []*Session{
+					{ID: "laptop", UserEmail: "session@example.com", RefreshToken: "r1", ExpiresAt: &expiresAt, UserAgent: "Firefox", IPAddress: "10.0.0.1"},
+					{ID: "phone", UserEmail: "session@example.com", RefreshToken: "r2", ExpiresAt: &expiresAt, UserAgent: "Safari", IPAddress: "10.0.0.2"},
+					{ID: "expired", UserEmail: "session@example.com", RefreshToken: "r3", ExpiresAt: &expiredAt},
+					{ID: "other", UserEmail: "other@example.com", RefreshToken: "r4", ExpiresAt: &expiresAt},
+				} {
+					_, err := st.CreateSession(ctx, s)
+					require.NoError(t, err)
+				}
+
+				require.NoError(t, st.RevokeSession(ctx, "phone"))
+
+				sessions, err := st.ListSessions(ctx, "session@example.com")
+				require.NoError(t, err)
+				require.Len(t, sessions, 1)
+				require.Equal(t, "laptop", sessions[0].ID)
+				require.Equal(t, "Firefox", sessions[0].UserAgent)
+				require.Equal(t, "10.0.0.1", sessions[0].IPAddress)
+
+				require.NoError(t, st.RevokeUserSessions(ctx, "session@example.com"))
+
+				sessions, err = st.ListSessions(ctx, "session@example.com")
+				require.NoError(t, err)
+				require.Empty(t, sessions)
+
+				sessions, err = st.ListSessions(ctx, "other@example.com")
+				require.NoError(t, err)
+				require.Len(t, sessions, 1)
+			},
+		},
+		{
+			name: "email verification",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("verify@example.com"))
+				require.NoError(t, err)
+				require.Nil(t, u.EmailVerifiedAt)
+
+				//* токен выдан на другой email
+				_, err = st.VerifyEmail(ctx, u.ID, "old@example.com")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				vu, err := st.VerifyEmail(ctx, u.ID, u.Email)
+				require.NoError(t, err)
+				require.NotNil(t, vu.EmailVerifiedAt)
+
+				gu, err := st.GetUser(ctx, u.Email)
+				require.NoError(t, err)
+				require.NotNil(t, gu.EmailVerifiedAt)
+
+				_, err = st.VerifyEmail(ctx, u.ID, u.Email)
+				require.ErrorIs(t, err, ErrEmailAlreadyVerified)
+			},
+		},
+		{
+			name: "password reset",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("reset@example.com"))
+				require.NoError(t, err)
+
+				_, err = st.CreateSession(ctx, &Session{ID: "reset-session", UserEmail: u.Email, RefreshToken: "refresh", ExpiresAt: toTimePtr(time.Now().Add(time.Hour))})
+				require.NoError(t, err)
+
+				expiresAt := time.Now().Add(time.Hour)
+				_, err = st.CreatePasswordReset(ctx, &PasswordReset{TokenHash: "first", UserID: u.ID, ExpiresAt: expiresAt})
+				require.NoError(t, err)
+				_, err = st.CreatePasswordReset(ctx, &PasswordReset{TokenHash: "second", UserID: u.ID, ExpiresAt: expiresAt})
+				require.NoError(t, err)
+				_, err = st.CreatePasswordReset(ctx, &PasswordReset{TokenHash: "expired", UserID: u.ID, ExpiresAt: time.Now().Add(-time.Minute)})
+				require.NoError(t, err)
+
+				_, err = st.CreatePasswordReset(ctx, &PasswordReset{TokenHash: "orphan", UserID: u.ID + 1000, ExpiresAt: expiresAt})
+				require.ErrorIs(t, err, ErrForeignKey)
+
+				_, err = st.ResetPassword(ctx, "expired", "new-hash")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				ru, err := st.ResetPassword(ctx, "first", "new-hash")
+				require.NoError(t, err)
+				require.Equal(t, u.ID, ru.ID)
+				require.Equal(t, "new-hash", ru.Password)
+
+				gu, err := st.GetUser(ctx, u.Email)
+				require.NoError(t, err)
+				require.Equal(t, "new-hash", gu.Password)
+
+				s, err := st.GetSession(ctx, "reset-session")
+				require.NoError(t, err)
+				require.True(t, s.IsRevoked)
+
+				//* токен одноразовый, остальные токены пользователя тоже погашены
+				_, err = st.ResetPassword(ctx, "first", "other-hash")
+				require.ErrorIs(t, err, ErrNotFound)
+				_, err = st.ResetPassword(ctx, "second", "other-hash")
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "password rehash",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("rehash@example.com"))
+				require.NoError(t, err)
+
+				require.NoError(t, st.RehashPassword(ctx, u.ID, u.Password, "rehashed"))
+				gu, err := st.GetUser(ctx, u.Email)
+				require.NoError(t, err)
+				require.Equal(t, "rehashed", gu.Password)
+
+				//* пароль сменили после проверки - старый хэш не совпадает
+				require.NoError(t, st.RehashPassword(ctx, u.ID, u.Password, "stale"))
+				gu, err = st.GetUser(ctx, u.Email)
+				require.NoError(t, err)
+				require.Equal(t, "rehashed", gu.Password)
+			},
+		},
+		{
+			name: "password change",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("change@example.com"))
+				require.NoError(t, err)
+
+				expiresAt := toTimePtr(time.Now().Add(time.Hour))
+				_, err = st.CreateSession(ctx, &Session{ID: "change-current", UserEmail: u.Email, RefreshToken: "current", ExpiresAt: expiresAt})
+				require.NoError(t, err)
+				_, err = st.CreateSession(ctx, &Session{ID: "change-other", UserEmail: u.Email, RefreshToken: "other", ExpiresAt: expiresAt})
+				require.NoError(t, err)
+				_, err = st.CreatePasswordReset(ctx, &PasswordReset{TokenHash: "change-reset", UserID: u.ID, ExpiresAt: *expiresAt})
+				require.NoError(t, err)
+
+				_, err = st.ChangePassword(ctx, u.ID, "stale", "new-hash", "change-current")
+				require.ErrorIs(t, err, ErrConflict)
+
+				_, err = st.ChangePassword(ctx, u.ID+1000, u.Password, "new-hash", "change-current")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				cu, err := st.ChangePassword(ctx, u.ID, u.Password, "new-hash", "change-current")
+				require.NoError(t, err)
+				require.Equal(t, "new-hash", cu.Password)
+
+				gu, err := st.GetUser(ctx, u.Email)
+				require.NoError(t, err)
+				require.Equal(t, "new-hash", gu.Password)
+
+				//* текущая сессия остается, остальные отозваны
+				s, err := st.GetSession(ctx, "change-current")
+				require.NoError(t, err)
+				require.False(t, s.IsRevoked)
+				s, err = st.GetSession(ctx, "change-other")
+				require.NoError(t, err)
+				require.True(t, s.IsRevoked)
+
+				_, err = st.ResetPassword(ctx, "change-reset", "other-hash")
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "totp",
+			test: func(t *testing.T, st Storer) {
+				u, err := st.CreateUser(ctx, newUser("totp@example.com"))
+				require.NoError(t, err)
+
+				_, err = st.GetTOTP(ctx, u.ID)
+				require.ErrorIs(t, err, ErrNotFound)
+
+				require.ErrorIs(t, st.SetTOTPSecret(ctx, u.ID+1000, "SECRET"), ErrForeignKey)
+
+				//* неподтвержденный секрет можно заменить
+				require.NoError(t, st.SetTOTPSecret(ctx, u.ID, "FIRST"))
+				require.NoError(t, st.SetTOTPSecret(ctx, u.ID, "SECOND"))
+
+				tp, err := st.GetTOTP(ctx, u.ID)
+				require.NoError(t, err)
+				require.Equal(t, "SECOND", tp.Secret)
+				require.Nil(t, tp.EnabledAt)
+
+				//* до включения коды не принимаются
+				require.ErrorIs(t, st.UseTOTPStep(ctx, u.ID, 10), ErrInvalidMFACode)
+
+				require.NoError(t, st.EnableTOTP(ctx, u.ID, 10, []string{"code1", "code2"}))
+				require.ErrorIs(t, st.EnableTOTP(ctx, u.ID, 11, nil), ErrTOTPAlreadyEnabled)
+				require.ErrorIs(t, st.SetTOTPSecret(ctx, u.ID, "THIRD"), ErrTOTPAlreadyEnabled)
+
+				tp, err = st.GetTOTP(ctx, u.ID)
+				require.NoError(t, err)
+				require.NotNil(t, tp.EnabledAt)
+
+				//* код шага подтверждения повторно не принимается
+				require.ErrorIs(t, st.UseTOTPStep(ctx, u.ID, 10), ErrInvalidMFACode)
+				require.NoError(t, st.UseTOTPStep(ctx, u.ID, 11))
+				require.ErrorIs(t, st.UseTOTPStep(ctx, u.ID, 11), ErrInvalidMFACode)
+
+				require.NoError(t, st.UseRecoveryCode(ctx, u.ID, "code1"))
+				require.ErrorIs(t, st.UseRecoveryCode(ctx, u.ID, "code1"), ErrInvalidMFACode)
+				require.ErrorIs(t, st.UseRecoveryCode(ctx, u.ID, "unknown"), ErrInvalidMFACode)
+
+				n, err := st.CountRecoveryCodes(ctx, u.ID)
+				require.NoError(t, err)
+				require.Equal(t, int64(1), n)
+
+				require.NoError(t, st.DeleteTOTP(ctx, u.ID))
+
+				_, err = st.GetTOTP(ctx, u.ID)
+				require.ErrorIs(t, err, ErrNotFound)
+
+				n, err = st.CountRecoveryCodes(ctx, u.ID)
+				require.NoError(t, err)
+				require.Zero(t, n)
+			},
+		},
+		{
+			name: "login attempts",
+			test: func(t *testing.T, st Storer) {
+				_, err := st.GetLoginAttempt(ctx, "account:user@example.com")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				for i := int64(1); i <= 3; i++ {
+					a, err := st.AddLoginFailure(ctx, "account:user@example.com", time.Hour)
+					require.NoError(t, err)
+					require.Equal(t, i, a.Failures)
+				}
+
+				a, err := st.GetLoginAttempt(ctx, "account:user@example.com")
+				require.NoError(t, err)
+				require.Equal(t, int64(3), a.Failures)
+				require.WithinDuration(t, time.Now(), a.LastFailureAt, time.Minute)
+
+				//* ключи считаются отдельно
+				a, err = st.AddLoginFailure(ctx, "ip:127.0.0.1", time.Hour)
+				require.NoError(t, err)
+				require.Equal(t, int64(1), a.Failures)
+
+				//* после окна без неудач счет начинается заново
+				time.Sleep(10 * time.Millisecond)
+				a, err = st.AddLoginFailure(ctx, "account:user@example.com", time.Millisecond)
+				require.NoError(t, err)
+				require.Equal(t, int64(1), a.Failures)
+
+				require.NoError(t, st.ResetLoginAttempts(ctx, "account:user@example.com"))
+
+				_, err = st.GetLoginAttempt(ctx, "account:user@example.com")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				//* отмена неудачи возвращает время предыдущей, если после нее неудач не было
+				first, err := st.AddLoginFailure(ctx, "ip:127.0.0.1", time.Hour)
+				require.NoError(t, err)
+				require.Equal(t, int64(2), first.Failures)
+
+				time.Sleep(10 * time.Millisecond)
+				second, err := st.AddLoginFailure(ctx, "ip:127.0.0.1", time.Hour)
+				require.NoError(t, err)
+
+				require.NoError(t, st.RemoveLoginFailure(ctx, "ip:127.0.0.1", second.LastFailureAt, first.LastFailureAt))
+
+				a, err = st.GetLoginAttempt(ctx, "ip:127.0.0.1")
+				require.NoError(t, err)
+				require.Equal(t, int64(2), a.Failures)
+				require.True(t, first.LastFailureAt.Equal(a.LastFailureAt))
+
+				//* более поздняя неудача другого запроса остается последней
+				second, err = st.AddLoginFailure(ctx, "ip:127.0.0.1", time.Hour)
+				require.NoError(t, err)
+
+				time.Sleep(10 * time.Millisecond)
+				third, err := st.AddLoginFailure(ctx, "ip:127.0.0.1", time.Hour)
+				require.NoError(t, err)
+
+				require.NoError(t, st.RemoveLoginFailure(ctx, "ip:127.0.0.1", second.LastFailureAt, first.LastFailureAt))
+
+				a, err = st.GetLoginAttempt(ctx, "ip:127.0.0.1")
+				require.NoError(t, err)
+				require.Equal(t, int64(3), a.Failures)
+				require.True(t, third.LastFailureAt.Equal(a.LastFailureAt))
+
+				//* без неудач ключ удаляется, отмена для отсутствующего ключа ничего не делает
+				for range 3 {
+					require.NoError(t, st.RemoveLoginFailure(ctx, "ip:127.0.0.1", third.LastFailureAt, time.Time{}))
+				}
+
+				_, err = st.GetLoginAttempt(ctx, "ip:127.0.0.1")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				require.NoError(t, st.RemoveLoginFailure(ctx, "ip:127.0.0.1", third.LastFailureAt, time.Time{}))
+			},
+		},
+		{
+			name: "roles",
+			test: func(t *testing.T, st Storer) {
+				roles, err := st.ListRoles(ctx)
+				require.NoError(t, err)
+				require.Len(t, roles, 4)
+
+				u, err := st.CreateUser(ctx, newUser("roles@example.com"))
+				require.NoError(t, err)
+
+				roles, err = st.GetUserRoles(ctx, u.ID)
+				require.NoError(t, err)
+				require.Empty(t, roles)
+
+				require.NoError(t, st.AssignRole(ctx, u.ID, "order-support"))
+				require.NoError(t, st.AssignRole(ctx, u.ID, "catalog-manager"))
+				//* повторное назначение ничего не меняет
+				require.NoError(t, st.AssignRole(ctx, u.ID, "catalog-manager"))
+
+				roles, err = st.GetUserRoles(ctx, u.ID)
+				require.NoError(t, err)
+				require.Len(t, roles, 2)
+				require.Equal(t, "catalog-manager", roles[0].Name)
+				require.Equal(t, Permissions{"products:write"}, roles[0].Permissions)
+				require.Equal(t, "order-support", roles[1].Name)
+
+				err = st.AssignRole(ctx, u.ID, "unknown")
+				require.ErrorIs(t, err, ErrForeignKey)
+
+				err = st.AssignRole(ctx, u.ID+1000, "customer")
+				require.ErrorIs(t, err, ErrForeignKey)
+
+				require.NoError(t, st.UnassignRole(ctx, u.ID, "order-support"))
+
+				//* пользователь с ролями создается целиком или не создается
+				admin, err := st.CreateUser(ctx, newUser("superadmin@example.com"), "superadmin", "customer")
+				require.NoError(t, err)
+
+				roles, err = st.GetUserRoles(ctx, admin.ID)
+				require.NoError(t, err)
+				require.Len(t, roles, 2)
+
+				_, err = st.CreateUser(ctx, newUser("broken@example.com"), "unknown")
+				require.ErrorIs(t, err, ErrForeignKey)
+
+				_, err = st.GetUser(ctx, "broken@example.com")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				roles, err = st.GetUserRoles(ctx, u.ID)
+				require.NoError(t, err)
+				require.Len(t, roles, 1)
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorer(t))
+		})
+	}
+}
@@ -17,14 +17,15 @@ type Product struct {
 }
 
 type Order struct {
-	ID            int64      `db:"id"`
-	PaymentMethod string     `db:"payment_method"`
-	TaxPrice      float32    `db:"tax_price"`
-	ShippingPrice float32    `db:"shipping_price"`
-	TotalPrice    float32    `db:"total_price"`
-	UserID        int64      `db:"user_id"`
-	CreatedAt     time.Time  `db:"created_at"`
-	UpdatedAt     *time.Time `db:"updated_at"`
+	ID            int64       `db:"id"`
+	PaymentMethod string      `db:"payment_method"`
+	TaxPrice      float32     `db:"tax_price"`
+	ShippingPrice float32     `db:"shipping_price"`
+	TotalPrice    float32     `db:"total_price"`
+	UserID        int64       `db:"user_id"`
+	Status        OrderStatus `db:"status"`
+	CreatedAt     time.Time   `db:"created_at"`
+	UpdatedAt     *time.Time  `db:"updated_at"`
 	Items         []OrderItem
 }
 
@@ -47,6 +48,47 @@ type User struct {
 	IsAdmin   bool       `db:"is_admin"`
 	CreatedAt time.Time  `db:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at"`
+	//* когда пользователь подтвердил email, nil - не подтвержден
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
+}
+
+//* ROLES
+
+// * Role - роль с набором прав, назначается пользователям через user_roles
+type Role struct {
+	Name        string      `db:"name"`
+	Description string      `db:"description"`
+	Permissions Permissions `db:"permissions"`
+	CreatedAt   time.Time   `db:"created_at"`
+}
+
+// * PasswordReset - запрос сброса пароля. Хранится только sha256 хэш токена из письма,
+// * UsedAt - когда токен был использован (или отменен новым сбросом)
+type PasswordReset struct {
+	TokenHash string     `db:"token_hash"`
+	UserID    int64      `db:"user_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// * TOTP - секрет второго фактора пользователя, зашифрованный totp.SecretCipher (или открытым текстом,
+// * если ключ шифрования не задан). До подтверждения кодом EnabledAt = nil,
+// * LastUsedStep - шаг времени последнего принятого кода, коды этого и прошлых шагов повторно не принимаются
+type TOTP struct {
+	UserID       int64      `db:"user_id"`
+	Secret       string     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// * LoginAttempt - неудачные попытки входа по ключу (email или IP клиента),
+// * LastFailureAt - время последней неудачи, по нему ecomm-api считает блокировку
+type LoginAttempt struct {
+	Key           string    `db:"attempt_key"`
+	Failures      int64     `db:"failures"`
+	LastFailureAt time.Time `db:"last_failure_at"`
 }
 
 //* SESSIONS
@@ -58,4 +100,12 @@ type Session struct {
 	IsRevoked    bool       `db:"is_revoked"`
 	CreatedAt    time.Time  `db:"created_at"`
 	ExpiresAt    *time.Time `db:"expires_at"`
+	//* семейство токенов обновления: все сессии, полученные ротацией после одного входа,
+	//* ParentID - сессия, которую заменила эта, RotatedAt - когда эта сессия была заменена
+	FamilyID  string     `db:"family_id"`
+	ParentID  string     `db:"parent_id"`
+	RotatedAt *time.Time `db:"rotated_at"`
+	//* устройство, с которого выполнен вход
+	UserAgent string `db:"user_agent"`
+	IPAddress string `db:"ip_address"`
 }
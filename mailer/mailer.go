@@ -0,0 +1,124 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// * Mailer - отправка писем пользователям (подтверждение email и т.п.)
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// * Message - текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+var (
+	_ Mailer = (*SMTPMailer)(nil)
+	_ Mailer = (*FileMailer)(nil)
+	_ Mailer = (*LogMailer)(nil)
+)
+
+// * SMTPMailer отправляет письма через SMTP сервер (в том числе локальный, например MailHog).
+// * Без username письма отправляются без авторизации
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	m := &SMTPMailer{addr: addr, from: from}
+
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, buildMessage(m.from, msg, time.Now())); err != nil {
+		return fmt.Errorf("error sending mail to %s: %w", msg.To, err)
+	}
+
+	return nil
+}
+
+// * FileMailer сохраняет каждое письмо в отдельный .eml файл в каталоге dir
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating mail directory: %w", err)
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405"), uuid.NewString())
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, msg, now), 0o600); err != nil {
+		return fmt.Errorf("error writing mail to %s: %w", msg.To, err)
+	}
+
+	return nil
+}
+
+// * LogMailer пишет письма в лог, для локального запуска
+type LogMailer struct {
+	logger *log.Logger
+}
+
+func NewLogMailer(logger *log.Logger) *LogMailer {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return &LogMailer{logger: logger}
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	m.logger.Printf("mail to=%q subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// * письмо в формате RFC 5322 с заголовками и телом text/plain в UTF-8
+func buildMessage(from string, msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return b.Bytes()
+}
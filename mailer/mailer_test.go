@@ -0,0 +1,105 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = Message{To: "user@example.com", Subject: "Подтвердите email", Body: "line 1\nline 2"}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m, err := NewFileMailer(dir, "shop@example.com")
+	require.NoError(t, err)
+	require.NoError(t, m.Send(context.Background(), testMessage))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "To: user@example.com\r\n")
+	require.Contains(t, string(data), "Subject: =?utf-8?q?")
+	require.True(t, strings.HasSuffix(string(data), "\r\n\r\nline 1\r\nline 2"))
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := NewLogMailer(log.New(&buf, "", 0))
+	require.NoError(t, m.Send(context.Background(), testMessage))
+	require.Contains(t, buf.String(), `to="user@example.com"`)
+	require.Contains(t, buf.String(), "line 2")
+}
+
+// * минимальный SMTP сервер без TLS и авторизации, как локальные заглушки SMTP
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 send data")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				received <- string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("502 unknown command")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+
+	m := NewSMTPMailer(addr, "shop@example.com", "", "")
+	require.NoError(t, m.Send(context.Background(), testMessage))
+
+	//* textproto.ReadDotBytes заменяет \r\n на \n
+	data := <-received
+	require.Contains(t, data, "From: shop@example.com\n")
+	require.True(t, strings.HasSuffix(data, "\n\nline 1\nline 2\n"))
+}
@@ -0,0 +1,65 @@
+package rbac
+
+// * права доступа, которые выдаются через роли и передаются в claims токена доступа
+const (
+	PermProductsWrite = "products:write"
+	PermOrdersRead    = "orders:read"
+	PermOrdersWrite   = "orders:write"
+	PermUsersRead     = "users:read"
+	PermUsersWrite    = "users:write"
+	PermRolesWrite    = "roles:write"
+
+	// * все права, есть только у роли superadmin
+	PermAll = "*"
+)
+
+// * встроенные роли, создаются миграцией 20250815110000_add_roles
+const (
+	RoleCustomer       = "customer"
+	RoleCatalogManager = "catalog-manager"
+	RoleOrderSupport   = "order-support"
+	RoleSuperadmin     = "superadmin"
+)
+
+// * Role - роль с набором прав
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// * должны совпадать с ролями, которые создает миграция
+var DefaultRoles = []Role{
+	{Name: RoleCustomer, Description: "buyer, works only with own orders and profile"},
+	{Name: RoleCatalogManager, Description: "manages the product catalog", Permissions: []string{PermProductsWrite}},
+	{Name: RoleOrderSupport, Description: "handles customer orders", Permissions: []string{PermOrdersRead, PermOrdersWrite, PermUsersRead}},
+	{Name: RoleSuperadmin, Description: "full access", Permissions: []string{PermAll}},
+}
+
+// * Has проверяет, дает ли набор прав право perm (PermAll дает любое)
+func Has(perms []string, perm string) bool {
+	for _, p := range perms {
+		if p == PermAll || p == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// * Merge объединяет права нескольких ролей без повторов, сохраняя порядок
+func Merge(sets ...[]string) []string {
+	seen := make(map[string]bool)
+
+	var res []string
+	for _, set := range sets {
+		for _, p := range set {
+			if !seen[p] {
+				seen[p] = true
+				res = append(res, p)
+			}
+		}
+	}
+
+	return res
+}
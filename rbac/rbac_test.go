@@ -0,0 +1,22 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHas(t *testing.T) {
+	require.True(t, Has([]string{PermOrdersRead, PermProductsWrite}, PermProductsWrite))
+	require.False(t, Has([]string{PermOrdersRead}, PermProductsWrite))
+	require.False(t, Has(nil, PermProductsWrite))
+	require.True(t, Has([]string{PermAll}, PermRolesWrite))
+}
+
+func TestMerge(t *testing.T) {
+	require.Equal(t,
+		[]string{PermOrdersRead, PermOrdersWrite, PermProductsWrite},
+		Merge([]string{PermOrdersRead, PermOrdersWrite}, nil, []string{PermOrdersRead, PermProductsWrite}),
+	)
+	require.Nil(t, Merge())
+}
@@ -0,0 +1,88 @@
+package rpcmeta
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// * ключи gRPC metadata, которые ecomm-api передает в ecomm-grpc
+const (
+	RequestIDKey   = "x-request-id"
+	UserIDKey      = "x-user-id"
+	UserEmailKey   = "x-user-email"
+	UserIsAdminKey = "x-user-is-admin"
+)
+
+// * RequestInfo - идентификатор запроса и пользователь, от имени которого он выполняется
+type RequestInfo struct {
+	RequestID string
+	UserID    int64
+	UserEmail string
+	IsAdmin   bool
+}
+
+func (ri RequestInfo) HasUser() bool {
+	return ri.UserID != 0
+}
+
+// * добавляет RequestInfo в исходящие metadata клиента
+func AppendToOutgoingContext(ctx context.Context, ri RequestInfo) context.Context {
+	var kv []string
+
+	if ri.RequestID != "" {
+		kv = append(kv, RequestIDKey, ri.RequestID)
+	}
+
+	if ri.HasUser() {
+		kv = append(kv,
+			UserIDKey, strconv.FormatInt(ri.UserID, 10),
+			UserEmailKey, ri.UserEmail,
+			UserIsAdminKey, strconv.FormatBool(ri.IsAdmin),
+		)
+	}
+
+	if len(kv) == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// * читает RequestInfo из входящих metadata сервера
+func FromIncomingContext(ctx context.Context) RequestInfo {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return RequestInfo{}
+	}
+
+	ri := RequestInfo{
+		RequestID: first(md, RequestIDKey),
+		UserEmail: first(md, UserEmailKey),
+	}
+
+	ri.UserID, _ = strconv.ParseInt(first(md, UserIDKey), 10, 64)
+	ri.IsAdmin, _ = strconv.ParseBool(first(md, UserIsAdminKey))
+
+	return ri
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+type requestInfoKey struct{}
+
+func NewContext(ctx context.Context, ri RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, ri)
+}
+
+func FromContext(ctx context.Context) (RequestInfo, bool) {
+	ri, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return ri, ok
+}
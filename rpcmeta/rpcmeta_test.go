@@ -0,0 +1,26 @@
+package rpcmeta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestInfoRoundTrip(t *testing.T) {
+	ri := RequestInfo{RequestID: "host/abc-000001", UserID: 42, UserEmail: "user@example.com", IsAdmin: true}
+
+	ctx := AppendToOutgoingContext(context.Background(), ri)
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(t, ok)
+
+	got := FromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+	require.Equal(t, ri, got)
+
+	// * без пользователя передается только request ID
+	ctx = AppendToOutgoingContext(context.Background(), RequestInfo{RequestID: "req"})
+	md, _ = metadata.FromOutgoingContext(ctx)
+	require.Empty(t, md.Get(UserIDKey))
+	require.False(t, FromIncomingContext(metadata.NewIncomingContext(context.Background(), md)).HasUser())
+}
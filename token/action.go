@@ -0,0 +1,97 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// * назначения токенов действий, токен одного назначения не принимается для другого
+const (
+	PurposeVerifyEmail = "verify-email"
+	//* второй шаг входа с включенной двухфакторной аутентификацией
+	PurposeMFALogin = "mfa-login"
+)
+
+var ErrTokenPurpose = errors.New("token purpose mismatch")
+
+// * ActionClaims - токен действия для ссылок из писем (подтверждение email) и второго шага входа.
+// * Поля отличаются от UserClaims (uid вместо id), токен доступа из него не получится
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	UserID  int64  `json:"uid"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// * ActionTokenMaker подписывает токены действий HS256 ключом, производным от секрета,
+// * поэтому даже при общем SECRET_KEY токен действия не проходит как токен доступа и наоборот
+type ActionTokenMaker struct {
+	key []byte
+}
+
+const minActionSecretSize = 32
+
+func NewActionTokenMaker(secretKey string) (*ActionTokenMaker, error) {
+	if len(secretKey) < minActionSecretSize {
+		return nil, fmt.Errorf("action token secret must be at least %d characters long", minActionSecretSize)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte("ecomm action token"))
+
+	return &ActionTokenMaker{key: mac.Sum(nil)}, nil
+}
+
+func (maker *ActionTokenMaker) CreateToken(purpose string, userID int64, email string, duration time.Duration) (string, *ActionClaims, error) {
+	tokenID, err := uuid.NewRandom()
+
+	if err != nil {
+		return "", nil, fmt.Errorf("error generating token id: %w", err)
+	}
+
+	now := time.Now()
+	claims := &ActionClaims{
+		Purpose: purpose,
+		UserID:  userID,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID.String(),
+			Subject:   email,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
+	}
+
+	tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(maker.key)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("error signing token: %w", err)
+	}
+
+	return tokenStr, claims, nil
+}
+
+// * проверка подписи, срока действия и назначения токена
+func (maker *ActionTokenMaker) VerifyToken(purpose, tokenStr string) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(*jwt.Token) (interface{}, error) {
+		return maker.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token: %w", err)
+	}
+
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("%w: expected %s, got %q", ErrTokenPurpose, purpose, claims.Purpose)
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,48 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionTokenMaker(t *testing.T) {
+	secret := "01234567890123456789012345678901"
+
+	maker, err := NewActionTokenMaker(secret)
+	require.NoError(t, err)
+
+	tok, created, err := maker.CreateToken(PurposeVerifyEmail, 7, "user@example.com", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := maker.VerifyToken(PurposeVerifyEmail, tok)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), claims.UserID)
+	require.Equal(t, "user@example.com", claims.Email)
+	require.Equal(t, created.RegisteredClaims.ID, claims.RegisteredClaims.ID)
+
+	_, err = maker.VerifyToken("other-purpose", tok)
+	require.ErrorIs(t, err, ErrTokenPurpose)
+
+	expired, _, err := maker.CreateToken(PurposeVerifyEmail, 7, "user@example.com", -time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(PurposeVerifyEmail, expired)
+	require.Error(t, err)
+
+	//* с тем же секретом токен доступа не принимается как токен действия и наоборот
+	access := NewJWTMaker(secret)
+
+	accessTok, _, err := access.CreateToken(7, "user@example.com", false, nil, "session", time.Hour)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(PurposeVerifyEmail, accessTok)
+	require.Error(t, err)
+
+	_, err = access.VerifyToken(tok)
+	require.Error(t, err)
+
+	_, err = NewActionTokenMaker("short")
+	require.Error(t, err)
+}
@@ -1,22 +1,32 @@
 package token
 
 import (
+	"davidHwang/ecomm/rbac"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// * ServiceAudience - аудитория сервисных токенов ecomm-api. Токены пользователей аудиторию не задают,
+// * поэтому токен администратора нельзя выдать за сервисный
+const ServiceAudience = "ecomm-grpc:service"
+
 type UserClaims struct {
 	ID        int64  `json:"id"`
 	Email     string `json:"email"`
 	IsAdmin   bool   `json:"is_admin"`
 	CreatedAt int64  `json:"created_at"`
+	// * права ролей пользователя (см. пакет rbac)
+	Permissions []string `json:"perms,omitempty"`
+	// * сессия (id токена обновления), к которой относится токен доступа
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewUserClaims(id int64, email string, isAdmin bool, duration time.Duration) (*UserClaims, error) {
+func NewUserClaims(id int64, email string, isAdmin bool, permissions []string, sessionID string, duration time.Duration) (*UserClaims, error) {
 	tokenID, err := uuid.NewRandom()
 
 	if err != nil {
@@ -24,9 +34,11 @@ func NewUserClaims(id int64, email string, isAdmin bool, duration time.Duration)
 	}
 
 	return &UserClaims{
-		ID:      id,
-		Email:   email,
-		IsAdmin: isAdmin,
+		ID:          id,
+		Email:       email,
+		IsAdmin:     isAdmin,
+		Permissions: permissions,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        tokenID.String(),
 			Subject:   email,
@@ -36,3 +48,27 @@ func NewUserClaims(id int64, email string, isAdmin bool, duration time.Duration)
 	}, nil
 
 }
+
+// * NewServiceClaims - claims сервисного токена: без пользователя и сессии, с аудиторией ServiceAudience
+func NewServiceClaims(service string, duration time.Duration) (*UserClaims, error) {
+	claims, err := NewUserClaims(0, "", false, nil, "", duration)
+	if err != nil {
+		return nil, err
+	}
+
+	claims.Subject = service
+	claims.Audience = jwt.ClaimStrings{ServiceAudience}
+
+	return claims, nil
+}
+
+// * IsService - токен выдан сервису ecomm-api, а не пользователю
+func (c *UserClaims) IsService() bool {
+	return slices.Contains(c.Audience, ServiceAudience)
+}
+
+// * сервисный токен ecomm-api имеет все права, пользователь - только права из Permissions.
+// * IsAdmin прав не дает: администратору при выдаче токена добавляются права роли superadmin
+func (c *UserClaims) HasPermission(perm string) bool {
+	return c.IsService() || rbac.Has(c.Permissions, perm)
+}
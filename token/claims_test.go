@@ -0,0 +1,31 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceToken(t *testing.T) {
+	maker := NewJWTMaker("01234567890123456789012345678901")
+
+	tokenStr, _, err := maker.CreateServiceToken("ecomm-api", time.Minute)
+	require.NoError(t, err)
+
+	claims, err := maker.VerifyToken(tokenStr)
+	require.NoError(t, err)
+	require.True(t, claims.IsService())
+	require.False(t, claims.IsAdmin)
+	require.Empty(t, claims.SessionID)
+	require.True(t, claims.HasPermission("products:write"))
+
+	// * токен администратора не является сервисным
+	tokenStr, _, err = maker.CreateToken(1, "admin@example.com", true, nil, "session", time.Minute)
+	require.NoError(t, err)
+
+	claims, err = maker.VerifyToken(tokenStr)
+	require.NoError(t, err)
+	require.False(t, claims.IsService())
+	require.False(t, claims.HasPermission("products:write"))
+}
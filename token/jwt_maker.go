@@ -7,8 +7,11 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// * JWTMaker подписывает токены HS256 общим секретом или, если задан набор ключей,
+// * RS256/EdDSA ключом подписи набора с заголовком kid
 type JWTMaker struct {
 	secretKey string
+	keys      *KeySet
 }
 
 func NewJWTMaker(secretKey string) *JWTMaker {
@@ -17,16 +20,53 @@ func NewJWTMaker(secretKey string) *JWTMaker {
 	}
 }
 
-func (maker *JWTMaker) CreateToken(id int64, email string, isAdmin bool, duration time.Duration) (string, *UserClaims, error) {
+// * проверять токены можно без закрытых ключей: достаточно набора из открытых ключей или JWKS
+func NewKeySetJWTMaker(keys *KeySet) *JWTMaker {
+	return &JWTMaker{
+		keys: keys,
+	}
+}
+
+// * открытые ключи проверки, для HS256 пустой набор (секрет не публикуется)
+func (maker *JWTMaker) JWKS() JWKS {
+	if maker.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
 
-	claims, err := NewUserClaims(id, email, isAdmin, duration)
+	return maker.keys.JWKS()
+}
+
+// * sessionID - сессия токена доступа, для токена обновления пустая
+func (maker *JWTMaker) CreateToken(id int64, email string, isAdmin bool, permissions []string, sessionID string, duration time.Duration) (string, *UserClaims, error) {
+
+	claims, err := NewUserClaims(id, email, isAdmin, permissions, sessionID, duration)
 	if err != nil {
 		return "", nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return maker.signClaims(claims)
+}
 
-	tokenStr, err := token.SignedString([]byte(maker.secretKey))
+func (maker *JWTMaker) CreateServiceToken(service string, duration time.Duration) (string, *UserClaims, error) {
+	claims, err := NewServiceClaims(service, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return maker.signClaims(claims)
+}
+
+func (maker *JWTMaker) signClaims(claims *UserClaims) (string, *UserClaims, error) {
+	var (
+		tokenStr string
+		err      error
+	)
+
+	if maker.keys != nil {
+		tokenStr, err = maker.keys.sign(claims)
+	} else {
+		tokenStr, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(maker.secretKey))
+	}
 
 	if err != nil {
 		return "", nil, fmt.Errorf("error signing token: %w", err)
@@ -40,6 +80,10 @@ func (maker *JWTMaker) CreateToken(id int64, email string, isAdmin bool, duratio
 func (maker *JWTMaker) VerifyToken(tokenStr string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 
+		if maker.keys != nil {
+			return maker.keys.verificationKey(token)
+		}
+
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 
 		if !ok {
@@ -0,0 +1,302 @@
+package token
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	minRSAKeyBits = 2048
+)
+
+var ErrNoSigningKey = errors.New("key set has no signing key")
+
+// * Key - ключ подписи или проверки токенов, ID передается в заголовке kid
+type Key struct {
+	ID        string
+	Algorithm string
+
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// * NewKey принимает закрытый ключ (подпись и проверка) или открытый (только проверка):
+// * *rsa.PrivateKey, *rsa.PublicKey, ed25519.PrivateKey, ed25519.PublicKey
+func NewKey(id string, key any) (*Key, error) {
+	if id == "" {
+		return nil, fmt.Errorf("key id is required")
+	}
+
+	k := &Key{ID: id}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		k.Algorithm, k.private, k.public = AlgRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.Algorithm, k.public = AlgRS256, key
+	case ed25519.PrivateKey:
+		k.Algorithm, k.private, k.public = AlgEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.Algorithm, k.public = AlgEdDSA, key
+	default:
+		return nil, fmt.Errorf("key %q: unsupported key type %T", id, key)
+	}
+
+	if pub, ok := k.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("key %q: RSA key must be at least %d bits", id, minRSAKeyBits)
+	}
+
+	return k, nil
+}
+
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+func (k *Key) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+
+	return jwt.SigningMethodRS256
+}
+
+// * KeySet - ключи проверки токенов и один ключ подписи.
+// * Старые ключи остаются в наборе, пока не истекут подписанные ими токены
+type KeySet struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+}
+
+// * signingKID - ключ подписи, пустой для набора только с ключами проверки
+func NewKeySet(signingKID string, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key, len(keys))}
+
+	for _, k := range keys {
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+
+		ks.keys[k.ID] = k
+	}
+
+	if signingKID != "" {
+		k, ok := ks.keys[signingKID]
+		if !ok || !k.CanSign() {
+			return nil, fmt.Errorf("signing key %q not found or has no private key", signingKID)
+		}
+
+		ks.signing = k
+	}
+
+	return ks, nil
+}
+
+// * Replace заменяет ключи набора, используется при ротации
+func (ks *KeySet) Replace(other *KeySet) {
+	other.mu.RLock()
+	signing, keys := other.signing, other.keys
+	other.mu.RUnlock()
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.signing, ks.keys = signing, keys
+}
+
+func (ks *KeySet) SigningKey() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return ks.signing, nil
+}
+
+func (ks *KeySet) Key(kid string) (*Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+// * ключи набора, отсортированные по id
+func (ks *KeySet) Keys() []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(ks.keys))
+	for _, k := range ks.keys {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	k, err := ks.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	t := jwt.NewWithClaims(k.signingMethod(), claims)
+	t.Header["kid"] = k.ID
+
+	return t.SignedString(k.private)
+}
+
+// * ключ проверки по заголовку kid, алгоритм токена должен совпадать с алгоритмом ключа
+func (ks *KeySet) verificationKey(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid header")
+	}
+
+	k, ok := ks.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	if t.Method.Alg() != k.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", t.Method.Alg(), kid)
+	}
+
+	return k.public, nil
+}
+
+//* ЗАГРУЗКА С ДИСКА
+
+// * LoadKeySet читает PEM файлы *.pem из dir, id ключа - имя файла без расширения.
+// * Закрытые ключи (PKCS#8 или PKCS#1) используются для подписи и проверки, открытые (PKIX) - только для проверки.
+// * Если signingKID пустой, подписывает закрытый ключ с наибольшим id (например 2025-08-15.pem)
+func LoadKeySet(dir, signingKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing keys: %w", err)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.pem keys found in %s", dir)
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	latestKID := ""
+
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+		k, err := loadKey(path, id)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+
+		if k.CanSign() && id > latestKID {
+			latestKID = id
+		}
+	}
+
+	if signingKID == "" {
+		signingKID = latestKID
+	}
+
+	return NewKeySet(signingKID, keys...)
+}
+
+func loadKey(path, id string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", path)
+	}
+
+	var key any
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %s: unsupported PEM block %q", path, block.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key %s: %w", path, err)
+	}
+
+	return NewKey(id, key)
+}
+
+// * WatchKeySet перечитывает ключи из dir каждые interval до отмены ctx.
+// * Ротация: положить новый закрытый ключ (он становится ключом подписи), а старый удалить
+// * или заменить открытым после истечения подписанных им токенов
+func WatchKeySet(ctx context.Context, ks *KeySet, dir, signingKID string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			loaded, err := LoadKeySet(dir, signingKID)
+			if err != nil {
+				log.Printf("error reloading keys from %s, keeping current keys: %v", dir, err)
+				continue
+			}
+
+			ks.Replace(loaded)
+		}
+	}
+}
+
+// * NewMakerFromKeyDir - JWTMaker с ключами из dir, которые перечитываются каждые reload (0 - без перечитывания)
+// * до отмены ctx. Пустой dir означает HS256 с общим секретом secretKey
+func NewMakerFromKeyDir(ctx context.Context, secretKey, dir, signingKID string, reload time.Duration) (*JWTMaker, error) {
+	if dir == "" {
+		return NewJWTMaker(secretKey), nil
+	}
+
+	ks, err := LoadKeySet(dir, signingKID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reload > 0 {
+		go WatchKeySet(ctx, ks, dir, signingKID, reload)
+	}
+
+	return NewKeySetJWTMaker(ks), nil
+}
@@ -0,0 +1,175 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+}
+
+func writePrivateKey(t *testing.T, dir, name string, key any) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	writePEM(t, dir, name, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, dir, name string, key any) {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+
+	writePEM(t, dir, name, "PUBLIC KEY", der)
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "2025-08-01.pem", rsaKey)
+
+	ks, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+
+	maker := NewKeySetJWTMaker(ks)
+
+	oldToken, _, err := maker.CreateToken(1, "user@example.com", false, nil, "session", time.Minute)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, &UserClaims{})
+	require.NoError(t, err)
+	require.Equal(t, "2025-08-01", parsed.Header["kid"])
+	require.Equal(t, AlgRS256, parsed.Method.Alg())
+
+	// * новый ключ становится ключом подписи, токены старого ключа продолжают проверяться
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "2025-08-15.pem", edKey)
+
+	loaded, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	ks.Replace(loaded)
+
+	newToken, _, err := maker.CreateToken(1, "user@example.com", false, nil, "session", time.Minute)
+	require.NoError(t, err)
+
+	parsed, _, err = jwt.NewParser().ParseUnverified(newToken, &UserClaims{})
+	require.NoError(t, err)
+	require.Equal(t, "2025-08-15", parsed.Header["kid"])
+	require.Equal(t, AlgEdDSA, parsed.Method.Alg())
+
+	for _, tok := range []string{oldToken, newToken} {
+		claims, err := maker.VerifyToken(tok)
+		require.NoError(t, err)
+		require.Equal(t, "session", claims.SessionID)
+	}
+
+	// * после удаления старого ключа его токены больше не принимаются
+	require.NoError(t, os.Remove(filepath.Join(dir, "2025-08-01.pem")))
+
+	loaded, err = LoadKeySet(dir, "")
+	require.NoError(t, err)
+	ks.Replace(loaded)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.Error(t, err)
+
+	_, err = maker.VerifyToken(newToken)
+	require.NoError(t, err)
+}
+
+func TestKeySetVerificationOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "signing.pem", rsaKey)
+
+	ks, err := LoadKeySet(dir, "signing")
+	require.NoError(t, err)
+
+	signer := NewKeySetJWTMaker(ks)
+
+	tok, _, err := signer.CreateToken(1, "user@example.com", true, nil, "session", time.Minute)
+	require.NoError(t, err)
+
+	// * сервис с открытым ключом на диске
+	pubDir := t.TempDir()
+	writePublicKey(t, pubDir, "signing.pem", &rsaKey.PublicKey)
+
+	pubKeys, err := LoadKeySet(pubDir, "")
+	require.NoError(t, err)
+
+	// * сервис с JWKS
+	data, err := json.Marshal(signer.JWKS())
+	require.NoError(t, err)
+
+	jwksKeys, err := ParseJWKS(data)
+	require.NoError(t, err)
+
+	for _, keys := range []*KeySet{pubKeys, jwksKeys} {
+		verifier := NewKeySetJWTMaker(keys)
+
+		claims, err := verifier.VerifyToken(tok)
+		require.NoError(t, err)
+		require.True(t, claims.IsAdmin)
+
+		_, _, err = verifier.CreateToken(1, "user@example.com", true, nil, "", time.Minute)
+		require.ErrorIs(t, err, ErrNoSigningKey)
+	}
+
+	// * токен HS256 с kid ключа RSA не принимается
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &UserClaims{ID: 1, IsAdmin: true})
+	forged.Header["kid"] = "signing"
+	forgedStr, err := forged.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+	require.NoError(t, err)
+
+	_, err = signer.VerifyToken(forgedStr)
+	require.Error(t, err)
+
+	_, err = LoadKeySet(pubDir, "signing")
+	require.Error(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	k, err := NewKey("ed", pub)
+	require.NoError(t, err)
+
+	ks, err := NewKeySet("", k)
+	require.NoError(t, err)
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, JWK{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: AlgEdDSA, Curve: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+
+	require.Empty(t, NewJWTMaker("01234567890123456789012345678901").JWKS().Keys)
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	_, err = NewKey("small", small)
+	require.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// * Maker - создание и проверка токенов доступа независимо от формата (JWT или PASETO)
+type Maker interface {
+	// * sessionID - сессия токена доступа, для токена обновления пустая
+	CreateToken(id int64, email string, isAdmin bool, permissions []string, sessionID string, duration time.Duration) (string, *UserClaims, error)
+	// * CreateServiceToken - токен для вызовов ecomm-grpc от имени сервиса service (см. NewServiceClaims)
+	CreateServiceToken(service string, duration time.Duration) (string, *UserClaims, error)
+	VerifyToken(tokenStr string) (*UserClaims, error)
+}
+
+// * KeyPublisher - Maker с открытыми ключами проверки в формате JWKS
+type KeyPublisher interface {
+	JWKS() JWKS
+}
+
+var (
+	_ Maker        = (*JWTMaker)(nil)
+	_ Maker        = (*PasetoMaker)(nil)
+	_ KeyPublisher = (*JWTMaker)(nil)
+)
+
+// * форматы токенов
+const (
+	FormatJWT            = "jwt"
+	FormatPasetoV4Local  = "paseto-v4-local"
+	FormatPasetoV4Public = "paseto-v4-public"
+)
+
+// * Config - формат токенов и ключи для NewMaker
+type Config struct {
+	Format string
+
+	// * JWT: HS256 с SecretKey или RS256/EdDSA с ключами из KeysDir
+	SecretKey  string
+	KeysDir    string
+	SigningKID string
+	KeysReload time.Duration
+
+	// * PASETO v4 (ключи в hex): PasetoLocalKey - симметричный ключ v4.local,
+	// * PasetoSecretKey/PasetoPublicKey - ключ подписи или только ключ проверки v4.public
+	PasetoLocalKey  string
+	PasetoSecretKey string
+	PasetoPublicKey string
+}
+
+// * MinSecretKeySize - минимальная длина SECRET_KEY для HS256
+const MinSecretKeySize = 32
+
+// * бывшее значение SECRET_KEY по умолчанию: оно опубликовано, и с ним любой может выпустить токен сервиса
+const publicSecretKey = "01234567890123456789012345678901"
+
+// * ключ HS256 проверяется одинаково в ecomm-api и ecomm-grpc: любой, кто его знает, выпускает токены
+func checkSecretKey(key string) error {
+	switch {
+	case key == "":
+		return errors.New("SECRET_KEY is required for HS256 tokens, set it or use JWT_KEYS_DIR")
+	case key == publicSecretKey:
+		return errors.New("SECRET_KEY must not be the public example key")
+	case len(key) < MinSecretKeySize:
+		return fmt.Errorf("SECRET_KEY must be at least %d characters long", MinSecretKeySize)
+	}
+
+	return nil
+}
+
+// * NewMaker выбирает реализацию Maker по cfg.Format (пустой формат - JWT)
+func NewMaker(ctx context.Context, cfg Config) (Maker, error) {
+	switch cfg.Format {
+	case "", FormatJWT:
+		if cfg.KeysDir == "" {
+			if err := checkSecretKey(cfg.SecretKey); err != nil {
+				return nil, err
+			}
+		}
+
+		return NewMakerFromKeyDir(ctx, cfg.SecretKey, cfg.KeysDir, cfg.SigningKID, cfg.KeysReload)
+	case FormatPasetoV4Local:
+		return NewPasetoLocalMaker(cfg.PasetoLocalKey)
+	case FormatPasetoV4Public:
+		return NewPasetoPublicMaker(cfg.PasetoSecretKey, cfg.PasetoPublicKey)
+	}
+
+	return nil, fmt.Errorf("unknown token format %q, expected %s, %s or %s", cfg.Format, FormatJWT, FormatPasetoV4Local, FormatPasetoV4Public)
+}
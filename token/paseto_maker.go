@@ -0,0 +1,159 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// * PasetoMaker создает токены PASETO v4.local (шифрование общим ключом)
+// * или v4.public (подпись Ed25519, проверка открытым ключом)
+type PasetoMaker struct {
+	local bool
+
+	localKey  paseto.V4SymmetricKey
+	secretKey *paseto.V4AsymmetricSecretKey
+	publicKey paseto.V4AsymmetricPublicKey
+}
+
+func NewPasetoLocalMaker(keyHex string) (*PasetoMaker, error) {
+	key, err := paseto.V4SymmetricKeyFromHex(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASETO v4.local key: %w", err)
+	}
+
+	return &PasetoMaker{local: true, localKey: key}, nil
+}
+
+// * без secretKeyHex получается Maker только для проверки токенов
+func NewPasetoPublicMaker(secretKeyHex, publicKeyHex string) (*PasetoMaker, error) {
+	maker := &PasetoMaker{}
+
+	if secretKeyHex != "" {
+		key, err := paseto.NewV4AsymmetricSecretKeyFromHex(secretKeyHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PASETO v4.public secret key: %w", err)
+		}
+
+		maker.secretKey = &key
+		maker.publicKey = key.Public()
+
+		return maker, nil
+	}
+
+	key, err := paseto.NewV4AsymmetricPublicKeyFromHex(publicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASETO v4.public public key: %w", err)
+	}
+
+	maker.publicKey = key
+
+	return maker, nil
+}
+
+func (maker *PasetoMaker) CreateToken(id int64, email string, isAdmin bool, permissions []string, sessionID string, duration time.Duration) (string, *UserClaims, error) {
+	if !maker.local && maker.secretKey == nil {
+		return "", nil, ErrNoSigningKey
+	}
+
+	claims, err := NewUserClaims(id, email, isAdmin, permissions, sessionID, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return maker.signClaims(claims)
+}
+
+func (maker *PasetoMaker) CreateServiceToken(service string, duration time.Duration) (string, *UserClaims, error) {
+	if !maker.local && maker.secretKey == nil {
+		return "", nil, ErrNoSigningKey
+	}
+
+	claims, err := NewServiceClaims(service, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return maker.signClaims(claims)
+}
+
+func (maker *PasetoMaker) signClaims(claims *UserClaims) (string, *UserClaims, error) {
+	t := paseto.NewToken()
+	t.SetJti(claims.RegisteredClaims.ID)
+	t.SetSubject(claims.Subject)
+	t.SetIssuedAt(claims.IssuedAt.Time)
+	t.SetNotBefore(claims.IssuedAt.Time)
+	t.SetExpiration(claims.ExpiresAt.Time)
+
+	if claims.IsService() {
+		t.SetAudience(ServiceAudience)
+	}
+
+	for key, value := range map[string]any{"id": claims.ID, "email": claims.Email, "is_admin": claims.IsAdmin, "perms": claims.Permissions, "sid": claims.SessionID} {
+		if err := t.Set(key, value); err != nil {
+			return "", nil, fmt.Errorf("error setting claim %s: %w", key, err)
+		}
+	}
+
+	if maker.local {
+		return t.V4Encrypt(maker.localKey, nil), claims, nil
+	}
+
+	return t.V4Sign(*maker.secretKey, nil), claims, nil
+}
+
+// * проверка подписи (или расшифровка), срока действия и nbf
+func (maker *PasetoMaker) VerifyToken(tokenStr string) (*UserClaims, error) {
+	parser := paseto.NewParser()
+	parser.AddRule(paseto.NotBeforeNbf())
+
+	var (
+		t   *paseto.Token
+		err error
+	)
+
+	if maker.local {
+		t, err = parser.ParseV4Local(maker.localKey, tokenStr, nil)
+	} else {
+		t, err = parser.ParseV4Public(maker.publicKey, tokenStr, nil)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token: %w", err)
+	}
+
+	claims := &UserClaims{}
+
+	if err := t.Get("id", &claims.ID); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	if err := t.Get("is_admin", &claims.IsAdmin); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	if err := t.Get("perms", &claims.Permissions); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	claims.Email, _ = t.GetString("email")
+	claims.SessionID, _ = t.GetString("sid")
+	claims.RegisteredClaims.ID, _ = t.GetJti()
+	claims.Subject, _ = t.GetSubject()
+
+	if aud, err := t.GetAudience(); err == nil {
+		claims.Audience = jwt.ClaimStrings{aud}
+	}
+
+	if iat, err := t.GetIssuedAt(); err == nil {
+		claims.IssuedAt = jwt.NewNumericDate(iat)
+	}
+
+	if exp, err := t.GetExpiration(); err == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(exp)
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,109 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoMaker(t *testing.T) {
+	localKey := paseto.NewV4SymmetricKey()
+	secretKey := paseto.NewV4AsymmetricSecretKey()
+
+	local, err := NewPasetoLocalMaker(localKey.ExportHex())
+	require.NoError(t, err)
+
+	public, err := NewPasetoPublicMaker(secretKey.ExportHex(), "")
+	require.NoError(t, err)
+
+	for name, maker := range map[string]Maker{"v4.local": local, "v4.public": public} {
+		t.Run(name, func(t *testing.T) {
+			tokenStr, created, err := maker.CreateToken(7, "user@example.com", true, []string{"products:write"}, "session", time.Minute)
+			require.NoError(t, err)
+
+			claims, err := maker.VerifyToken(tokenStr)
+			require.NoError(t, err)
+			require.Equal(t, int64(7), claims.ID)
+			require.Equal(t, "user@example.com", claims.Email)
+			require.True(t, claims.IsAdmin)
+			require.Equal(t, "session", claims.SessionID)
+			require.Equal(t, []string{"products:write"}, claims.Permissions)
+			require.Equal(t, created.RegisteredClaims.ID, claims.RegisteredClaims.ID)
+			require.Equal(t, created.ExpiresAt.Unix(), claims.ExpiresAt.Unix())
+			require.False(t, claims.IsService())
+
+			serviceStr, _, err := maker.CreateServiceToken("ecomm-api", time.Minute)
+			require.NoError(t, err)
+
+			service, err := maker.VerifyToken(serviceStr)
+			require.NoError(t, err)
+			require.True(t, service.IsService())
+			require.False(t, service.IsAdmin)
+			require.True(t, service.HasPermission("products:write"))
+			require.Equal(t, "ecomm-api", service.Subject)
+
+			expired, _, err := maker.CreateToken(7, "user@example.com", true, nil, "session", -time.Minute)
+			require.NoError(t, err)
+
+			_, err = maker.VerifyToken(expired)
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("verification only", func(t *testing.T) {
+		verifier, err := NewPasetoPublicMaker("", secretKey.Public().ExportHex())
+		require.NoError(t, err)
+
+		tokenStr, _, err := public.CreateToken(7, "user@example.com", false, nil, "", time.Minute)
+		require.NoError(t, err)
+
+		claims, err := verifier.VerifyToken(tokenStr)
+		require.NoError(t, err)
+		require.Equal(t, int64(7), claims.ID)
+
+		_, _, err = verifier.CreateToken(7, "user@example.com", false, nil, "", time.Minute)
+		require.ErrorIs(t, err, ErrNoSigningKey)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, err := NewPasetoLocalMaker(paseto.NewV4SymmetricKey().ExportHex())
+		require.NoError(t, err)
+
+		tokenStr, _, err := local.CreateToken(7, "user@example.com", false, nil, "", time.Minute)
+		require.NoError(t, err)
+
+		_, err = other.VerifyToken(tokenStr)
+		require.Error(t, err)
+
+		// * токен v4.local не принимается как v4.public и наоборот
+		_, err = public.VerifyToken(tokenStr)
+		require.Error(t, err)
+	})
+}
+
+func TestNewMaker(t *testing.T) {
+	ctx := context.Background()
+
+	maker, err := NewMaker(ctx, Config{SecretKey: "a3f9c1e07b2d4c58e6a1b9d03f7e2c4a"})
+	require.NoError(t, err)
+	require.IsType(t, &JWTMaker{}, maker)
+
+	//* HS256 без ключа, с коротким или опубликованным ключом не запускается
+	for _, key := range []string{"", "short", "01234567890123456789012345678901"} {
+		_, err = NewMaker(ctx, Config{Format: FormatJWT, SecretKey: key})
+		require.Error(t, err, key)
+	}
+
+	maker, err = NewMaker(ctx, Config{Format: FormatPasetoV4Local, PasetoLocalKey: paseto.NewV4SymmetricKey().ExportHex()})
+	require.NoError(t, err)
+	require.IsType(t, &PasetoMaker{}, maker)
+
+	_, err = NewMaker(ctx, Config{Format: FormatPasetoV4Public})
+	require.Error(t, err)
+
+	_, err = NewMaker(ctx, Config{Format: "jwe"})
+	require.Error(t, err)
+}
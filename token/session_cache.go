@@ -0,0 +1,86 @@
+package token
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// * при переполнении кэш сначала удаляет устаревшие записи, затем очищается целиком
+const maxSessionCacheEntries = 10000
+
+// * SessionCache - состояние сессий в памяти процесса, чтобы проверка токена доступа
+// * не обращалась к хранилищу сессий на каждый запрос. Отзыв через Revoke виден сразу,
+// * отзыв в другом месте - не позже чем через ttl
+type SessionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]sessionCacheEntry
+
+	lookup func(ctx context.Context, sessionID string) (bool, error)
+	now    func() time.Time
+}
+
+type sessionCacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// * lookup возвращает true, если сессия отозвана или не найдена
+func NewSessionCache(ttl time.Duration, lookup func(ctx context.Context, sessionID string) (bool, error)) *SessionCache {
+	return &SessionCache{
+		ttl:     ttl,
+		entries: make(map[string]sessionCacheEntry),
+		lookup:  lookup,
+		now:     time.Now,
+	}
+}
+
+func (c *SessionCache) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	e, ok := c.entries[sessionID]
+	c.mu.Unlock()
+
+	if ok && now.Before(e.expiresAt) {
+		return e.revoked, nil
+	}
+
+	revoked, err := c.lookup(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	c.set(sessionID, revoked, now)
+
+	return revoked, nil
+}
+
+// * Revoke отмечает сессию отозванной без обращения к хранилищу
+func (c *SessionCache) Revoke(sessionIDs ...string) {
+	now := c.now()
+
+	for _, id := range sessionIDs {
+		c.set(id, true, now)
+	}
+}
+
+func (c *SessionCache) set(sessionID string, revoked bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxSessionCacheEntries {
+		for id, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+
+		if len(c.entries) >= maxSessionCacheEntries {
+			clear(c.entries)
+		}
+	}
+
+	c.entries[sessionID] = sessionCacheEntry{revoked: revoked, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,62 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionCache(t *testing.T) {
+	calls := 0
+	revoked := map[string]bool{"revoked": true}
+
+	cache := NewSessionCache(time.Minute, func(ctx context.Context, id string) (bool, error) {
+		calls++
+		if id == "broken" {
+			return false, errors.New("unavailable")
+		}
+
+		return revoked[id], nil
+	})
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	ok, err := cache.IsRevoked(context.Background(), "active")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = cache.IsRevoked(context.Background(), "revoked")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, calls)
+
+	// * в пределах ttl ответ берется из кэша
+	_, err = cache.IsRevoked(context.Background(), "active")
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	// * локальный отзыв виден сразу
+	cache.Revoke("active")
+	ok, err = cache.IsRevoked(context.Background(), "active")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, calls)
+
+	// * после ttl состояние запрашивается снова
+	now = now.Add(2 * time.Minute)
+	ok, err = cache.IsRevoked(context.Background(), "active")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, 3, calls)
+
+	// * ошибки не кэшируются
+	_, err = cache.IsRevoked(context.Background(), "broken")
+	require.Error(t, err)
+	_, err = cache.IsRevoked(context.Background(), "broken")
+	require.Error(t, err)
+	require.Equal(t, 5, calls)
+}
@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// * префикс зашифрованного секрета, секреты без него хранятся открытым текстом
+const sealedPrefix = "v1:"
+
+var ErrNoSecretKey = errors.New("totp secret is encrypted, but no encryption key is configured")
+
+// * SecretCipher шифрует секреты TOTP для хранения в базе (AES-256-GCM).
+// * Шифротекст привязан к пользователю, секрет другого пользователя не расшифруется.
+// * nil *SecretCipher хранит новые секреты открытым текстом
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// * keyHex - 32 байта в hex
+func NewSecretCipher(keyHex string) (*SecretCipher, error) {
+	key, err := hex.DecodeString(keyHex)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("totp encryption key must be 32 bytes encoded in hex")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating totp cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating totp cipher: %w", err)
+	}
+
+	return &SecretCipher{aead: aead}, nil
+}
+
+// * Seal - значение для хранения секрета пользователя userID
+func (c *SecretCipher) Seal(userID int64, secret string) (string, error) {
+	if c == nil {
+		return secret, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error encrypting totp secret: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), userData(userID))
+
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// * Open - секрет из сохраненного значения. Секреты, сохраненные без шифрования, возвращаются как есть
+func (c *SecretCipher) Open(userID int64, stored string) (string, error) {
+	data, ok := strings.CutPrefix(stored, sealedPrefix)
+	if !ok {
+		return stored, nil
+	}
+
+	if c == nil {
+		return "", ErrNoSecretKey
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("invalid encrypted totp secret")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+
+	secret, err := c.aead.Open(nil, nonce, ciphertext, userData(userID))
+	if err != nil {
+		return "", fmt.Errorf("error decrypting totp secret: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+func userData(userID int64) []byte {
+	return []byte("user:" + strconv.FormatInt(userID, 10))
+}
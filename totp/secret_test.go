@@ -0,0 +1,46 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretCipher(t *testing.T) {
+	c, err := NewSecretCipher(strings.Repeat("ab", 32))
+	require.NoError(t, err)
+
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	sealed, err := c.Seal(7, secret)
+	require.NoError(t, err)
+	require.NotContains(t, sealed, secret)
+
+	opened, err := c.Open(7, sealed)
+	require.NoError(t, err)
+	require.Equal(t, secret, opened)
+
+	//* секрет привязан к пользователю
+	_, err = c.Open(8, sealed)
+	require.Error(t, err)
+
+	//* секреты, сохраненные до включения шифрования
+	opened, err = c.Open(7, secret)
+	require.NoError(t, err)
+	require.Equal(t, secret, opened)
+
+	//* без ключа новые секреты не шифруются, а зашифрованные не читаются
+	var plain *SecretCipher
+
+	stored, err := plain.Seal(7, secret)
+	require.NoError(t, err)
+	require.Equal(t, secret, stored)
+
+	_, err = plain.Open(7, sealed)
+	require.ErrorIs(t, err, ErrNoSecretKey)
+
+	_, err = NewSecretCipher("short")
+	require.Error(t, err)
+}
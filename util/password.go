@@ -1,14 +1,140 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrPasswordMismatch  = errors.New("password does not match")
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+)
+
+// * Argon2Params - параметры argon2id, Memory в KiB
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// * рекомендации OWASP для argon2id с запасом по памяти
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// * PasswordConfig - чем хэшируются новые пароли. Проверяются хэши любого поддерживаемого алгоритма
+type PasswordConfig struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// * PasswordConfigFromEnv читает настройки хэширования новых паролей: PASSWORD_HASH (bcrypt или argon2id),
+// * BCRYPT_COST, ARGON2_MEMORY в KiB, ARGON2_ITERATIONS и ARGON2_PARALLELISM. Пустые переменные - значения
+// * по умолчанию. Все процессы, которые хэшируют пароли, берут настройки отсюда, чтобы они совпадали
+func PasswordConfigFromEnv() (PasswordConfig, error) {
+	cfg := PasswordConfig{Algorithm: AlgorithmBcrypt, Argon2: DefaultArgon2Params}
+
+	if v := os.Getenv("PASSWORD_HASH"); v != "" {
+		cfg.Algorithm = v
+	}
+
+	cost, err := envUint("BCRYPT_COST", 8, uint64(bcrypt.DefaultCost))
+	if err != nil {
+		return cfg, err
+	}
+
+	memory, err := envUint("ARGON2_MEMORY", 32, uint64(DefaultArgon2Params.Memory))
+	if err != nil {
+		return cfg, err
+	}
+
+	iterations, err := envUint("ARGON2_ITERATIONS", 32, uint64(DefaultArgon2Params.Iterations))
+	if err != nil {
+		return cfg, err
+	}
+
+	parallelism, err := envUint("ARGON2_PARALLELISM", 8, uint64(DefaultArgon2Params.Parallelism))
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.BcryptCost = int(cost)
+	cfg.Argon2.Memory = uint32(memory)
+	cfg.Argon2.Iterations = uint32(iterations)
+	cfg.Argon2.Parallelism = uint8(parallelism)
+
+	return cfg, nil
+}
+
+func envUint(name string, bitSize int, value uint64) (uint64, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return value, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return n, nil
+}
+
+// * PasswordHasher хэширует новые пароли и определяет, устарели ли параметры сохраненного хэша
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// * NeedsRehash - хэш создан другим алгоритмом или с другими параметрами
+	NeedsRehash(hashedPassword string) bool
+}
+
+// * NewPasswordHasher проверяет параметры алгоритма из cfg
+func NewPasswordHasher(cfg PasswordConfig) (PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case "", AlgorithmBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+
+		return BcryptHasher{Cost: cfg.BcryptCost}, nil
+	case AlgorithmArgon2id:
+		p := cfg.Argon2
+		if p.Iterations < 1 || p.Parallelism < 1 || p.Memory < 8*uint32(p.Parallelism) || p.SaltLength < 8 || p.KeyLength < 16 {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+
+		return Argon2idHasher{Params: p}, nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q, expected %s or %s", cfg.Algorithm, AlgorithmBcrypt, AlgorithmArgon2id)
+	}
+}
+
+type BcryptHasher struct {
+	Cost int
+}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (b BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
 
 	if err != nil {
 		return "", fmt.Errorf("error hashing password: %w", err)
@@ -17,7 +143,109 @@ func HashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
-// проверка пароля
+func (b BcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != b.Cost
+}
+
+// * хэш в формате PHC: $argon2id$v=19$m=65536,t=3,p=2$<соль>$<ключ>
+type Argon2idHasher struct {
+	Params Argon2Params
+}
+
+func (a Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.Params.SaltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Params.Iterations, a.Params.Memory, a.Params.Parallelism, a.Params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, a.Params.Memory, a.Params.Iterations, a.Params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	p, _, _, err := decodeArgon2id(hashedPassword)
+	return err != nil || p != a.Params
+}
+
+func decodeArgon2id(hashedPassword string) (Argon2Params, []byte, []byte, error) {
+	var (
+		p       Argon2Params
+		version int
+	)
+
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
+
+// * хэшер новых паролей процесса, по умолчанию bcrypt со стоимостью DefaultCost
+var passwordHasher PasswordHasher = BcryptHasher{Cost: bcrypt.DefaultCost}
+
+// * SetPasswordHasher вызывается при запуске до обработки запросов
+func SetPasswordHasher(h PasswordHasher) {
+	passwordHasher = h
+}
+
+func HashPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+// * NeedsRehash - хэш нужно пересчитать текущим хэшером после успешной проверки пароля
+func NeedsRehash(hashedPassword string) bool {
+	return passwordHasher.NeedsRehash(hashedPassword)
+}
+
+// проверка пароля, алгоритм определяется по префиксу хэша
 func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-}
\ No newline at end of file
+	switch {
+	case strings.HasPrefix(hashedPassword, "$argon2id$"):
+		p, salt, key, err := decodeArgon2id(hashedPassword)
+		if err != nil {
+			return err
+		}
+
+		other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrPasswordMismatch
+		}
+
+		return nil
+	case strings.HasPrefix(hashedPassword, "$2a$"), strings.HasPrefix(hashedPassword, "$2b$"), strings.HasPrefix(hashedPassword, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+
+		return err
+	default:
+		return ErrUnknownHashFormat
+	}
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// * быстрые параметры, чтобы тесты не тратили 64 MiB на хэш
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestNewPasswordHasher(t *testing.T) {
+	_, err := NewPasswordHasher(PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 3})
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(PasswordConfig{Algorithm: AlgorithmArgon2id})
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(PasswordConfig{Algorithm: "md5"})
+	require.Error(t, err)
+
+	h, err := NewPasswordHasher(PasswordConfig{Algorithm: AlgorithmArgon2id, Argon2: DefaultArgon2Params})
+	require.NoError(t, err)
+	require.Equal(t, Argon2idHasher{Params: DefaultArgon2Params}, h)
+}
+
+func TestCheckPassword(t *testing.T) {
+	hashers := map[string]PasswordHasher{
+		"bcrypt":   BcryptHasher{Cost: bcrypt.MinCost},
+		"argon2id": Argon2idHasher{Params: testArgon2Params},
+	}
+
+	for name, h := range hashers {
+		t.Run(name, func(t *testing.T) {
+			hashed, err := h.Hash("password")
+			require.NoError(t, err)
+
+			require.NoError(t, CheckPassword("password", hashed))
+			require.ErrorIs(t, CheckPassword("wrong-password", hashed), ErrPasswordMismatch)
+			require.False(t, h.NeedsRehash(hashed))
+
+			other, err := h.Hash("password")
+			require.NoError(t, err)
+			require.NotEqual(t, hashed, other)
+		})
+	}
+
+	require.ErrorIs(t, CheckPassword("password", "plain"), ErrUnknownHashFormat)
+	require.ErrorIs(t, CheckPassword("password", "$argon2id$v=19$broken"), ErrUnknownHashFormat)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	oldBcrypt, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("password")
+	require.NoError(t, err)
+
+	argon, err := Argon2idHasher{Params: testArgon2Params}.Hash("password")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(argon, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	//* стоимость bcrypt повышена
+	require.True(t, BcryptHasher{Cost: bcrypt.MinCost + 1}.NeedsRehash(oldBcrypt))
+	require.True(t, BcryptHasher{Cost: bcrypt.MinCost}.NeedsRehash(argon))
+
+	//* переход на argon2id и изменение его параметров
+	require.True(t, Argon2idHasher{Params: testArgon2Params}.NeedsRehash(oldBcrypt))
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	require.True(t, Argon2idHasher{Params: stronger}.NeedsRehash(argon))
+}
+
+func TestPasswordConfigFromEnv(t *testing.T) {
+	for _, name := range []string{"PASSWORD_HASH", "BCRYPT_COST", "ARGON2_MEMORY", "ARGON2_ITERATIONS", "ARGON2_PARALLELISM"} {
+		t.Setenv(name, "")
+	}
+
+	cfg, err := PasswordConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.DefaultCost, Argon2: DefaultArgon2Params}, cfg)
+
+	t.Setenv("PASSWORD_HASH", AlgorithmArgon2id)
+	t.Setenv("ARGON2_MEMORY", "32768")
+	t.Setenv("ARGON2_PARALLELISM", "4")
+
+	cfg, err = PasswordConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, AlgorithmArgon2id, cfg.Algorithm)
+	require.Equal(t, uint32(32768), cfg.Argon2.Memory)
+	require.Equal(t, uint8(4), cfg.Argon2.Parallelism)
+	require.Equal(t, DefaultArgon2Params.Iterations, cfg.Argon2.Iterations)
+
+	t.Setenv("ARGON2_PARALLELISM", "256")
+	_, err = PasswordConfigFromEnv()
+	require.ErrorContains(t, err, "ARGON2_PARALLELISM")
+}
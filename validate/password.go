@@ -0,0 +1,70 @@
+package validate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const DefaultMinPasswordLength = minPasswordLen
+
+// * PasswordPolicy - требования к новому открытому паролю: длина и отсутствие в списке
+// * утекших паролей. Список - локальный файл, по одному паролю в строке, регистр не учитывается
+type PasswordPolicy struct {
+	MinLength int
+
+	breached map[string]struct{}
+}
+
+// * breachedFile можно не задавать, тогда проверяется только длина
+func NewPasswordPolicy(minLength int, breachedFile string) (*PasswordPolicy, error) {
+	if minLength < 1 || minLength > maxPasswordLen {
+		return nil, fmt.Errorf("minimum password length must be between 1 and %d", maxPasswordLen)
+	}
+
+	p := &PasswordPolicy{MinLength: minLength}
+
+	if breachedFile == "" {
+		return p, nil
+	}
+
+	f, err := os.Open(breachedFile)
+	if err != nil {
+		return nil, fmt.Errorf("error opening breached password list: %w", err)
+	}
+	defer f.Close()
+
+	p.breached = make(map[string]struct{})
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if password := strings.TrimSpace(scanner.Text()); password != "" {
+			p.breached[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading breached password list: %w", err)
+	}
+
+	return p, nil
+}
+
+// * правила для поля field с новым паролем
+func (p *PasswordPolicy) Rules(field string) Rules {
+	return Rules{
+		Field(field, Required(), MinLen(p.MinLength), MaxBytes(maxPasswordLen), p.notBreached()),
+	}
+}
+
+func (p *PasswordPolicy) notBreached() Rule {
+	return func(field string, v any) Errors {
+		s, _ := v.(string)
+		if _, ok := p.breached[strings.ToLower(s)]; ok {
+			return fail(field, "is too common or has appeared in a data breach")
+		}
+
+		return nil
+	}
+}
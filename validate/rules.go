@@ -0,0 +1,117 @@
+package validate
+
+// * общие правила для REST запросов ecomm-api и gRPC сообщений ecomm-grpc
+
+const (
+	maxStringLen     = 255
+	minPasswordLen   = 8
+	maxPasswordLen   = 72 // * в байтах: bcrypt не принимает пароли длиннее 72 байт
+	maxProductRating = 5
+	maxRoleNameLen   = 64
+	// * "account:" + email, "ip:" + адрес или оба через "|"
+	maxLoginAttemptKeyLen = 320
+)
+
+var ProductCreate = Rules{
+	Field("name", Required(), MaxLen(maxStringLen)),
+	Field("image", Required(), MaxLen(maxStringLen)),
+	Field("category", Required(), MaxLen(maxStringLen)),
+	Field("price", Positive()),
+	Field("count_in_stock", Min(0)),
+	Field("rating", Min(0), Max(maxProductRating)),
+	Field("num_reviews", Min(0)),
+}
+
+// * при частичном обновлении пустые поля не меняются, поэтому они не обязательны
+var ProductUpdate = Rules{
+	Field("name", MaxLen(maxStringLen)),
+	Field("image", MaxLen(maxStringLen)),
+	Field("category", MaxLen(maxStringLen)),
+	Field("price", Min(0)),
+	Field("count_in_stock", Min(0)),
+	Field("rating", Min(0), Max(maxProductRating)),
+	Field("num_reviews", Min(0)),
+}
+
+var OrderItem = Rules{
+	Field("product_id", Positive()),
+	Field("quantity", Positive()),
+	Field("price", Min(0)),
+}
+
+var Order = Rules{
+	Field("items", Required(), Each(OrderItem)),
+	Field("payment_method", MaxLen(maxStringLen)),
+	Field("tax_price", Min(0)),
+	Field("shipping_price", Min(0)),
+	Field("total_price", Min(0)),
+}
+
+// * требования к новому паролю (PasswordPolicy) проверяет ecomm-api,
+// * ecomm-grpc перед хэшированием проверяет только длину в байтах (HashablePassword)
+var User = Rules{
+	Field("name", Required(), MaxLen(maxStringLen)),
+	Field("email", Required(), Email(), MaxLen(maxStringLen)),
+	Field("password", Required()),
+}
+
+var UserUpdate = Rules{
+	Field("name", MaxLen(maxStringLen)),
+	Field("email", Email(), MaxLen(maxStringLen)),
+}
+
+var Password = Rules{
+	Field("password", Required(), MinLen(minPasswordLen), MaxBytes(maxPasswordLen)),
+}
+
+// * пароль в gRPC запросе, который хэширует ecomm-grpc
+var HashablePassword = Rules{
+	Field("password", MaxBytes(maxPasswordLen)),
+}
+
+var RoleAssignment = Rules{
+	Field("user_id", Positive()),
+	Field("name", Required(), MaxLen(maxRoleNameLen)),
+}
+
+var Login = Rules{
+	Field("email", Required(), Email()),
+	Field("password", Required()),
+}
+
+// * gRPC запросы сброса пароля: ecomm-api передает только sha256 хэш токена из письма
+var PasswordResetCreate = Rules{
+	Field("email", Required(), Email()),
+	Field("token_hash", Required()),
+	Field("expires_at", Required()),
+}
+
+var PasswordReset = Rules{
+	Field("token_hash", Required()),
+	Field("password", Required(), MaxBytes(maxPasswordLen)),
+}
+
+var PasswordChange = Rules{
+	Field("email", Required(), Email()),
+	Field("current_password", Required()),
+	Field("new_password", Required(), MaxBytes(maxPasswordLen)),
+}
+
+var TOTPEnrollment = Rules{
+	Field("user_id", Positive()),
+	Field("secret", Required()),
+}
+
+var LoginAttempt = Rules{
+	Field("key", Required(), MaxLen(maxLoginAttemptKeyLen)),
+}
+
+var LoginAttemptFailure = Rules{
+	Field("key", Required(), MaxLen(maxLoginAttemptKeyLen)),
+	Field("window", Required()),
+}
+
+var LoginAttemptRemoval = Rules{
+	Field("key", Required(), MaxLen(maxLoginAttemptKeyLen)),
+	Field("failure_at", Required()),
+}
@@ -0,0 +1,342 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// * FieldError - ошибка валидации конкретного поля, Field - путь к полю (items[0].quantity)
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// * Rule проверяет значение поля и возвращает ошибки.
+// * Значение приводится к string, float64, bool, []any или Source (вложенная структура), nil если поля нет
+type Rule func(field string, v any) Errors
+
+type FieldRules struct {
+	Name  string
+	Rules []Rule
+}
+
+// * Rules - декларативный набор правил, поля проверяются в порядке объявления.
+// * Имена полей совпадают с json тегами REST запросов и именами полей proto сообщений
+type Rules []FieldRules
+
+func Field(name string, rules ...Rule) FieldRules {
+	return FieldRules{Name: name, Rules: rules}
+}
+
+// * Source - источник значений полей (структура с json тегами или proto сообщение)
+type Source interface {
+	Value(field string) any
+}
+
+// * проверка структуры по json тегам
+func Struct(s any, rules ...Rules) Errors {
+	return check(structSource{reflect.ValueOf(s)}, "", rules...)
+}
+
+// * проверка proto сообщения по именам полей
+func Message(m proto.Message, rules ...Rules) Errors {
+	return check(messageSource{m.ProtoReflect()}, "", rules...)
+}
+
+// * по каждому полю возвращается только первая ошибка (для списков - по каждому элементу)
+func check(src Source, prefix string, rules ...Rules) Errors {
+	var errs Errors
+	failed := make(map[string]bool)
+
+	for _, set := range rules {
+		for _, f := range set {
+			field := f.Name
+			if prefix != "" {
+				field = prefix + "." + f.Name
+			}
+
+			// * поле может встречаться в нескольких наборах правил
+			if failed[field] {
+				continue
+			}
+
+			v := src.Value(f.Name)
+
+			for _, rule := range f.Rules {
+				if fe := rule(field, v); len(fe) > 0 {
+					errs = append(errs, fe...)
+					failed[field] = true
+					break
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+func fail(field, format string, args ...any) Errors {
+	return Errors{{Field: field, Message: fmt.Sprintf(format, args...)}}
+}
+
+//* RULES
+
+func Required() Rule {
+	return func(field string, v any) Errors {
+		if isZero(v) {
+			return fail(field, "is required")
+		}
+
+		return nil
+	}
+}
+
+// * правила для строк пропускают пустое значение, обязательность задается Required
+func Email() Rule {
+	return func(field string, v any) Errors {
+		s, _ := v.(string)
+		if s == "" {
+			return nil
+		}
+
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return fail(field, "must be a valid email address")
+		}
+
+		return nil
+	}
+}
+
+func MinLen(n int) Rule {
+	return func(field string, v any) Errors {
+		s, _ := v.(string)
+		if s != "" && utf8.RuneCountInString(s) < n {
+			return fail(field, "must be at least %d characters long", n)
+		}
+
+		return nil
+	}
+}
+
+func MaxLen(n int) Rule {
+	return func(field string, v any) Errors {
+		s, _ := v.(string)
+		if utf8.RuneCountInString(s) > n {
+			return fail(field, "must be at most %d characters long", n)
+		}
+
+		return nil
+	}
+}
+
+// * MaxBytes ограничивает длину строки в байтах UTF-8, а не в символах
+func MaxBytes(n int) Rule {
+	return func(field string, v any) Errors {
+		s, _ := v.(string)
+		if len(s) > n {
+			return fail(field, "must be at most %d bytes long", n)
+		}
+
+		return nil
+	}
+}
+
+func Min(min float64) Rule {
+	return func(field string, v any) Errors {
+		if n, ok := v.(float64); ok && n < min {
+			return fail(field, "must be greater than or equal to %v", min)
+		}
+
+		return nil
+	}
+}
+
+func Max(max float64) Rule {
+	return func(field string, v any) Errors {
+		if n, ok := v.(float64); ok && n > max {
+			return fail(field, "must be less than or equal to %v", max)
+		}
+
+		return nil
+	}
+}
+
+func Positive() Rule {
+	return func(field string, v any) Errors {
+		if n, ok := v.(float64); ok && n <= 0 {
+			return fail(field, "must be greater than 0")
+		}
+
+		return nil
+	}
+}
+
+// * Each проверяет каждый элемент списка по вложенным правилам
+func Each(rules Rules) Rule {
+	return func(field string, v any) Errors {
+		list, _ := v.([]any)
+
+		var errs Errors
+		for i, item := range list {
+			prefix := fmt.Sprintf("%s[%d]", field, i)
+
+			src, ok := item.(Source)
+			if !ok {
+				errs = append(errs, fail(prefix, "is required")...)
+				continue
+			}
+
+			errs = append(errs, check(src, prefix, rules)...)
+		}
+
+		return errs
+	}
+}
+
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []any:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+//* SOURCES
+
+type structSource struct {
+	v reflect.Value
+}
+
+func (s structSource) Value(field string) any {
+	v := indirect(s.v)
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == field {
+			return reflectValue(v.Field(i))
+		}
+	}
+
+	return nil
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func reflectValue(v reflect.Value) any {
+	v = indirect(v)
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Slice, reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = reflectValue(v.Index(i))
+		}
+		return list
+	case reflect.Struct:
+		return structSource{v}
+	}
+
+	return nil
+}
+
+type messageSource struct {
+	m protoreflect.Message
+}
+
+func (s messageSource) Value(field string) any {
+	fd := s.m.Descriptor().Fields().ByName(protoreflect.Name(field))
+	if fd == nil {
+		return nil
+	}
+
+	if fd.IsList() {
+		l := s.m.Get(fd).List()
+		list := make([]any, l.Len())
+		for i := range list {
+			list[i] = protoValue(fd, l.Get(i))
+		}
+		return list
+	}
+
+	if fd.Message() != nil && !s.m.Has(fd) {
+		return nil
+	}
+
+	return protoValue(fd, s.m.Get(fd))
+}
+
+func protoValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return float64(v.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return float64(v.Uint())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.EnumKind:
+		return float64(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSource{v.Message()}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package validate
+
+import (
+	"davidHwang/ecomm/ecomm-grpc/pb"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type orderItem struct {
+	Quantity  int64   `json:"quantity"`
+	Price     float32 `json:"price"`
+	ProductID int64   `json:"product_id"`
+}
+
+type order struct {
+	Items         []*orderItem `json:"items"`
+	PaymentMethod string       `json:"payment_method"`
+	TotalPrice    float32      `json:"total_price"`
+}
+
+type user struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+}
+
+func TestRules(t *testing.T) {
+	tcs := []struct {
+		name    string
+		req     any
+		message *pb.OrderReq
+		rules   []Rules
+		errs    Errors
+	}{
+		{
+			name:    "valid order",
+			req:     order{Items: []*orderItem{{Quantity: 1, ProductID: 1}}},
+			message: &pb.OrderReq{Items: []*pb.OrderItem{{Quantity: 1, ProductId: 1}}},
+			rules:   []Rules{Order},
+		},
+		{
+			name:    "order without items",
+			req:     order{},
+			message: &pb.OrderReq{},
+			rules:   []Rules{Order},
+			errs:    Errors{{Field: "items", Message: "is required"}},
+		},
+		{
+			name:    "invalid order items",
+			req:     order{Items: []*orderItem{{Quantity: 1, ProductID: 1}, {Quantity: 0, ProductID: 2, Price: -1}}, TotalPrice: -5},
+			message: &pb.OrderReq{Items: []*pb.OrderItem{{Quantity: 1, ProductId: 1}, {Quantity: 0, ProductId: 2, Price: -1}}, TotalPrice: -5},
+			rules:   []Rules{Order},
+			errs: Errors{
+				{Field: "items[1].quantity", Message: "must be greater than 0"},
+				{Field: "items[1].price", Message: "must be greater than or equal to 0"},
+				{Field: "total_price", Message: "must be greater than or equal to 0"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.errs, Struct(tc.req, tc.rules...))
+			require.Equal(t, tc.errs, Message(tc.message, tc.rules...))
+		})
+	}
+}
+
+func TestUserRules(t *testing.T) {
+	errs := Struct(user{Email: "not an email", Password: "short"}, User, Password)
+	require.Equal(t, Errors{
+		{Field: "name", Message: "is required"},
+		{Field: "email", Message: "must be a valid email address"},
+		{Field: "password", Message: "must be at least 8 characters long"},
+	}, errs)
+
+	// * одно поле в двух наборах правил дает одну ошибку
+	errs = Struct(&user{Name: "user", Email: "user@example.com"}, User, Password)
+	require.Equal(t, Errors{{Field: "password", Message: "is required"}}, errs)
+
+	// * 40 символов кириллицы - 80 байт, больше лимита bcrypt
+	errs = Struct(&user{Name: "user", Email: "user@example.com", Password: strings.Repeat("ж", 40)}, User, Password)
+	require.Equal(t, Errors{{Field: "password", Message: "must be at most 72 bytes long"}}, errs)
+
+	errs = Message(&pb.UserReq{Name: "user", Email: "user@example.com", Password: "pass"}, User, HashablePassword)
+	require.Empty(t, errs)
+
+	errs = Message(&pb.UserReq{Name: "user", Email: "user@example.com", Password: strings.Repeat("ж", 40)}, User, HashablePassword)
+	require.Equal(t, Errors{{Field: "password", Message: "must be at most 72 bytes long"}}, errs)
+
+	require.Empty(t, Struct(user{}, UserUpdate))
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(list, []byte("password123\n\n  Qwerty12345  \n"), 0o600))
+
+	policy, err := NewPasswordPolicy(10, list)
+	require.NoError(t, err)
+
+	type req struct {
+		NewPassword string `json:"new_password"`
+	}
+
+	tcs := []struct {
+		password string
+		errs     Errors
+	}{
+		{"", Errors{{Field: "new_password", Message: "is required"}}},
+		{"short-one", Errors{{Field: "new_password", Message: "must be at least 10 characters long"}}},
+		{"qwerty12345", Errors{{Field: "new_password", Message: "is too common or has appeared in a data breach"}}},
+		{"correct horse battery", nil},
+		{strings.Repeat("пароль", 7), Errors{{Field: "new_password", Message: "must be at most 72 bytes long"}}},
+	}
+
+	for _, tc := range tcs {
+		require.Equal(t, tc.errs, Struct(req{NewPassword: tc.password}, policy.Rules("new_password")), tc.password)
+	}
+
+	_, err = NewPasswordPolicy(0, "")
+	require.Error(t, err)
+
+	_, err = NewPasswordPolicy(8, filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}